@@ -0,0 +1,108 @@
+// Command bensin menjalankan tugas administrasi untuk file .bensin.
+//
+// Penggunaan:
+//
+//	bensin backup <database> <file-backup>
+//	bensin restore [-until WAKTU] [-wal FILE]... <file-backup> <database>
+//
+// backup membuka database hanya-baca, jadi bisa berjalan bersama engine
+// hanya-baca lain tetapi tidak selagi engine penulis memegang file itu;
+// untuk backup tanpa henti pakai Engine.Backup dari dalam proses penulis.
+// restore membuat database baru dari backup dan menjalankan ulang catatan
+// WAL sampai WAKTU (RFC 3339), lihat engine.RestoreTo.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+const usage = `Penggunaan:
+  bensin backup <database> <file-backup>
+  bensin restore [-until WAKTU] [-wal FILE]... <file-backup> <database>
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "backup":
+		err = backup(ctx, os.Args[2:])
+	case "restore":
+		err = restore(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bensin %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func backup(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	db, err := engine.OpenTangkiWithOptions(flags.Arg(0), engine.Options{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	out, err := os.Create(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	err = db.Backup(ctx, out)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(flags.Arg(1))
+	}
+	return err
+}
+
+func restore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	until := flags.String("until", "", "pulihkan sampai waktu ini (RFC 3339); kosong berarti semua catatan WAL")
+	var wals []string
+	flags.Func("wal", "file WAL atau arsip WAL, urut dari yang terlama (boleh berulang)", func(s string) error {
+		wals = append(wals, s)
+		return nil
+	})
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var t time.Time
+	if *until != "" {
+		var err error
+		if t, err = time.Parse(time.RFC3339, strings.TrimSpace(*until)); err != nil {
+			return fmt.Errorf("waktu -until tidak valid: %v", err)
+		}
+	}
+	return engine.RestoreTo(flags.Arg(1), flags.Arg(0), t, wals...)
+}
@@ -0,0 +1,314 @@
+// Package arrow menulis data dalam format Apache Arrow IPC tanpa pustaka
+// luar, baik format stream (.arrows) maupun format file (.arrow), agar
+// hasil query bisa dibaca langsung oleh pandas, Polars, atau DuckDB.
+//
+// INT menjadi Int64, FLOAT menjadi Float64, dan TEKS menjadi Utf8. Semua
+// field nullable; NULL ditandai di bitmap validitas.
+package arrow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// DefaultBatchSize adalah jumlah baris per record batch.
+const DefaultBatchSize = 64 * 1024
+
+// maxBatchBytes membatasi isi kolom TEKS satu batch karena offset Utf8
+// hanya 32 bit.
+const maxBatchBytes = 1 << 30
+
+const fileMagic = "ARROW1"
+
+// Konstanta dari Schema.fbs dan Message.fbs.
+const (
+	metadataV5       = 4
+	headerSchema     = 1
+	headerRecord     = 3
+	typeInt          = 2
+	typeFloatingPt   = 3
+	typeUtf8         = 5
+	precisionDouble  = 2
+	fieldNodeSize    = 16
+	bufferSize       = 16
+	blockSize        = 24
+	continuationMark = 0xffffffff
+)
+
+// Writer menulis baris sebagai record batch Arrow. Baris ditampung sampai
+// DefaultBatchSize, jadi memori yang dipakai tidak bergantung pada jumlah
+// seluruh baris.
+type Writer struct {
+	w       io.Writer
+	file    bool
+	columns []tangki.Column
+	types   []*table
+	builds  []*columnBuilder
+	rows    int
+	bytes   int
+	offset  int64
+	schema  bool
+	batches []block
+	err     error
+}
+
+// block adalah posisi satu record batch untuk footer format file.
+type block struct {
+	offset   int64
+	metadata int32
+	body     int64
+}
+
+// NewStreamWriter membuat Writer untuk format stream Arrow IPC.
+func NewStreamWriter(w io.Writer, columns []tangki.Column) (*Writer, error) {
+	return newWriter(w, columns, false)
+}
+
+// NewFileWriter membuat Writer untuk format file Arrow IPC (Feather v2),
+// yang punya footer sehingga batch bisa dibaca secara acak.
+func NewFileWriter(w io.Writer, columns []tangki.Column) (*Writer, error) {
+	return newWriter(w, columns, true)
+}
+
+func newWriter(w io.Writer, columns []tangki.Column, file bool) (*Writer, error) {
+	aw := &Writer{w: w, file: file, columns: columns}
+	for _, col := range columns {
+		var typ *table
+		switch col.Type {
+		case "INT":
+			typ = (&table{}).i32(0, 64).bool(1, true)
+		case "FLOAT":
+			typ = (&table{}).i16(0, precisionDouble)
+		case "TEKS":
+			typ = &table{}
+		default:
+			return nil, fmt.Errorf("kolom '%s': tipe kolom %s belum didukung", col.Name, col.Type)
+		}
+		aw.types = append(aw.types, typ)
+		aw.builds = append(aw.builds, &columnBuilder{offsets: []int32{0}})
+	}
+	return aw, nil
+}
+
+func typeID(colType string) uint8 {
+	switch colType {
+	case "INT":
+		return typeInt
+	case "FLOAT":
+		return typeFloatingPt
+	}
+	return typeUtf8
+}
+
+// columnBuilder menampung satu kolom batch yang sedang diisi. Nilai NULL
+// tetap punya slot (nol) di data.
+type columnBuilder struct {
+	valid   []byte
+	nulls   int
+	fixed   []byte
+	offsets []int32
+	data    []byte
+}
+
+// Write menambahkan satu baris. Nilai disesuaikan dengan tipe kolom
+// seperti AddRow; nil ditulis sebagai NULL.
+func (aw *Writer) Write(row tangki.Row) error {
+	if aw.err != nil {
+		return aw.err
+	}
+	if len(row) != len(aw.columns) {
+		return fmt.Errorf("jumlah nilai %d, seharusnya %d", len(row), len(aw.columns))
+	}
+	// Nilai dikonversi dulu agar baris yang salah tidak masuk setengah
+	values := make([]interface{}, len(row))
+	for i, val := range row {
+		if val == nil {
+			continue
+		}
+		var err error
+		switch aw.columns[i].Type {
+		case "INT":
+			values[i], err = tangki.ToInt64(val)
+		case "FLOAT":
+			values[i], err = tangki.ToFloat64(val)
+		default:
+			values[i] = tangki.ToText(val)
+		}
+		if err != nil {
+			return fmt.Errorf("kolom '%s': %v", aw.columns[i].Name, err)
+		}
+	}
+
+	for i, val := range values {
+		b := aw.builds[i]
+		if aw.rows%8 == 0 {
+			b.valid = append(b.valid, 0)
+		}
+		if val != nil {
+			b.valid[aw.rows/8] |= 1 << (aw.rows % 8)
+		} else {
+			b.nulls++
+		}
+		switch v := val.(type) {
+		case int64:
+			b.fixed = binary.LittleEndian.AppendUint64(b.fixed, uint64(v))
+		case float64:
+			b.fixed = binary.LittleEndian.AppendUint64(b.fixed, math.Float64bits(v))
+		case string:
+			b.data = append(b.data, v...)
+			aw.bytes += len(v)
+		}
+		if aw.columns[i].Type == "TEKS" {
+			b.offsets = append(b.offsets, int32(len(b.data)))
+		} else if val == nil {
+			b.fixed = append(b.fixed, make([]byte, 8)...)
+		}
+	}
+	if aw.rows++; aw.rows >= DefaultBatchSize || aw.bytes >= maxBatchBytes {
+		return aw.flush()
+	}
+	return nil
+}
+
+func (aw *Writer) write(b []byte) error {
+	if aw.err != nil {
+		return aw.err
+	}
+	_, aw.err = aw.w.Write(b)
+	aw.offset += int64(len(b))
+	return aw.err
+}
+
+// message menulis satu pesan IPC: penanda kelanjutan, panjang metadata,
+// metadata flatbuffer, lalu body. Mengembalikan panjang bagian metadata.
+func (aw *Writer) message(header uint8, body *table, bodyLen int64) (int32, error) {
+	msg := (&table{}).i16(0, metadataV5).u8(1, header).ref(2, body).i64(3, bodyLen)
+	meta := finish(msg)
+	prefix := binary.LittleEndian.AppendUint32(nil, continuationMark)
+	prefix = binary.LittleEndian.AppendUint32(prefix, uint32(len(meta)))
+	if err := aw.write(prefix); err != nil {
+		return 0, err
+	}
+	return int32(len(prefix) + len(meta)), aw.write(meta)
+}
+
+func (aw *Writer) schemaTable() *table {
+	fields := make(tables, len(aw.columns))
+	for i, col := range aw.columns {
+		fields[i] = (&table{}).
+			ref(0, col.Name).
+			bool(1, true).
+			u8(2, typeID(col.Type)).
+			ref(3, aw.types[i]).
+			ref(5, tables{})
+	}
+	return (&table{}).i16(0, 0).ref(1, fields)
+}
+
+func (aw *Writer) start() error {
+	if aw.schema {
+		return nil
+	}
+	aw.schema = true
+	if aw.file {
+		if err := aw.write([]byte(fileMagic + "\x00\x00")); err != nil {
+			return err
+		}
+	}
+	_, err := aw.message(headerSchema, aw.schemaTable(), 0)
+	return err
+}
+
+// flush menulis batch yang sedang ditampung sebagai satu record batch.
+func (aw *Writer) flush() error {
+	if err := aw.start(); err != nil {
+		return err
+	}
+	if aw.rows == 0 {
+		return nil
+	}
+
+	var body []byte
+	var nodes, buffers []byte
+	addBuffer := func(b []byte) {
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(b)))
+		body = append(body, b...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	for i, b := range aw.builds {
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(aw.rows))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(b.nulls))
+		if b.nulls > 0 {
+			addBuffer(b.valid)
+		} else {
+			addBuffer(nil)
+		}
+		if aw.columns[i].Type == "TEKS" {
+			offsets := make([]byte, 0, 4*len(b.offsets))
+			for _, off := range b.offsets {
+				offsets = binary.LittleEndian.AppendUint32(offsets, uint32(off))
+			}
+			addBuffer(offsets)
+			addBuffer(b.data)
+		} else {
+			addBuffer(b.fixed)
+		}
+		aw.builds[i] = &columnBuilder{offsets: []int32{0}}
+	}
+
+	batch := (&table{}).
+		i64(0, int64(aw.rows)).
+		ref(1, structs{size: fieldNodeSize, data: nodes}).
+		ref(2, structs{size: bufferSize, data: buffers})
+	start := aw.offset
+	meta, err := aw.message(headerRecord, batch, int64(len(body)))
+	if err != nil {
+		return err
+	}
+	if err := aw.write(body); err != nil {
+		return err
+	}
+	aw.batches = append(aw.batches, block{offset: start, metadata: meta, body: int64(len(body))})
+	aw.rows, aw.bytes = 0, 0
+	return nil
+}
+
+// Close menulis batch terakhir, penanda akhir stream, dan footer untuk
+// format file. Close tidak menutup writer di bawahnya.
+func (aw *Writer) Close() error {
+	if err := aw.flush(); err != nil {
+		return err
+	}
+	eos := binary.LittleEndian.AppendUint32(nil, continuationMark)
+	eos = binary.LittleEndian.AppendUint32(eos, 0)
+	if err := aw.write(eos); err != nil {
+		return err
+	}
+	if aw.file {
+		var blocks []byte
+		for _, b := range aw.batches {
+			blocks = binary.LittleEndian.AppendUint64(blocks, uint64(b.offset))
+			blocks = binary.LittleEndian.AppendUint32(blocks, uint32(b.metadata))
+			blocks = binary.LittleEndian.AppendUint32(blocks, 0)
+			blocks = binary.LittleEndian.AppendUint64(blocks, uint64(b.body))
+		}
+		footer := finish((&table{}).
+			i16(0, metadataV5).
+			ref(1, aw.schemaTable()).
+			ref(2, structs{size: blockSize}).
+			ref(3, structs{size: blockSize, data: blocks}))
+		footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+		if err := aw.write(append(footer, fileMagic...)); err != nil {
+			return err
+		}
+	}
+	aw.err = fmt.Errorf("arrow writer sudah ditutup")
+	return nil
+}
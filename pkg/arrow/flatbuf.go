@@ -0,0 +1,185 @@
+package arrow
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// Metadata Arrow IPC adalah flatbuffer. Builder di bawah cukup untuk
+// tabel-tabel yang ditulis Writer: objek ditulis dari depan, induk lebih
+// dulu, lalu anak-anaknya setelahnya, sehingga semua offset mengarah ke
+// depan seperti yang diharuskan format flatbuffer.
+
+// table adalah tabel flatbuffer; indeks fields adalah id field.
+type table struct {
+	fields []field
+}
+
+// field adalah scalar (size 1, 2, 4, atau 8 byte) atau, bila ref tidak
+// nil, offset ke objek lain.
+type field struct {
+	size int
+	bits uint64
+	ref  interface{}
+}
+
+// Objek yang bisa dirujuk field: *table, string, tables (vektor tabel),
+// dan structs (vektor struct berukuran tetap dengan alignment 8).
+type tables []*table
+
+type structs struct {
+	size int
+	data []byte
+}
+
+func (t *table) set(id int, f field) *table {
+	for len(t.fields) <= id {
+		t.fields = append(t.fields, field{})
+	}
+	t.fields[id] = f
+	return t
+}
+
+func (t *table) u8(id int, v uint8) *table {
+	return t.set(id, field{size: 1, bits: uint64(v)})
+}
+
+func (t *table) i16(id int, v int16) *table {
+	return t.set(id, field{size: 2, bits: uint64(uint16(v))})
+}
+
+func (t *table) i32(id int, v int32) *table {
+	return t.set(id, field{size: 4, bits: uint64(uint32(v))})
+}
+
+func (t *table) i64(id int, v int64) *table {
+	return t.set(id, field{size: 8, bits: uint64(v)})
+}
+
+func (t *table) ref(id int, v interface{}) *table {
+	return t.set(id, field{size: 4, ref: v})
+}
+
+func (t *table) bool(id int, v bool) *table {
+	if v {
+		return t.u8(id, 1)
+	}
+	return t.u8(id, 0)
+}
+
+type builder struct {
+	buf []byte
+}
+
+// finish menghasilkan flatbuffer dengan root, panjangnya kelipatan 8.
+func finish(root *table) []byte {
+	b := &builder{buf: make([]byte, 4)}
+	pos := b.object(root)
+	binary.LittleEndian.PutUint32(b.buf, uint32(pos))
+	b.pad(8)
+	return b.buf
+}
+
+func (b *builder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// object menulis obj di akhir buffer dan mengembalikan posisinya.
+func (b *builder) object(obj interface{}) int {
+	switch o := obj.(type) {
+	case *table:
+		return b.table(o)
+	case string:
+		b.pad(4)
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(o)))
+		b.buf = append(b.buf, o...)
+		b.buf = append(b.buf, 0)
+		return pos
+	case tables:
+		b.pad(4)
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(o)))
+		slots := len(b.buf)
+		b.buf = append(b.buf, make([]byte, 4*len(o))...)
+		for i, t := range o {
+			slot := slots + 4*i
+			b.patch(slot, b.object(t))
+		}
+		return pos
+	case structs:
+		// Isi vektor harus rata 8, jadi panjangnya ada di posisi 4 mod 8
+		for len(b.buf)%8 != 4 {
+			b.buf = append(b.buf, 0)
+		}
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(o.data)/o.size))
+		b.buf = append(b.buf, o.data...)
+		return pos
+	}
+	panic("arrow: objek flatbuffer tidak dikenal")
+}
+
+// patch menulis offset dari slot ke target.
+func (b *builder) patch(slot, target int) {
+	binary.LittleEndian.PutUint32(b.buf[slot:], uint32(target-slot))
+}
+
+func (b *builder) table(t *table) int {
+	// Field diletakkan dari yang terbesar agar setiap field rata dengan
+	// ukurannya; tabel sendiri dimulai di posisi kelipatan 8.
+	order := make([]int, 0, len(t.fields))
+	for id, f := range t.fields {
+		if f.size > 0 {
+			order = append(order, id)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return t.fields[order[i]].size > t.fields[order[j]].size
+	})
+	offsets := make([]int, len(t.fields))
+	size := 4
+	for _, id := range order {
+		f := t.fields[id]
+		for size%f.size != 0 {
+			size++
+		}
+		offsets[id] = size
+		size += f.size
+	}
+
+	b.pad(2)
+	vtable := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(4+2*len(t.fields)))
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(size))
+	for _, off := range offsets {
+		b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(off))
+	}
+
+	b.pad(8)
+	pos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(int32(pos-vtable)))
+	for _, id := range order {
+		f := t.fields[id]
+		at := pos + offsets[id]
+		switch f.size {
+		case 1:
+			b.buf[at] = byte(f.bits)
+		case 2:
+			binary.LittleEndian.PutUint16(b.buf[at:], uint16(f.bits))
+		case 4:
+			binary.LittleEndian.PutUint32(b.buf[at:], uint32(f.bits))
+		case 8:
+			binary.LittleEndian.PutUint64(b.buf[at:], f.bits)
+		}
+	}
+	for _, id := range order {
+		if f := t.fields[id]; f.ref != nil {
+			b.patch(pos+offsets[id], b.object(f.ref))
+		}
+	}
+	return pos
+}
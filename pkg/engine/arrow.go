@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"context"
+	"io"
+
+	"github.com/Dziqha/BensinDB/pkg/arrow"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+func arrowWriter(file bool) openTyped {
+	return func(w io.Writer, columns []tangki.Column) (typedWriter, error) {
+		if file {
+			return arrow.NewFileWriter(w, columns)
+		}
+		return arrow.NewStreamWriter(w, columns)
+	}
+}
+
+// ExportArrow menulis isi tangki atau pandangan name ke w dalam format
+// stream Arrow IPC dari snapshot terakhir, sama seperti EKSPOR TANGKI ke
+// file .arrows. Untuk format file Arrow (.arrow) pakai EKSPOR TANGKI.
+func (e *Engine) ExportArrow(ctx context.Context, name string, w io.Writer) error {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Query)
+	defer cancel()
+	return e.snapshot().exportTyped(ctx, name, w, arrowWriter(false))
+}
+
+// WriteArrow menulis semua baris yang tersisa ke w dalam format stream
+// Arrow IPC, lalu menutup r. Tipe kolom ditentukan seperti WriteParquet.
+func (r *Rows) WriteArrow(w io.Writer) error {
+	return r.writeTyped(w, arrowWriter(false))
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Backup menulis snapshot database terakhir ke w dalam format file .bensin
+// biasa, jadi hasilnya bisa langsung dibuka dengan OpenTangki. Seperti
+// Checkpoint, penulisan tidak menahan lock engine: penulis lain tetap
+// berjalan dan perubahan mereka tidak ikut masuk ke backup. Semua baris
+// ditulis ke dalam backup, termasuk tangki mode halaman dan tangki lazy.
+// Backup mencatat posisi WAL-nya sehingga bisa dipakai RestoreTo.
+//
+// Backup berhenti dengan ctx.Err() bila ctx selesai sebelum semua data
+// tertulis, termasuk di tengah tangki yang besar; isi w pada saat itu
+// tidak lengkap.
+func (e *Engine) Backup(ctx context.Context, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	snap, lsn := e.capture()
+	writer := bufio.NewWriter(&ctxWriter{ctx: ctx, w: w})
+	if err := snap.writeSnapshot(ctx, writer, false, lsn); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// ctxWriter menolak tulisan begitu ctx selesai.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c *ctxWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
+
+// RestoreTo membuat database baru di path dari file backup (hasil Backup
+// atau file .bensin lain), lalu menjalankan ulang catatan dari file WAL
+// wals yang dicommit paling lambat pada until. wals diurutkan dari yang
+// terlama, biasanya arsip Options.WALArchive lalu WAL database asal
+// (path+".wal"). Catatan yang sudah tercakup backup dilewati; bila ada
+// celah antara backup dan catatan WAL pertama, RestoreTo gagal. until nol
+// berarti semua catatan. path tidak boleh sudah ada.
+func RestoreTo(path, backup string, until time.Time, wals ...string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("file '%s' sudah ada", path)
+	}
+	eng, err := OpenTangkiWithOptions(backup, Options{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer eng.Close()
+
+	for _, wal := range wals {
+		_, stopped, err := eng.replayWAL(wal, until)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			break
+		}
+	}
+	return eng.snapshot().writeFile(path, eng.walLSN)
+}
@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/pager"
+)
+
+// CheckpointStatus adalah keadaan checkpointer latar belakang.
+type CheckpointStatus struct {
+	// Running berarti checkpointer latar belakang sedang aktif.
+	Running bool
+	// Checkpoints adalah jumlah checkpoint yang berhasil ditulis.
+	Checkpoints    int
+	LastCheckpoint time.Time
+	LastDuration   time.Duration
+	LastError      error
+	// PendingWrites adalah jumlah perubahan yang belum ada di file.
+	PendingWrites int
+}
+
+// Checkpoint menulis snapshot database terakhir ke file sekarang juga.
+// Serialisasi dan I/O berjalan tanpa lock engine sehingga pembaca dan
+// penulis lain tidak menunggu. Dengan WAL, lock katalog diambil sebentar
+// agar snapshot dan posisi WAL yang dicatat cocok, dan catatan WAL yang
+// sudah tercakup dibuang setelah file tertulis.
+func (e *Engine) Checkpoint() error {
+	if !e.persistent() {
+		return nil
+	}
+
+	e.stateMu.Lock()
+	dirty := e.dirty
+	e.stateMu.Unlock()
+	if !dirty {
+		return nil
+	}
+	snap, lsn := e.capture()
+	version := snap.version
+
+	start := time.Now()
+	err := e.persist(snap, version, lsn)
+	if err == nil {
+		e.stateMu.Lock()
+		if e.version == version {
+			e.dirty = false
+		}
+		e.stateMu.Unlock()
+	}
+
+	e.statusMu.Lock()
+	e.status.LastError = err
+	if err == nil {
+		e.status.Checkpoints++
+		e.status.LastCheckpoint = time.Now()
+		e.status.LastDuration = time.Since(start)
+	}
+	e.statusMu.Unlock()
+	return err
+}
+
+// CheckpointStatus mengembalikan keadaan checkpoint terakhir.
+func (e *Engine) CheckpointStatus() CheckpointStatus {
+	e.stateMu.Lock()
+	version := e.version
+	e.stateMu.Unlock()
+	e.fileMu.Lock()
+	saved := e.savedVersion
+	e.fileMu.Unlock()
+
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	status := e.status
+	status.Running = e.stopCheckpoint != nil
+	status.PendingWrites = int(version - saved)
+	return status
+}
+
+// capture mengambil snapshot terakhir beserta posisi WAL yang tercakup di
+// dalamnya. Dengan WAL, lock katalog diambil sebentar agar tidak ada
+// perubahan yang sudah terpasang tetapi belum tercatat.
+func (e *Engine) capture() (*Engine, int64) {
+	if e.wal != nil {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+	}
+	return e.snapshot(), e.walEnd()
+}
+
+// persist menulis snap (keadaan pada versi version, mencakup WAL sampai
+// lsn) ke e.file. Snapshot yang lebih tua dari file yang sudah tertulis
+// dibuang, sehingga checkpoint yang lambat tidak menimpa hasil penyimpanan
+// yang lebih baru.
+func (e *Engine) persist(snap *Engine, version uint64, lsn int64) error {
+	tmp, err := snap.writeTemp(e.file, lsn)
+	if err != nil {
+		return err
+	}
+
+	e.fileMu.Lock()
+	defer e.fileMu.Unlock()
+	if version < e.savedVersion {
+		os.Remove(tmp)
+		return nil
+	}
+	if err := os.Rename(tmp, e.file); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	e.savedVersion = version
+	if e.pool != nil {
+		// Halaman versi lama baru boleh dipakai ulang setelah file tidak
+		// lagi merujuknya
+		old := e.savedPages
+		e.savedPages = snap.leasePages()
+		if old != nil {
+			old.Release()
+		}
+	}
+	if e.wal != nil {
+		return e.wal.trim(lsn)
+	}
+	return nil
+}
+
+// leasePages menahan semua halaman tangki e agar tidak dipakai ulang.
+func (e *Engine) leasePages() *pager.Lease {
+	var ids []pager.PageID
+	for _, t := range e.tangkis {
+		for _, ref := range t.Pages() {
+			ids = append(ids, ref.ID)
+		}
+	}
+	return e.pool.Pager().Lease(ids)
+}
+
+// markDirty mencatat satu perubahan dan membangunkan checkpointer bila
+// jumlah perubahan sejak checkpoint terakhir sudah mencapai ambang.
+// memStale berarti ukuran memori harus dihitung ulang (lihat checkMemory).
+func (e *Engine) markDirty(memStale bool) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	e.markDirtyNoLock(memStale)
+}
+
+// Asumsi: stateMu sudah diambil oleh caller
+func (e *Engine) markDirtyNoLock(memStale bool) {
+	e.dirty = true
+	if memStale {
+		e.memStale = true
+	}
+	e.version++
+	if e.checkpointWrites > 0 && int(e.version-e.kickedAt) >= e.checkpointWrites {
+		e.kickedAt = e.version
+		select {
+		case e.kickCheckpoint <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// startCheckpointer menjalankan Checkpoint setiap interval dan setiap kali
+// markDirty melihat writes perubahan baru.
+func (e *Engine) startCheckpointer(interval time.Duration, writes int) {
+	e.stopCheckpoint = make(chan struct{})
+	e.checkpointDone = make(chan struct{})
+	e.kickCheckpoint = make(chan struct{}, 1)
+	e.checkpointWrites = writes
+
+	go func() {
+		defer close(e.checkpointDone)
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-e.stopCheckpoint:
+				return
+			case <-tick:
+			case <-e.kickCheckpoint:
+			}
+			if err := e.Checkpoint(); err != nil {
+				e.logf("checkpoint gagal: %v", err)
+			}
+		}
+	}()
+}
+
+// stopCheckpointer menghentikan checkpointer dan menunggu checkpoint yang
+// sedang berjalan selesai.
+func (e *Engine) stopCheckpointer() {
+	if e.stopCheckpoint == nil {
+		return
+	}
+	close(e.stopCheckpoint)
+	<-e.checkpointDone
+
+	e.statusMu.Lock()
+	e.stopCheckpoint = nil
+	e.statusMu.Unlock()
+}
+
+func tempPath(path string) (dir, pattern string) {
+	return filepath.Dir(path), filepath.Base(path) + ".*.tmp"
+}
@@ -0,0 +1,356 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// CSVOptions mengatur ImportCSV dan ExportCSV.
+type CSVOptions struct {
+	// Delimiter adalah pemisah kolom; nol berarti koma.
+	Delimiter rune
+	// Header berarti baris pertama berisi nama kolom. Saat impor kolom
+	// dicocokkan lewat nama (kolom yang tidak ada di file menjadi NULL),
+	// dan tangki yang belum ada dibuat dari header itu. Saat ekspor nama
+	// kolom ditulis lebih dulu.
+	Header bool
+	// LazyQuotes menerima tanda kutip yang tidak rapi saat impor, misalnya
+	// kutip di tengah kolom yang tidak dikutip (lihat csv.Reader). Saat
+	// ekspor kolom selalu dikutip bila perlu.
+	LazyQuotes bool
+	// MaxErrors adalah jumlah baris salah yang boleh dilewati saat impor.
+	// Nol berarti baris salah pertama membatalkan seluruh impor; negatif
+	// berarti tanpa batas. Baris yang dilewati ada di ImportResult.Errors.
+	MaxErrors int
+	// Null adalah penanda NULL, misalnya `\N`. Saat ekspor NULL ditulis
+	// sebagai Null, dan saat impor field yang sama persis dengan Null
+	// menjadi NULL di kolom apa pun, termasuk TEKS. Kosong berarti NULL
+	// ditulis sebagai kolom kosong, yang terbaca lagi sebagai teks kosong
+	// di kolom TEKS. Teks yang isinya sama dengan Null tidak bisa
+	// dibedakan dari NULL, jadi pilih penanda yang tidak muncul di data.
+	Null string
+}
+
+// ImportCSV menambahkan baris dari r ke tangki name, sama seperti
+// IMPOR TANGKI. Nilai dikonversi ke tipe kolom seperti ISI; kolom kosong
+// pada kolom INT atau FLOAT menjadi NULL. Bila tangki belum ada, tangki
+// baru dibuat dari header dengan tipe yang ditebak dari isinya: INT bila
+// semua nilai bilangan bulat, FLOAT bila semua angka, selain itu TEKS.
+//
+// Impor berjalan utuh atau tidak sama sekali: bila gagal, tangki tidak
+// berubah. Impor memegang lock katalog eksklusif sampai selesai.
+func (e *Engine) ImportCSV(ctx context.Context, name string, r io.Reader, opts CSVOptions) (*ImportResult, error) {
+	return e.importFrom(ctx, name, &importSource{format: "CSV", r: r, csv: opts})
+}
+
+func (imp *importer) csv(name string, r io.Reader, opts CSVOptions) error {
+	reader := csv.NewReader(bufio.NewReader(r))
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	reader.LazyQuotes = opts.LazyQuotes
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	c := &csvImport{importer: imp, reader: reader, null: opts.Null}
+
+	if opts.Header {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return fmt.Errorf("file CSV kosong, header tidak ditemukan")
+		}
+		if err != nil {
+			return err
+		}
+		c.header = make([]string, len(record))
+		for i, name := range record {
+			c.header[i] = strings.TrimSpace(name)
+		}
+	}
+
+	if _, exists := imp.e.tangkis[name]; exists {
+		return c.into(name)
+	}
+	return c.create(name)
+}
+
+// csvImport membaca baris dari satu reader CSV.
+type csvImport struct {
+	*importer
+	reader *csv.Reader
+	header []string
+	null   string
+}
+
+// next membaca record berikutnya. Record yang rusak atau yang jumlah
+// kolomnya tidak sama dengan want dicatat lewat reject dan dilewati.
+func (c *csvImport) next(want int) ([]string, int, error) {
+	for {
+		if err := c.tick(); err != nil {
+			return nil, 0, err
+		}
+		record, err := c.reader.Read()
+		if err == nil {
+			line, _ := c.reader.FieldPos(0)
+			if len(record) == want {
+				return record, line, nil
+			}
+			err = fmt.Errorf("jumlah kolom %d, seharusnya %d", len(record), want)
+			if err := c.reject(line, err); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		var perr *csv.ParseError
+		if !errors.As(err, &perr) {
+			return nil, 0, err
+		}
+		if err := c.reject(perr.StartLine, perr.Err); err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
+// into menambahkan baris ke tangki yang sudah ada. Tanpa header, kolom
+// dicocokkan menurut urutan.
+func (c *csvImport) into(name string) error {
+	t, err := c.e.forkTangki(name)
+	if err != nil {
+		return err
+	}
+
+	want := len(t.Columns)
+	index := make([]int, want)
+	for i := range index {
+		index[i] = i
+	}
+	if c.header != nil {
+		want = len(c.header)
+		if index, err = columnIndex(t, c.header); err != nil {
+			return err
+		}
+	}
+
+	for {
+		record, line, err := c.next(want)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(t.Columns))
+		for i, col := range t.Columns {
+			if index[i] != -1 {
+				values[i] = c.value(col.Type, record[index[i]])
+			}
+		}
+		if err := c.add(t, values, line); err != nil {
+			return err
+		}
+	}
+	return c.install(t, false)
+}
+
+// create membuat tangki baru dari header. Semua record dibaca dulu untuk
+// menebak tipe kolom.
+func (c *csvImport) create(name string) error {
+	if c.header == nil {
+		return fmt.Errorf("tangki '%s' tidak ditemukan; DENGAN HEADER dibutuhkan untuk membuat tangki baru", name)
+	}
+	columns, err := newColumns(c.header)
+	if err != nil {
+		return fmt.Errorf("header: %v", err)
+	}
+
+	type csvRecord struct {
+		fields []string
+		line   int
+	}
+	var records []csvRecord
+	for {
+		record, line, err := c.next(len(columns))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fields := append([]string(nil), record...)
+		for i, field := range fields {
+			if c.null == "" || field != c.null {
+				columns[i].Type = widenType(columns[i].Type, csvType(field))
+			}
+		}
+		records = append(records, csvRecord{fields: fields, line: line})
+	}
+	finishTypes(columns)
+
+	t := tangki.NewTangki(name, columns)
+	for _, rec := range records {
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			values[i] = c.value(col.Type, rec.fields[i])
+		}
+		if err := c.add(t, values, rec.line); err != nil {
+			return err
+		}
+	}
+	return c.install(t, true)
+}
+
+// csvType menebak tipe satu field CSV; field kosong tidak punya tipe.
+func csvType(field string) string {
+	if field == "" {
+		return ""
+	}
+	if _, err := strconv.Atoi(field); err == nil {
+		return "INT"
+	}
+	if _, err := strconv.ParseFloat(field, 64); err == nil {
+		return "FLOAT"
+	}
+	return "TEKS"
+}
+
+// value mengubah field CSV menjadi nilai untuk AddRow. Penanda NULL, dan
+// field kosong di kolom INT atau FLOAT, menjadi NULL; sisanya dikonversi
+// oleh AddRow.
+func (c *csvImport) value(colType, field string) interface{} {
+	if c.null != "" && field == c.null {
+		return nil
+	}
+	if field == "" && colType != "TEKS" {
+		return nil
+	}
+	return field
+}
+
+// ExportCSV menulis isi tangki atau pandangan name ke w dari snapshot
+// terakhir, sama seperti EKSPOR TANGKI. NULL ditulis sebagai kolom kosong,
+// atau sebagai opts.Null bila diisi.
+func (e *Engine) ExportCSV(ctx context.Context, name string, w io.Writer, opts CSVOptions) error {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Query)
+	defer cancel()
+	return e.snapshot().exportCSV(ctx, name, w, opts)
+}
+
+// exportFile menjalankan EKSPOR TANGKI. File ditulis ke file sementara
+// lalu di-rename, jadi file lama tidak rusak bila ekspor gagal.
+func (e *Engine) exportFile(ctx context.Context, q *parser.Query) error {
+	path := q.Transfer.Path
+	file, err := os.CreateTemp(tempPath(path))
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+
+	writer := bufio.NewWriter(file)
+	snap := e.snapshot()
+	switch q.Transfer.Format {
+	case "NDJSON":
+		err = snap.exportNDJSON(ctx, q.Tangki, writer)
+	case "PARQUET":
+		err = snap.exportTyped(ctx, q.Tangki, writer, parquetWriter(ParquetOptions{}))
+	case "ARROW", "ARROW_STREAM":
+		err = snap.exportTyped(ctx, q.Tangki, writer, arrowWriter(q.Transfer.Format == "ARROW"))
+	default:
+		err = snap.exportCSV(ctx, q.Tangki, writer, CSVOptions{Header: q.Transfer.Header})
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// exportSource mengembalikan tangki yang diekspor. Pandangan biasa
+// dijalankan lebih dulu; tangki berhalaman tetap dibaca per halaman.
+// Asumsi: e adalah snapshot
+func (e *Engine) exportSource(name string) (*tangki.Tangki, error) {
+	if v, ok := e.views[name]; ok && !v.materialized {
+		return e.readTangki(name)
+	}
+	return e.getTangkiNoLock(name)
+}
+
+// Asumsi: e adalah snapshot
+func (e *Engine) exportCSV(ctx context.Context, name string, w io.Writer, opts CSVOptions) error {
+	t, err := e.exportSource(name)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+	record := make([]string, len(t.Columns))
+	if opts.Header {
+		for i, col := range t.Columns {
+			record[i] = col.Name
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	n := 0
+	scanErr := t.Scan(func(row tangki.Row) bool {
+		if n++; n%1024 == 0 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+		for i, val := range row {
+			if val == nil {
+				record[i] = opts.Null
+			} else {
+				record[i] = csvField(val)
+			}
+		}
+		err = writer.Write(record)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if scanErr != nil {
+		return scanErr
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvField(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(val)
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// maxCTEIterations membatasi putaran CTE rekursif agar data bersiklus
+// (misalnya atasan_id yang saling menunjuk dengan SATUKAN SEMUA) tidak
+// berjalan selamanya.
+const maxCTEIterations = 10000
+
+// cteScope adalah hasil DENGAN yang terlihat oleh sebuah query. Hasilnya
+// hanya hidup selama eksekusi query dan tidak pernah masuk ke e.tangkis.
+type cteScope struct {
+	relations map[string]*query.Relation
+	parent    *cteScope
+}
+
+func (s *cteScope) lookup(name string) (*query.Relation, bool) {
+	for ; s != nil; s = s.parent {
+		if rel, ok := s.relations[name]; ok {
+			return rel, true
+		}
+	}
+	return nil, false
+}
+
+// evalWith mengevaluasi CTE secara berurutan; setiap CTE bisa membaca
+// CTE sebelumnya di DENGAN yang sama dan CTE milik query luar.
+func (e *Engine) evalWith(ctx context.Context, ctes []*parser.CTE, outer *query.Scope, parent *cteScope) (*cteScope, error) {
+	if len(ctes) == 0 {
+		return parent, nil
+	}
+
+	scope := &cteScope{relations: make(map[string]*query.Relation), parent: parent}
+	for _, cte := range ctes {
+		var rel *query.Relation
+		var err error
+		if cte.Recursive && containsName(cte.Select.Tables(), cte.Name) {
+			rel, err = e.evalRecursive(ctx, cte, outer, scope)
+		} else {
+			rel, err = e.execSelect(ctx, cte.Select, outer, scope)
+			if err == nil {
+				rel, err = cteRelation(cte, rel)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CTE '%s': %v", cte.Name, err)
+		}
+		scope.relations[cte.Name] = rel
+	}
+	return scope, nil
+}
+
+// evalRecursive menjalankan PILIH pertama sebagai anchor, lalu mengulang
+// bagian SATUKAN terhadap baris yang baru ditemukan pada putaran
+// sebelumnya sampai tidak ada baris baru.
+func (e *Engine) evalRecursive(ctx context.Context, cte *parser.CTE, outer *query.Scope, parent *cteScope) (*query.Relation, error) {
+	stmt := cte.Select
+	inner, err := e.evalWith(ctx, stmt.With, outer, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	anchor := *stmt
+	anchor.With, anchor.SetOps = nil, nil
+	if containsName(anchor.Tables(), cte.Name) {
+		return nil, fmt.Errorf("anchor tidak boleh merujuk dirinya sendiri")
+	}
+
+	dedupe := false
+	for _, clause := range stmt.SetOps {
+		if clause.Operation != query.SetUnion {
+			return nil, fmt.Errorf("CTE rekursif harus memakai SATUKAN")
+		}
+		dedupe = dedupe || !clause.All
+	}
+
+	rel, err := e.execSimpleSelect(ctx, &anchor, outer, inner)
+	if err != nil {
+		return nil, err
+	}
+	rel, err = cteRelation(cte, rel)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	rows := make([]tangki.Row, 0, len(rel.Rows))
+	for _, row := range rel.Rows {
+		key := query.RowKey(row)
+		if dedupe && seen[key] {
+			continue
+		}
+		seen[key] = true
+		rows = append(rows, row)
+	}
+
+	working := rows
+	for iter := 0; len(working) > 0; iter++ {
+		if iter >= maxCTEIterations {
+			return nil, fmt.Errorf("rekursi melebihi %d putaran", maxCTEIterations)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		step := &cteScope{
+			relations: map[string]*query.Relation{cte.Name: {Schema: rel.Schema, Rows: working}},
+			parent:    inner,
+		}
+
+		var next []tangki.Row
+		for _, clause := range stmt.SetOps {
+			part, err := e.execSimpleSelect(ctx, clause.Select, outer, step)
+			if err != nil {
+				return nil, err
+			}
+			if len(part.Schema) != len(rel.Schema) {
+				return nil, fmt.Errorf("jumlah kolom tidak sama: %d dan %d", len(rel.Schema), len(part.Schema))
+			}
+			for _, row := range part.Rows {
+				key := query.RowKey(row)
+				if !clause.All && seen[key] {
+					continue
+				}
+				seen[key] = true
+				next = append(next, row)
+			}
+		}
+
+		rows = append(rows, next...)
+		working = next
+	}
+
+	return &query.Relation{Schema: rel.Schema, Rows: rows}, nil
+}
+
+// cteRelation melepas kualifikasi tangki dan menerapkan daftar kolom
+// nama (kolom, ...) bila ada.
+func cteRelation(cte *parser.CTE, rel *query.Relation) (*query.Relation, error) {
+	schema := unqualified(rel.Schema)
+	if len(cte.Columns) > 0 {
+		if len(cte.Columns) != len(schema) {
+			return nil, fmt.Errorf("punya %d kolom tetapi %d nama kolom", len(schema), len(cte.Columns))
+		}
+		for i, name := range cte.Columns {
+			schema[i].Name = name
+		}
+	}
+	return &query.Relation{Schema: schema, Rows: rel.Rows}, nil
+}
@@ -0,0 +1,491 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Sejak format minor 4 isi tangki ditulis per kolom. Setiap kolom adalah
+// satu blok: byte encoding, byte flag, panjang payload (uint32), lalu
+// payload. Payload diawali jumlah NULL (uvarint) dan, bila ada NULL,
+// bitmap-nya; sesudah itu hanya nilai baris yang tidak NULL.
+//
+// Encoding dipilih per kolom saat Save dari statistik kolom (jumlah run,
+// nilai berbeda, panjang varint) yang dihitung dalam satu lintasan; hanya
+// encoding terpilih yang benar-benar di-encode.
+const (
+	encPlain   = 0 // nilai apa adanya: INT/FLOAT 8 byte, TEKS panjang+isi
+	encVarint  = 1 // INT: zigzag varint
+	encDelta   = 2 // INT: selisih dengan nilai sebelumnya, zigzag varint
+	encRLE     = 3 // INT/FLOAT: pasangan (nilai, panjang run)
+	encDict    = 4 // TEKS: kamus lalu kode varint per baris
+	encDictRLE = 5 // TEKS: kamus lalu pasangan (kode, panjang run)
+)
+
+// blockFlate menandai payload yang dikompres dengan DEFLATE. DEFLATE
+// dipakai karena tersedia di pustaka standar (compress/flate); BensinDB
+// tidak bergantung pada modul luar untuk Snappy atau zstd.
+const blockFlate = 1 << 0
+
+// columnValues adalah isi satu kolom tanpa NULL, siap di-encode.
+type columnValues struct {
+	typ    string
+	nulls  []byte
+	ints   []int64
+	floats []float64
+	teks   []string
+}
+
+func collectColumn(col tangki.Column, rows []tangki.Row, idx int) *columnValues {
+	cv := &columnValues{typ: col.Type}
+	for i, row := range rows {
+		val := row[idx]
+		if val == nil {
+			if cv.nulls == nil {
+				cv.nulls = make([]byte, (len(rows)+7)/8)
+			}
+			cv.nulls[i/8] |= 1 << (i % 8)
+			continue
+		}
+		switch col.Type {
+		case "INT":
+			cv.ints = append(cv.ints, toInt64(val))
+		case "FLOAT":
+			cv.floats = append(cv.floats, toFloat(val))
+		case "TEKS":
+			cv.teks = append(cv.teks, fmt.Sprint(val))
+		}
+	}
+	return cv
+}
+
+// writeColumn menulis kolom idx dari rows sebagai satu blok.
+func writeColumn(w *bufio.Writer, col tangki.Column, rows []tangki.Row, idx int, compress bool) error {
+	cv := collectColumn(col, rows, idx)
+
+	var header []byte
+	if cv.nulls == nil {
+		header = binary.AppendUvarint(header, 0)
+	} else {
+		nullCount := len(rows) - len(cv.ints) - len(cv.floats) - len(cv.teks)
+		header = binary.AppendUvarint(header, uint64(nullCount))
+		header = append(header, cv.nulls...)
+	}
+
+	enc := chooseEncoding(cv)
+	payload := append(header, encodeValues(cv, enc)...)
+
+	flags := byte(0)
+	if compress {
+		packed, err := deflate(payload)
+		if err != nil {
+			return err
+		}
+		if len(packed) < len(payload) {
+			payload = packed
+			flags |= blockFlate
+		}
+	}
+
+	w.WriteByte(enc)
+	w.WriteByte(flags)
+	binary.Write(w, binary.LittleEndian, uint32(len(payload)))
+	_, err := w.Write(payload)
+	return err
+}
+
+// chooseEncoding memilih encoding terkecil untuk cv. Ukuran setiap
+// kandidat dihitung dari statistik kolom tanpa membangun payload-nya;
+// bila sama besar, encoding yang lebih sederhana menang.
+func chooseEncoding(cv *columnValues) byte {
+	var sizes [encDictRLE + 1]int
+	var cands []byte
+	switch cv.typ {
+	case "INT":
+		cands = []byte{encPlain, encVarint, encDelta, encRLE}
+		sizes[encPlain] = 8 * len(cv.ints)
+		prev, run := int64(0), 0
+		for i, v := range cv.ints {
+			sizes[encVarint] += varintLen(v)
+			sizes[encDelta] += varintLen(v - prev)
+			if i > 0 && v != prev {
+				sizes[encRLE] += varintLen(prev) + uvarintLen(uint64(run))
+				run = 0
+			}
+			prev = v
+			run++
+		}
+		if run > 0 {
+			sizes[encRLE] += varintLen(prev) + uvarintLen(uint64(run))
+		}
+	case "FLOAT":
+		cands = []byte{encPlain, encRLE}
+		sizes[encPlain] = 8 * len(cv.floats)
+		prev, run := uint64(0), 0
+		for i, v := range cv.floats {
+			bits := math.Float64bits(v)
+			if i > 0 && bits != prev {
+				sizes[encRLE] += 8 + uvarintLen(uint64(run))
+				run = 0
+			}
+			prev = bits
+			run++
+		}
+		if run > 0 {
+			sizes[encRLE] += 8 + uvarintLen(uint64(run))
+		}
+	case "TEKS":
+		cands = []byte{encPlain, encDict, encDictRLE}
+		index := make(map[string]uint64)
+		dict := 0
+		prev, run := uint64(0), 0
+		for i, s := range cv.teks {
+			text := uvarintLen(uint64(len(s))) + len(s)
+			sizes[encPlain] += text
+			code, ok := index[s]
+			if !ok {
+				code = uint64(len(index))
+				index[s] = code
+				dict += text
+			}
+			sizes[encDict] += uvarintLen(code)
+			if i > 0 && code != prev {
+				sizes[encDictRLE] += uvarintLen(prev) + uvarintLen(uint64(run))
+				run = 0
+			}
+			prev = code
+			run++
+		}
+		if run > 0 {
+			sizes[encDictRLE] += uvarintLen(prev) + uvarintLen(uint64(run))
+		}
+		dict += uvarintLen(uint64(len(index)))
+		sizes[encDict] += dict
+		sizes[encDictRLE] += dict
+	default:
+		return encPlain
+	}
+
+	best := cands[0]
+	for _, enc := range cands[1:] {
+		if sizes[enc] < sizes[best] {
+			best = enc
+		}
+	}
+	return best
+}
+
+func varintLen(v int64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutVarint(buf[:], v)
+}
+
+func uvarintLen(v uint64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], v)
+}
+
+func encodeValues(cv *columnValues, enc byte) []byte {
+	var b []byte
+	switch cv.typ {
+	case "INT":
+		switch enc {
+		case encPlain:
+			for _, v := range cv.ints {
+				b = binary.LittleEndian.AppendUint64(b, uint64(v))
+			}
+		case encVarint:
+			for _, v := range cv.ints {
+				b = binary.AppendVarint(b, v)
+			}
+		case encDelta:
+			prev := int64(0)
+			for _, v := range cv.ints {
+				b = binary.AppendVarint(b, v-prev)
+				prev = v
+			}
+		case encRLE:
+			b = appendRuns(b, len(cv.ints), func(i, j int) bool { return cv.ints[i] == cv.ints[j] },
+				func(b []byte, i int) []byte { return binary.AppendVarint(b, cv.ints[i]) })
+		}
+	case "FLOAT":
+		bits := func(b []byte, i int) []byte {
+			return binary.LittleEndian.AppendUint64(b, math.Float64bits(cv.floats[i]))
+		}
+		switch enc {
+		case encPlain:
+			for i := range cv.floats {
+				b = bits(b, i)
+			}
+		case encRLE:
+			b = appendRuns(b, len(cv.floats), func(i, j int) bool {
+				return math.Float64bits(cv.floats[i]) == math.Float64bits(cv.floats[j])
+			}, bits)
+		}
+	case "TEKS":
+		switch enc {
+		case encPlain:
+			for _, s := range cv.teks {
+				b = appendText(b, s)
+			}
+		case encDict, encDictRLE:
+			codes := make([]uint64, len(cv.teks))
+			index := make(map[string]uint64)
+			var dict []string
+			for i, s := range cv.teks {
+				code, ok := index[s]
+				if !ok {
+					code = uint64(len(dict))
+					index[s] = code
+					dict = append(dict, s)
+				}
+				codes[i] = code
+			}
+			b = binary.AppendUvarint(b, uint64(len(dict)))
+			for _, s := range dict {
+				b = appendText(b, s)
+			}
+			if enc == encDict {
+				for _, c := range codes {
+					b = binary.AppendUvarint(b, c)
+				}
+			} else {
+				b = appendRuns(b, len(codes), func(i, j int) bool { return codes[i] == codes[j] },
+					func(b []byte, i int) []byte { return binary.AppendUvarint(b, codes[i]) })
+			}
+		}
+	}
+	return b
+}
+
+// appendRuns menulis n nilai sebagai pasangan (nilai, panjang run).
+func appendRuns(b []byte, n int, same func(i, j int) bool, value func(b []byte, i int) []byte) []byte {
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && same(i, j) {
+			j++
+		}
+		b = value(b, i)
+		b = binary.AppendUvarint(b, uint64(j-i))
+		i = j
+	}
+	return b
+}
+
+func appendText(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// columnDecoder membaca payload satu blok kolom dengan pemeriksaan batas.
+type columnDecoder struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (d *columnDecoder) fail() {
+	if d.err == nil {
+		d.err = io.ErrUnexpectedEOF
+	}
+}
+
+func (d *columnDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.pos += n
+	return v
+}
+
+func (d *columnDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf[d.pos:])
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.pos += n
+	return v
+}
+
+func (d *columnDecoder) bytes(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(len(d.buf)-d.pos) {
+		d.fail()
+		return nil
+	}
+	b := d.buf[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b
+}
+
+func (d *columnDecoder) uint64() uint64 {
+	b := d.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (d *columnDecoder) text() string {
+	return string(d.bytes(d.uvarint()))
+}
+
+// decodeColumn mendekode satu blok kolom untuk numRows baris dan memanggil
+// set untuk setiap baris yang tidak NULL, berurutan dari baris pertama.
+func decodeColumn(enc, flags byte, payload []byte, col tangki.Column, numRows int, set func(row int, val interface{})) error {
+	if flags&blockFlate != 0 {
+		raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(payload)))
+		if err != nil {
+			return fmt.Errorf("kolom '%s' rusak: %v", col.Name, err)
+		}
+		payload = raw
+	}
+	d := &columnDecoder{buf: payload}
+
+	var nulls []byte
+	nullCount := d.uvarint()
+	if nullCount > uint64(numRows) {
+		return fmt.Errorf("kolom '%s' rusak: %d NULL untuk %d baris", col.Name, nullCount, numRows)
+	}
+	if nullCount > 0 {
+		nulls = d.bytes(uint64(numRows+7) / 8)
+	}
+	// remaining adalah jumlah baris tidak NULL yang belum terisi
+	remaining := uint64(numRows) - nullCount
+	// next mengembalikan indeks baris tidak NULL berikutnya, atau -1
+	cur := 0
+	next := func() int {
+		for cur < numRows && nulls != nil && nulls[cur/8]&(1<<(cur%8)) != 0 {
+			cur++
+		}
+		if cur >= numRows {
+			return -1
+		}
+		cur++
+		return cur - 1
+	}
+	// fill mengisi run baris tidak NULL dengan val
+	fill := func(val interface{}, count uint64) bool {
+		if count == 0 || count > remaining {
+			return false
+		}
+		remaining -= count
+		for ; count > 0; count-- {
+			r := next()
+			if r < 0 {
+				return false
+			}
+			set(r, val)
+		}
+		return true
+	}
+
+	switch {
+	case col.Type == "INT" && enc == encPlain:
+		for r := next(); r >= 0 && d.err == nil; r = next() {
+			set(r, int64(d.uint64()))
+		}
+	case col.Type == "INT" && enc == encVarint:
+		for r := next(); r >= 0 && d.err == nil; r = next() {
+			set(r, d.varint())
+		}
+	case col.Type == "INT" && enc == encDelta:
+		prev := int64(0)
+		for r := next(); r >= 0 && d.err == nil; r = next() {
+			prev += d.varint()
+			set(r, prev)
+		}
+	case col.Type == "INT" && enc == encRLE:
+		for remaining > 0 && d.err == nil {
+			val := d.varint()
+			if !fill(val, d.uvarint()) {
+				d.fail()
+			}
+		}
+	case col.Type == "FLOAT" && enc == encPlain:
+		for r := next(); r >= 0 && d.err == nil; r = next() {
+			set(r, math.Float64frombits(d.uint64()))
+		}
+	case col.Type == "FLOAT" && enc == encRLE:
+		for remaining > 0 && d.err == nil {
+			val := math.Float64frombits(d.uint64())
+			if !fill(val, d.uvarint()) {
+				d.fail()
+			}
+		}
+	case col.Type == "TEKS" && enc == encPlain:
+		for r := next(); r >= 0 && d.err == nil; r = next() {
+			set(r, d.text())
+		}
+	case col.Type == "TEKS" && (enc == encDict || enc == encDictRLE):
+		size := d.uvarint()
+		if size > uint64(len(payload)) {
+			d.fail()
+			break
+		}
+		dict := make([]string, size)
+		for i := range dict {
+			dict[i] = d.text()
+		}
+		code := func() string {
+			c := d.uvarint()
+			if c >= uint64(len(dict)) {
+				d.fail()
+				return ""
+			}
+			return dict[c]
+		}
+		if enc == encDict {
+			for r := next(); r >= 0 && d.err == nil; r = next() {
+				set(r, code())
+			}
+		} else {
+			for remaining > 0 && d.err == nil {
+				val := code()
+				if !fill(val, d.uvarint()) {
+					d.fail()
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("encoding %d tidak dikenal untuk kolom '%s' (%s)", enc, col.Name, col.Type)
+	}
+
+	if d.err != nil {
+		return fmt.Errorf("kolom '%s' rusak: %v", col.Name, d.err)
+	}
+	return nil
+}
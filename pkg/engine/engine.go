@@ -1,12 +1,16 @@
 package engine
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Dziqha/BensinDB/pkg/pager"
 	"github.com/Dziqha/BensinDB/pkg/parser"
 	"github.com/Dziqha/BensinDB/pkg/query"
 	"github.com/Dziqha/BensinDB/pkg/tangki"
@@ -17,102 +21,275 @@ type Engine struct {
 	mu      sync.RWMutex
 	file    string 
 	dirty   bool  
+	views   map[string]*view
+	viewSeq int
+
+	stats            map[string]*query.TableStats
+	changes          map[string]int
+	analyzeThreshold float64
+
+	timeouts     Timeouts
+	readOnly     bool
+	durability   Durability
+	memoryLimit  int64
+	memUsed      int64
+	memStale     bool
+	columnCache  bool
+	compress     bool
+	// pool tidak nil pada mode halaman (Options.PageCache)
+	pool         *pager.BufferPool
+	// lazyFile adalah file asal tangki yang belum dimuat (Options.LazyLoad)
+	lazyFile     *os.File
+	// wal tidak nil pada DurabilityFsync (lihat wal.go); walLSN adalah
+	// posisi WAL yang sudah tercakup oleh file yang dimuat
+	wal          *wal
+	walLSN       int64
+	walArchive   string
+	logger       Logger
+	metrics      Metrics
+
+	// Checkpoint: version bertambah setiap perubahan, savedVersion adalah
+	// versi yang terakhir tertulis ke file dan savedPages menahan halaman
+	// yang dirujuk file itu (keduanya dijaga fileMu).
+	version          uint64
+	kickedAt         uint64
+	checkpointWrites int
+	kickCheckpoint   chan struct{}
+	stopCheckpoint   chan struct{}
+	checkpointDone   chan struct{}
+	fileMu           sync.Mutex
+	savedVersion     uint64
+	savedPages       *pager.Lease
+	statusMu         sync.Mutex
+	status           CheckpointStatus
+
+	// lock dipegang dari open sampai Close; nil untuk engine di memori.
+	lock *fileLock
+
+	// current adalah snapshot terakhir untuk pembaca, nil bila harus dibuat
+	// ulang (lihat snapshot.go).
+	current atomic.Pointer[Engine]
+
+	// stateMu menjaga peta tangkis/stats/changes, version, dirty, dan
+	// hitungan memori saat beberapa penulis berjalan bersamaan, serta
+	// tangkiLocks (lihat locks.go).
+	stateMu     sync.Mutex
+	tangkiLocks map[string]*sync.Mutex
+	// pending menyimpan isi katalog sebelum diubah penulis yang belum
+	// selesai mencatat perubahannya di WAL (lihat stage).
+	pending map[string]pendingEntry
 }
 
-func OpenTangki(filepath string) (*Engine, error) {
-	eng := &Engine{
-		tangkis: make(map[string]*tangki.Tangki),
-		file:    filepath,
-		dirty:   false,
-	}
-
-	if _, err := os.Stat(filepath); os.IsNotExist(err) {
-		return eng, nil
-	}
-
-	if err := Load(eng, filepath); err != nil {
-		return nil, err
-	}
-
-	eng.dirty = false
+// Timeouts adalah batas waktu bawaan untuk perintah yang context-nya tidak
+// punya deadline sendiri. Nilai 0 berarti tanpa batas.
+type Timeouts struct {
+	Jalankan time.Duration
+	Query    time.Duration
+}
 
-	return eng, nil
+// OpenTangki membuka file .bensin, atau engine kosong bila file belum ada.
+// Gunakan OpenTangkiWithOptions untuk batas waktu dan pengaturan lainnya.
+// File dikunci sampai Close; engine kedua pada file yang sama gagal dengan
+// ErrLocked.
+func OpenTangki(filepath string) (*Engine, error) {
+	return OpenTangkiWithOptions(filepath, Options{})
 }
 
 func (e *Engine) Jalankan(fql string) error {
+	return e.JalankanContext(context.Background(), fql)
+}
+
+// JalankanContext seperti Jalankan, tetapi berhenti dengan ctx.Err() bila
+// ctx selesai sebelum lock didapat atau sebelum tangki diubah.
+func (e *Engine) JalankanContext(ctx context.Context, fql string) error {
 	p := parser.NewParser(fql)
 	q, err := p.Parse()
 	if err != nil {
 		return fmt.Errorf("parse error: %v", err)
 	}
+	if e.readOnly && q.Type != "EXPORT" {
+		return errReadOnly
+	}
 	
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	start := time.Now()
+	err = e.jalankan(ctx, q, nil, fql)
+	e.observe(q.Type, start, err)
+	return err
+}
+
+// jalankan menjalankan perintah yang mengubah database. src adalah sumber
+// data IMPOR dari ImportCSV, atau nil. fql adalah teks perintah untuk WAL.
+func (e *Engine) jalankan(ctx context.Context, q *parser.Query, src *importSource, fql string) (err error) {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Jalankan)
+	defer cancel()
+	if q.Type == "EXPORT" {
+		// EKSPOR hanya membaca snapshot, jadi tidak butuh lock
+		return e.exportFile(ctx, q)
+	}
+	target, inserted := writeTarget(q)
+	unlock, locked, err := e.lockFor(ctx, q, target)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	
+	if target != "" {
+		if err := e.ensureWritable(target); err != nil {
+			return err
+		}
+	}
+	// changed adalah jumlah baris yang diubah, untuk statistik
+	changed := 0
 	
 	switch q.Type {
 	case "CREATE":
 		err = e.createTangki(q)
 	case "INSERT":
 		err = e.insertData(q)
+		changed = 1
 	case "UPDATE":
-		err = e.updateData(q)
+		changed, err = e.updateData(q)
 	case "DELETE":
-		err = e.deleteData(q)
+		changed, err = e.deleteData(q)
 	case "JOIN":
 		err = e.joinTangki(q)
 	case "UNION":
 		err = e.unionTangki(q)
+	case "CREATE_VIEW":
+		err = e.createView(ctx, q)
+	case "REFRESH_VIEW":
+		err = e.refreshView(ctx, q)
+	case "ANALYZE":
+		err = e.analyzeTangki(q)
+	case "IMPORT":
+		var res *ImportResult
+		if res, err = e.importData(ctx, q, src); err == nil {
+			changed = res.Rows
+			if !res.Created {
+				inserted = res.Rows
+			}
+		}
 	default:
 		return fmt.Errorf("perintah tidak didukung untuk Jalankan: %s", q.Type)
 	}
 	
-	if err == nil {
-		e.dirty = true
+	if err == nil && target != "" {
+		e.trackChanges(q, target, changed)
+		err = e.refreshDependents(target, inserted)
 	}
+	// Perubahan baru diterbitkan setelah tercatat di WAL; bila gagal,
+	// pembaca tidak pernah melihatnya
+	if err == nil && e.wal != nil {
+		// Sumber IMPOR tidak bisa dijalankan ulang dari teks perintahnya,
+		// jadi hasilnya langsung ditulis sebagai checkpoint
+		if q.Type == "IMPORT" {
+			err = e.savePending()
+		} else {
+			err = e.logChange(walStatement, fql)
+		}
+	}
+	if err != nil {
+		e.rollback(locked)
+		return err
+	}
+	e.commit(locked, q.Type != "INSERT")
 	
-	return err
+	return nil
+}
+
+var errReadOnly = fmt.Errorf("database dibuka hanya-baca")
+
+// writeTarget mengembalikan tangki yang diubah oleh q dan jumlah baris
+// yang ditambahkan di akhir tangki (0 bila perubahan bukan ISI).
+func writeTarget(q *parser.Query) (string, int) {
+	switch q.Type {
+	case "CREATE":
+		return q.Tangki, 0
+	case "INSERT":
+		return q.Tangki, 1
+	case "UPDATE", "DELETE", "IMPORT":
+		return q.Tangki, 0
+	case "JOIN":
+		return q.JoinInfo.NewTangki, 0
+	case "UNION":
+		return q.UnionInfo.NewTangki, 0
+	}
+	return "", 0
 }
 
 func (e *Engine) Query(fql string) ([]tangki.Row, error) {
+	return e.QueryContext(context.Background(), fql)
+}
+
+// QueryContext seperti Query, tetapi scan, join, pengurutan, dan
+// pengelompokan berhenti dengan ctx.Err() begitu ctx dibatalkan. Query
+// membaca snapshot terakhir tanpa lock, jadi tidak menunggu Jalankan dan
+// tidak melihat perubahan yang terjadi selama query berjalan.
+func (e *Engine) QueryContext(ctx context.Context, fql string) ([]tangki.Row, error) {
 	p := parser.NewParser(fql)
 	q, err := p.Parse()
 	if err != nil {
 		return nil, fmt.Errorf("parse error: %v", err)
 	}
 	
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	start := time.Now()
+	rows, err := e.query(ctx, q)
+	e.observe(q.Type, start, err)
+	return rows, err
+}
+
+func (e *Engine) query(ctx context.Context, q *parser.Query) ([]tangki.Row, error) {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Query)
+	defer cancel()
 	
+	return e.snapshot().queryNoLock(ctx, q)
+}
+
+// queryNoLock menjalankan query baca yang sudah diparse.
+// Asumsi: e adalah snapshot, atau read lock sudah diambil oleh caller
+func (e *Engine) queryNoLock(ctx context.Context, q *parser.Query) ([]tangki.Row, error) {
 	switch q.Type {
 	case "SELECT":
-		return e.selectData(q)
+		return e.selectData(ctx, q)
+	case "EXPLAIN":
+		return e.explainSelect(ctx, q.Select)
 	case "ORDER":
-		return e.orderData(q)
+		return e.orderData(ctx, q)
 	case "GROUP":
-		return e.groupData(q)
+		return e.groupData(ctx, q)
 	default:
 		return nil, fmt.Errorf("perintah tidak didukung untuk Query: %s", q.Type)
 	}
 }
 
+// GetTangki mengembalikan salinan tangki pada snapshot terakhir. Salinan
+// itu tidak berubah oleh Jalankan berikutnya, dan mengubahnya tidak
+// mengubah database; panggil lagi untuk versi baru. Semua baris dimuat ke
+// Rows, juga pada mode halaman (Options.PageCache); pakai QueryIter untuk
+// membaca tangki besar tanpa memuatnya. Tangki lazy (Options.LazyLoad)
+// dimuat dulu. Hasilnya false bila tangki tidak ada atau gagal dimuat.
 func (e *Engine) GetTangki(name string) (*tangki.Tangki, bool) {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
-	
-	tangki, exists := e.tangkis[name]
-	return tangki, exists
+	t, err := e.snapshot().getTangkiNoLock(name)
+	if err != nil {
+		return nil, false
+	}
+	t, err = t.Copy()
+	return t, err == nil
 }
 
-func (e *Engine) getTangkiNoLock(name string) (*tangki.Tangki, bool) {
+func (e *Engine) getTangkiNoLock(name string) (*tangki.Tangki, error) {
 	tangki, exists := e.tangkis[name]
-	return tangki, exists
+	if !exists {
+		return nil, fmt.Errorf("tangki '%s' tidak ditemukan", name)
+	}
+	return tangki.Resolve()
 }
 
 func (e *Engine) ListTangki() []string {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
-	
-	return e.listTangkiNoLock()
+	return e.snapshot().listTangkiNoLock()
 }
 
 func (e *Engine) listTangkiNoLock() []string {
@@ -124,15 +301,34 @@ func (e *Engine) listTangkiNoLock() []string {
 }
 
 func (e *Engine) DropTangki(name string) error {
+	if e.readOnly {
+		return errReadOnly
+	}
+	return e.dropTangki(name)
+}
+
+func (e *Engine) dropTangki(name string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	
 	if _, exists := e.tangkis[name]; !exists {
 		return fmt.Errorf("tangki '%s' tidak ditemukan", name)
 	}
+	if err := e.ensureWritable(name); err != nil {
+		return err
+	}
 	
+	e.stateMu.Lock()
+	e.stage(name)
 	delete(e.tangkis, name)
-	e.dirty = true
+	delete(e.tangkiLocks, name)
+	e.forgetStats(name)
+	e.stateMu.Unlock()
+	if err := e.logChange(walDropTangki, name); err != nil {
+		e.rollback(nil)
+		return err
+	}
+	e.commit(nil, true)
 	return nil
 }
 
@@ -154,147 +350,222 @@ func (e *Engine) createTangki(q *parser.Query) error {
         }
     }
     
-    e.tangkis[q.Tangki] = tangki.NewTangki(q.Tangki, columns)
-    return nil
+    return e.installTangki(tangki.NewTangki(q.Tangki, columns))
 }
 
 func (e *Engine) insertData(q *parser.Query) error {
-	tangki, exists := e.tangkis[q.Tangki]
-	if !exists {
-		return fmt.Errorf("tangki '%s' tidak ditemukan", q.Tangki)
+	tangki, err := e.forkTangki(q.Tangki)
+	if err != nil {
+		return err
+	}
+	if err := e.checkMemory(q.Values); err != nil {
+		return err
 	}
 	
-	return tangki.AddRow(q.Values...)
+	if err := tangki.AddRow(q.Values...); err != nil {
+		e.stateMu.Lock()
+		e.memStale = true
+		e.stateMu.Unlock()
+		return err
+	}
+	return e.installTangki(tangki)
 }
 
-func (e *Engine) selectData(q *parser.Query) ([]tangki.Row, error) {
+func (e *Engine) selectData(ctx context.Context, q *parser.Query) ([]tangki.Row, error) {
+	if q.Select != nil {
+		rel, err := e.execSelect(ctx, q.Select, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return rel.Rows, nil
+	}
+
 	tangki, exists := e.tangkis[q.Tangki]
 	if !exists {
 		return nil, fmt.Errorf("tangki '%s' tidak ditemukan", q.Tangki)
 	}
+	tangki, err := tangki.Resolve()
+	if err != nil {
+		return nil, err
+	}
 
 	condition := e.buildConditionFunc(tangki, q.Condition)
 	return tangki.SelectRows(q.Columns, condition)
 }
 
-func (e *Engine) updateData(q *parser.Query) error {
-    tangki, exists := e.tangkis[q.Tangki]
-    if !exists {
-        return fmt.Errorf("tangki '%s' tidak ditemukan", q.Tangki)
+// updateData mengembalikan jumlah baris yang diubah
+func (e *Engine) updateData(q *parser.Query) (int, error) {
+    tangki, err := e.forkTangki(q.Tangki)
+    if err != nil {
+        return 0, err
     }
     
     column := q.Columns[0] // Nama kolom (string)
     value := q.Values[0]   // Nilai baru
     
-    if expr, ok := value.(map[string]interface{}); ok {
-        if expr["type"] == "expression" {
-            return e.updateWithExpression(tangki, column, expr, q.Condition)
+    changed := 0
+    if expr, ok := value.(map[string]interface{}); ok && expr["type"] == "expression" {
+        changed, err = e.updateWithExpression(tangki, column, expr, q.Condition)
+    } else {
+        condition := countMatches(e.buildConditionFunc(tangki, q.Condition), &changed)
+        err = tangki.UpdateRows(column, value, condition)
+    }
+    if err != nil {
+        return 0, err
+    }
+    return changed, e.installTangki(tangki)
+}
+
+// countMatches membungkus condition agar setiap baris yang lolos ikut
+// dihitung ke *n
+func countMatches(condition func(tangki.Row) bool, n *int) func(tangki.Row) bool {
+    return func(row tangki.Row) bool {
+        if condition(row) {
+            *n++
+            return true
         }
+        return false
     }
-    
-    condition := e.buildConditionFunc(tangki, q.Condition)
-    return tangki.UpdateRows(column, value, condition)
 }
 
-func (e *Engine) updateWithExpression(tangki *tangki.Tangki, column string, expr map[string]interface{}, cond *parser.Condition) error {
-    targetIndex := tangki.GetColumnIndex(column)
+func (e *Engine) updateWithExpression(t *tangki.Tangki, column string, expr map[string]interface{}, cond *parser.Condition) (int, error) {
+    targetIndex := t.GetColumnIndex(column)
     sourceColName := expr["column"].(string)
-    sourceIndex := tangki.GetColumnIndex(sourceColName)
+    sourceIndex := t.GetColumnIndex(sourceColName)
 
     if targetIndex == -1 {
-        return fmt.Errorf("kolom target '%s' tidak ditemukan", column)
+        return 0, fmt.Errorf("kolom target '%s' tidak ditemukan", column)
     }
     if sourceIndex == -1 {
-        return fmt.Errorf("kolom sumber '%s' tidak ditemukan", sourceColName)
+        return 0, fmt.Errorf("kolom sumber '%s' tidak ditemukan", sourceColName)
     }
 
     operator := expr["operator"].(string)
     value := expr["value"]
+    switch operator {
+    case "+", "-", "*", "/":
+    default:
+        return 0, fmt.Errorf("operator tidak didukung: %s", operator)
+    }
+    valFloat := toFloat(value)
     
-    conditionFunc := e.buildConditionFunc(tangki, cond)
+    conditionFunc := e.buildConditionFunc(t, cond)
     
-    for i := range tangki.Rows {
-        if conditionFunc(tangki.Rows[i]) {
-            currentVal := toFloat(tangki.Rows[i][sourceIndex])
-            
-            var newVal float64
-            valFloat := toFloat(value)
-
-            switch operator {
-            case "+": newVal = currentVal + valFloat
-            case "-": newVal = currentVal - valFloat
-            case "*": newVal = currentVal * valFloat
-            case "/": newVal = currentVal / valFloat
-            default: return fmt.Errorf("operator tidak didukung: %s", operator)
-            }
-            
-            tangki.Rows[i][targetIndex] = newVal
+    // Baris yang diubah disalin agar snapshot lama tidak ikut berubah
+    changed := 0
+    err := t.RewriteRows(func(row tangki.Row) (tangki.Row, bool) {
+        if !conditionFunc(row) {
+            return row, true
         }
+        changed++
+        currentVal := toFloat(row[sourceIndex])
+        
+        var newVal float64
+        switch operator {
+        case "+": newVal = currentVal + valFloat
+        case "-": newVal = currentVal - valFloat
+        case "*": newVal = currentVal * valFloat
+        case "/": newVal = currentVal / valFloat
+        }
+        
+        row = row.Clone()
+        row[targetIndex] = newVal
+        return row, true
+    })
+    if err != nil {
+        return 0, err
     }
-    return nil
+    return changed, nil
 }
-func (e *Engine) deleteData(q *parser.Query) error {
-	tangki, exists := e.tangkis[q.Tangki]
-	if !exists {
-		return fmt.Errorf("tangki '%s' tidak ditemukan", q.Tangki)
+// deleteData mengembalikan jumlah baris yang dihapus
+func (e *Engine) deleteData(q *parser.Query) (int, error) {
+	tangki, err := e.forkTangki(q.Tangki)
+	if err != nil {
+		return 0, err
 	}
 	
-	condition := e.buildConditionFunc(tangki, q.Condition)
-	return tangki.DeleteRows(condition)
+	changed := 0
+	condition := countMatches(e.buildConditionFunc(tangki, q.Condition), &changed)
+	if err := tangki.DeleteRows(condition); err != nil {
+		return 0, err
+	}
+	return changed, e.installTangki(tangki)
 }
 
 func (e *Engine) joinTangki(q *parser.Query) error {
-	tangki1, exists1 := e.tangkis[q.JoinInfo.Tangki1]
-	tangki2, exists2 := e.tangkis[q.JoinInfo.Tangki2]
-	
-	if !exists1 {
-		return fmt.Errorf("tangki '%s' tidak ditemukan", q.JoinInfo.Tangki1)
+	tangki1, err := e.lookupTangki(q.JoinInfo.Tangki1)
+	if err != nil {
+		return err
 	}
-	if !exists2 {
-		return fmt.Errorf("tangki '%s' tidak ditemukan", q.JoinInfo.Tangki2)
+	tangki2, err := e.lookupTangki(q.JoinInfo.Tangki2)
+	if err != nil {
+		return err
+	}
+	tangki1, err = tangki1.Materialize()
+	if err != nil {
+		return err
+	}
+	tangki2, err = tangki2.Materialize()
+	if err != nil {
+		return err
 	}
 	
-	result := query.Join(tangki1, tangki2, q.JoinInfo.OnColumn1, q.JoinInfo.OnColumn2)
-	result.Name = q.JoinInfo.NewTangki
-	e.tangkis[q.JoinInfo.NewTangki] = result
+	conds := make([]query.JoinCondition, len(q.JoinInfo.Conditions))
+	for i, c := range q.JoinInfo.Conditions {
+		conds[i] = query.JoinCondition{LeftColumn: c.Column1, Operator: c.Operator, RightColumn: c.Column2}
+	}
 	
-	return nil
+	result, err := query.JoinWith(tangki1, tangki2, q.JoinInfo.Type, conds)
+	if err != nil {
+		return err
+	}
+	result.Name = q.JoinInfo.NewTangki
+	return e.installTangki(result)
 }
 
 func (e *Engine) unionTangki(q *parser.Query) error {
 	tangkis := make([]*tangki.Tangki, len(q.UnionInfo.Tangkis))
 	
 	for i, name := range q.UnionInfo.Tangkis {
-		t, exists := e.tangkis[name]
-		if !exists {
-			return fmt.Errorf("tangki '%s' tidak ditemukan", name)
+		t, err := e.lookupTangki(name)
+		if err != nil {
+			return err
+		}
+		t, err = t.Materialize()
+		if err != nil {
+			return err
 		}
 		tangkis[i] = t
 	}
 	
-	result := query.Union(tangkis...)
+	result, err := query.SetOp(q.UnionInfo.Operation, q.UnionInfo.All, tangkis...)
+	if err != nil {
+		return err
+	}
 	result.Name = q.UnionInfo.NewTangki
-	e.tangkis[q.UnionInfo.NewTangki] = result
-	
-	return nil
+	return e.installTangki(result)
 }
 
-func (e *Engine) orderData(q *parser.Query) ([]tangki.Row, error) {
-	tangki, exists := e.tangkis[q.Tangki]
-	if !exists {
-		return nil, fmt.Errorf("tangki '%s' tidak ditemukan", q.Tangki)
+func (e *Engine) orderData(ctx context.Context, q *parser.Query) ([]tangki.Row, error) {
+	tangki, err := e.readTangki(q.Tangki)
+	if err != nil {
+		return nil, err
+	}
+	// Hasil URUTKAN adalah seluruh tangki, jadi semua barisnya dimuat
+	if tangki, err = tangki.Materialize(); err != nil {
+		return nil, err
 	}
 	
-	return query.OrderBy(tangki, q.OrderInfo.Column, q.OrderInfo.Ascending), nil
+	return query.OrderByContext(ctx, tangki, q.OrderInfo.Column, q.OrderInfo.Ascending)
 }
 
-func (e *Engine) groupData(q *parser.Query) ([]tangki.Row, error) {
-	tangki, exists := e.tangkis[q.Tangki]
-	if !exists {
-		return nil, fmt.Errorf("tangki '%s' tidak ditemukan", q.Tangki)
+func (e *Engine) groupData(ctx context.Context, q *parser.Query) ([]tangki.Row, error) {
+	tangki, err := e.readTangki(q.Tangki)
+	if err != nil {
+		return nil, err
 	}
 	
-	return query.GroupBy(tangki, q.GroupInfo.Column, q.GroupInfo.AggregateFunc, q.GroupInfo.AggregateCol)
+	return query.GroupByContext(ctx, tangki, q.GroupInfo.Column, q.GroupInfo.AggregateFunc, q.GroupInfo.AggregateCol)
 }
 
 func (e *Engine) buildConditionFunc(t *tangki.Tangki, cond *parser.Condition) func(tangki.Row) bool {
@@ -320,23 +591,49 @@ func (e *Engine) buildConditionFunc(t *tangki.Tangki, cond *parser.Condition) fu
     }
 }
 
-func (e *Engine) registerTangki(t *tangki.Tangki) {
-	e.tangkis[t.Name] = t
+func (e *Engine) registerTangki(t *tangki.Tangki) error {
+	return e.installTangki(t)
 }
 
-
+// Close menyimpan perubahan yang belum tertulis lalu melepas lock file.
 func (e *Engine) Close() error {
+	e.stopCheckpointer()
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	if !e.dirty {
-		return nil
-	}
+	e.stateMu.Lock()
+	dirty := e.dirty
+	e.stateMu.Unlock()
 
-	return e.saveNoLock()
+	var err error
+	if dirty && e.persistent() {
+		err = e.saveNoLock()
+	}
+	if perr := e.closePages(); err == nil {
+		err = perr
+	}
+	if e.wal != nil {
+		if werr := e.wal.close(); err == nil {
+			err = werr
+		}
+		e.wal = nil
+	}
+	if e.lazyFile != nil {
+		e.lazyFile.Close()
+	}
+	if lerr := e.lock.release(); err == nil {
+		err = lerr
+	}
+	e.lock = nil
+	return err
 }
 
 func compareValues(a interface{}, op string, b interface{}) bool {
+    if a == nil || b == nil {
+        return false
+    }
+
     switch va := a.(type) {
     case int64:
         if vb, ok := b.(int64); ok {
@@ -398,4 +695,33 @@ func toFloat(val interface{}) float64 {
     default:
         return 0
     }
-}
\ No newline at end of file
+}
+// withTimeout memasang batas waktu bawaan d bila ctx belum punya deadline.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// lockRetry adalah jeda antar percobaan mengambil lock saat ctx bisa
+// dibatalkan.
+const lockRetry = time.Millisecond
+
+// lockContext mengambil lock lewat try, menyerah dengan ctx.Err() bila ctx
+// selesai lebih dulu. Context yang tidak bisa dibatalkan langsung memakai
+// lock biasa.
+func lockContext(ctx context.Context, try func() bool, lock func()) error {
+	if ctx.Done() == nil {
+		lock()
+		return nil
+	}
+	for !try() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetry):
+		}
+	}
+	return nil
+}
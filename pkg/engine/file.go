@@ -2,10 +2,14 @@ package engine
 
 import (
 	"bufio"
+	"context"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"os"
+	"github.com/Dziqha/BensinDB/pkg/pager"
+	"github.com/Dziqha/BensinDB/pkg/query"
 	"github.com/Dziqha/BensinDB/pkg/tangki"
 )
 
@@ -15,33 +19,169 @@ const (
 	TypeTeks  = 2
 )
 
+// Versi format file .bensin. Minor 1 menambahkan bitmap NULL per baris,
+// minor 2 menambahkan daftar pandangan setelah semua tangki, minor 3
+// menambahkan statistik ANALISIS TANGKI setelah pandangan, minor 4
+// menulis baris per kolom dengan encoding per kolom (lihat encoding.go),
+// minor 5 menambahkan byte penyimpanan per tangki: baris di file ini atau
+// daftar halaman di file .pages (mode halaman), minor 6 menulis panjang
+// string (nama, definisi pandangan, nilai statistik) sebagai uvarint,
+// bukan uint16, minor 7 menambahkan posisi WAL yang sudah tercakup
+// (uint64, lihat wal.go) di akhir file.
+const (
+	formatMajor = 1
+	formatMinor = 7
+)
+
+const (
+	storageInline = 0
+	storagePaged  = 1
+)
+
+// pagesPath adalah file halaman milik database di path.
+func pagesPath(path string) string {
+	return path + ".pages"
+}
+
+// Tag nilai statistik (min, max, batas histogram).
+const (
+	statNull  = 0
+	statInt   = 1
+	statFloat = 2
+	statTeks  = 3
+)
+
+const (
+	viewKindPlain        = 0
+	viewKindMaterialized = 1
+)
+
 // saveNoLock adalah versi internal Save yang dipanggil dari Close()
-// Asumsi: lock sudah diambil oleh caller
+// Yang ditulis adalah snapshot terakhir yang sudah diterbitkan
+// Asumsi: lock katalog eksklusif sudah diambil oleh caller, jadi semua
+// perubahan di snapshot sudah tercatat di WAL
 func (e *Engine) saveNoLock() error {
-	file, err := os.Create(e.file)
+	snap := e.snapshot()
+	if err := e.persist(snap, snap.version, e.walEnd()); err != nil {
+		return err
+	}
+	e.stateMu.Lock()
+	if e.version == snap.version {
+		e.dirty = false
+	}
+	e.stateMu.Unlock()
+	return nil
+}
+
+// savePending menulis keadaan terbaru sebagai checkpoint, termasuk
+// perubahan yang belum di-commit, dengan versi yang akan dipakai commit
+// berikutnya.
+// Asumsi: lock katalog eksklusif sudah diambil oleh caller
+func (e *Engine) savePending() error {
+	e.stateMu.Lock()
+	pending := e.pending
+	e.pending = nil
+	snap := e.snapshotNoLock()
+	snap.version = e.version + 1
+	e.pending = pending
+	e.stateMu.Unlock()
+	return e.persist(snap, snap.version, e.walEnd())
+}
+
+// Save adalah fungsi publik yang bisa dipanggil dari luar
+// Yang ditulis adalah snapshot terakhir, jadi tidak perlu lock engine.
+// Save ke file milik engine sendiri dicatat seperti checkpoint, agar
+// halaman dan WAL yang dirujuknya tetap terjaga.
+func Save(eng *Engine, filepath string) error {
+	if filepath == eng.file {
+		snap, lsn := eng.capture()
+		return eng.persist(snap, snap.version, lsn)
+	}
+	return eng.snapshot().writeFile(filepath, eng.walEnd())
+}
+
+// writeFile menulis snapshot ke file sementara lalu me-rename-nya, agar
+// pembaca lain tidak pernah melihat file yang setengah tertulis.
+func (e *Engine) writeFile(filepath string, lsn int64) error {
+	tmp, err := e.writeTemp(filepath, lsn)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	if err := os.Rename(tmp, filepath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeTemp menulis snapshot ke file sementara di direktori yang sama
+// dengan path dan mengembalikan namanya. lsn adalah posisi WAL yang
+// tercakup oleh snapshot.
+func (e *Engine) writeTemp(path string, lsn int64) (string, error) {
+	file, err := os.CreateTemp(tempPath(path))
+	if err != nil {
+		return "", err
+	}
+	tmp := file.Name()
+	fail := func(err error) (string, error) {
+		file.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+
+	// Daftar halaman hanya berlaku untuk file milik engine sendiri; Save ke
+	// path lain menulis semua baris ke dalam file itu
+	paged := e.pool != nil && path == e.file
+	if paged {
+		if err := e.pool.Flush(); err != nil {
+			return fail(err)
+		}
+	}
 
 	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+	if err := e.writeSnapshot(context.Background(), writer, paged, lsn); err != nil {
+		return fail(err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fail(err)
+	}
+	if e.durability == DurabilityFsync {
+		if err := file.Sync(); err != nil {
+			return fail(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
 
-	binary.Write(writer, binary.LittleEndian, uint16(1)) // major
-	binary.Write(writer, binary.LittleEndian, uint16(0)) // minor
+// writeSnapshot menulis seluruh isi e. ctx diperiksa sebelum setiap tangki
+// dan setiap kolom, jadi pembatalan tidak menunggu seluruh file selesai.
+func (e *Engine) writeSnapshot(ctx context.Context, writer *bufio.Writer, paged bool, lsn int64) error {
+	binary.Write(writer, binary.LittleEndian, uint16(formatMajor))
+	binary.Write(writer, binary.LittleEndian, uint16(formatMinor))
 
 	tangkiNames := e.listTangkiNoLock()
-	binary.Write(writer, binary.LittleEndian, uint16(len(tangkiNames)))
+	if err := writeCount(writer, len(tangkiNames), "tangki"); err != nil {
+		return err
+	}
 
 	for _, name := range tangkiNames {
-		t, ok := e.getTangkiNoLock(name)
-		if !ok {
-			continue
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		
+		t, err := e.getTangkiNoLock(name)
+		if err != nil {
+			return err
+		}
+
 		writeString(writer, t.Name)
 
-		binary.Write(writer, binary.LittleEndian, uint16(len(t.Columns)))
+		if err := writeCount(writer, len(t.Columns), "kolom di tangki '"+t.Name+"'"); err != nil {
+			return err
+		}
 		for _, col := range t.Columns {
 			writeString(writer, col.Name)
 			var tbyte byte
@@ -56,183 +196,385 @@ func (e *Engine) saveNoLock() error {
 			writer.WriteByte(tbyte)
 		}
 
+		if paged && t.Paged() {
+			writer.WriteByte(storagePaged)
+			refs := t.Pages()
+			binary.Write(writer, binary.LittleEndian, uint32(t.Len()))
+			binary.Write(writer, binary.LittleEndian, uint32(len(refs)))
+			for _, ref := range refs {
+				binary.Write(writer, binary.LittleEndian, uint32(ref.ID))
+				binary.Write(writer, binary.LittleEndian, uint16(ref.Rows))
+				binary.Write(writer, binary.LittleEndian, uint16(ref.End))
+			}
+			continue
+		}
+
+		t, err = t.Materialize()
+		if err != nil {
+			return err
+		}
+		writer.WriteByte(storageInline)
 		binary.Write(writer, binary.LittleEndian, uint32(len(t.Rows)))
-		for _, row := range t.Rows {
-			for j, col := range t.Columns {
-				val := row[j]
-				switch col.Type {
-				case "INT":
-					binary.Write(writer, binary.LittleEndian, toInt64(val))
-				case "FLOAT":
-					f := val.(float64)
-					binary.Write(writer, binary.LittleEndian, math.Float64bits(f))
-				case "TEKS":
-					writeString(writer, val.(string))
-				}
+		for j, col := range t.Columns {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := writeColumn(writer, col, t.Rows, j, e.compress); err != nil {
+				return err
 			}
 		}
 	}
-	return nil
+
+	// Pandangan ditulis sesuai urutan pembuatan agar dependensinya
+	// sudah terdaftar saat dimuat kembali
+	views := e.viewsBySeq()
+	if err := writeCount(writer, len(views), "pandangan"); err != nil {
+		return err
+	}
+	for _, v := range views {
+		writeString(writer, v.name)
+		kind := byte(viewKindPlain)
+		if v.materialized {
+			kind = viewKindMaterialized
+		}
+		writer.WriteByte(kind)
+		writeString(writer, v.definition)
+	}
+
+	if err := writeCount(writer, len(e.stats), "statistik"); err != nil {
+		return err
+	}
+	for name, stats := range e.stats {
+		writeString(writer, name)
+		binary.Write(writer, binary.LittleEndian, uint32(stats.Rows))
+		binary.Write(writer, binary.LittleEndian, uint32(e.changes[name]))
+		if err := writeCount(writer, len(stats.Columns), "kolom statistik"); err != nil {
+			return err
+		}
+		for _, cs := range stats.Columns {
+			writeString(writer, cs.Name)
+			binary.Write(writer, binary.LittleEndian, uint32(cs.Distinct))
+			binary.Write(writer, binary.LittleEndian, math.Float64bits(cs.NullFraction))
+			writeStatValue(writer, cs.Min)
+			writeStatValue(writer, cs.Max)
+			if err := writeCount(writer, len(cs.Bounds), "batas histogram"); err != nil {
+				return err
+			}
+			for _, b := range cs.Bounds {
+				writeStatValue(writer, b)
+			}
+		}
+	}
+	return binary.Write(writer, binary.LittleEndian, uint64(lsn))
 }
 
-// Save adalah fungsi publik yang bisa dipanggil dari luar
-// Fungsi ini mengambil lock sendiri
-func Save(eng *Engine, filepath string) error {
-	eng.mu.RLock()
-	defer eng.mu.RUnlock()
-	
-	file, err := os.Create(filepath)
+// Load memuat seluruh isi file path ke eng.
+func Load(eng *Engine, path string) error {
+	return load(eng, path, false)
+}
+
+// load membaca file snapshot secara streaming, tangki demi tangki, tanpa
+// menampung seluruh file di memori. File yang terpotong atau rusak
+// menghasilkan *CorruptError. Dengan lazy, baris tangki (format minor 4 ke
+// atas) dilewati dan baru didekode saat tangki itu pertama dipakai.
+func load(eng *Engine, path string, lazy bool) error {
+	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	// File tetap terbuka bila ada tangki lazy yang membacanya (lihat Close)
+	defer func() {
+		if eng.lazyFile != file {
+			file.Close()
+		}
+	}()
 
-	binary.Write(writer, binary.LittleEndian, uint16(1)) // major
-	binary.Write(writer, binary.LittleEndian, uint16(0)) // minor
+	r := newSnapshotReader(file, 0, info.Size())
+	major := r.uint16("versi format")
+	minor := r.uint16("versi format")
+	if r.err != nil {
+		return r.err
+	}
+	if major != formatMajor || minor > formatMinor {
+		return fmt.Errorf("versi format %d.%d tidak didukung (engine %d.%d)", major, minor, formatMajor, formatMinor)
+	}
+	r.minor = minor
+
+	numTangki := r.count(uint64(r.uint16("jumlah tangki")), 4, "tangki")
+	for i := 0; i < numTangki && r.err == nil; i++ {
+		name := r.string("nama tangki")
+		cols := make([]tangki.Column, r.count(uint64(r.uint16("jumlah kolom")), 3, "kolom"))
+		for j := range cols {
+			cols[j].Name = r.string("nama kolom")
+			switch ctype := r.uint8("tipe kolom"); ctype {
+			case TypeInt:
+				cols[j].Type = "INT"
+			case TypeFloat:
+				cols[j].Type = "FLOAT"
+			case TypeTeks:
+				cols[j].Type = "TEKS"
+			default:
+				r.corrupt("tipe kolom %d tidak dikenal", ctype)
+			}
+		}
 
-	tangkiNames := eng.listTangkiNoLock()
-	binary.Write(writer, binary.LittleEndian, uint16(len(tangkiNames)))
+		storage := byte(storageInline)
+		if minor >= 5 {
+			storage = r.uint8("jenis penyimpanan")
+		}
+		if r.err != nil {
+			break
+		}
 
-	for _, name := range tangkiNames {
-		t, ok := eng.getTangkiNoLock(name)
-		if !ok {
-			continue
+		var t *tangki.Tangki
+		switch {
+		case storage == storagePaged:
+			refs := readPageRefs(r)
+			if r.err != nil {
+				break
+			}
+			if eng.pool == nil {
+				if err := eng.openPages(pagesPath(path), defaultPageCache); err != nil {
+					return err
+				}
+			}
+			t = tangki.NewPagedTangki(name, cols, eng.pool, refs)
+		case storage != storageInline:
+			r.corrupt("jenis penyimpanan %d tidak dikenal", storage)
+		case lazy && minor >= 4:
+			start := r.off
+			numRows := skipRows(r, cols)
+			end := r.off
+			t = tangki.NewLazyTangki(name, cols, numRows, func() (*tangki.Tangki, error) {
+				return eng.loadLazy(file, start, end, name, cols, minor)
+			})
+			eng.lazyFile = file
+		default:
+			t = tangki.NewTangki(name, cols)
+			t.Rows = readRows(r, cols, minor)
 		}
-		
-		writeString(writer, t.Name)
+		if r.err != nil {
+			break
+		}
+		if err := eng.registerTangki(t); err != nil {
+			return err
+		}
+	}
 
-		binary.Write(writer, binary.LittleEndian, uint16(len(t.Columns)))
-		for _, col := range t.Columns {
-			writeString(writer, col.Name)
-			var tbyte byte
-			switch col.Type {
-			case "INT":
-				tbyte = TypeInt
-			case "FLOAT":
-				tbyte = TypeFloat
-			case "TEKS":
-				tbyte = TypeTeks
+	if minor >= 2 && r.err == nil {
+		numViews := r.count(uint64(r.uint16("jumlah pandangan")), 5, "pandangan")
+		for i := 0; i < numViews && r.err == nil; i++ {
+			name := r.string("nama pandangan")
+			kind := r.uint8("jenis pandangan")
+			definition := r.string("definisi pandangan")
+			if r.err != nil {
+				break
+			}
+			if err := eng.loadView(name, kind == viewKindMaterialized, definition); err != nil {
+				return err
 			}
-			writer.WriteByte(tbyte)
 		}
+	}
 
-		binary.Write(writer, binary.LittleEndian, uint32(len(t.Rows)))
-		for _, row := range t.Rows {
-			for j, col := range t.Columns {
-				val := row[j]
-				switch col.Type {
-				case "INT":
-					binary.Write(writer, binary.LittleEndian, toInt64(val))
-				case "FLOAT":
-					f := val.(float64)
-					binary.Write(writer, binary.LittleEndian, math.Float64bits(f))
-				case "TEKS":
-					writeString(writer, val.(string))
+	if minor >= 3 && r.err == nil {
+		numStats := r.count(uint64(r.uint16("jumlah statistik")), 12, "statistik")
+		for i := 0; i < numStats && r.err == nil; i++ {
+			name := r.string("statistik")
+			stats := &query.TableStats{Rows: int(r.uint32("statistik"))}
+			changes := int(r.uint32("statistik"))
+			stats.Columns = make([]query.ColumnStats, r.count(uint64(r.uint16("statistik")), 18, "kolom statistik"))
+			for j := range stats.Columns {
+				cs := &stats.Columns[j]
+				cs.Name = r.string("statistik")
+				cs.Distinct = int(r.uint32("statistik"))
+				cs.NullFraction = math.Float64frombits(r.uint64("statistik"))
+				cs.Min = r.statValue()
+				cs.Max = r.statValue()
+				cs.Bounds = make([]interface{}, r.count(uint64(r.uint16("statistik")), 1, "batas histogram"))
+				for k := range cs.Bounds {
+					cs.Bounds[k] = r.statValue()
 				}
 			}
+			if r.err != nil {
+				break
+			}
+			eng.stats[name] = stats
+			eng.changes[name] = changes
 		}
 	}
+	if minor >= 7 && r.err == nil {
+		eng.walLSN = int64(r.uint64("posisi WAL"))
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	eng.publish()
 	return nil
 }
 
-func Load(eng *Engine, filepath string) error {
-	data, err := os.ReadFile(filepath)
-	if err != nil {
-		return err
+// readRows membaca jumlah baris lalu isi tangki: blok kolom untuk format
+// minor 4 ke atas, atau baris demi baris untuk format lama.
+func readRows(r *snapshotReader, cols []tangki.Column, minor uint16) []tangki.Row {
+	numRows := readRowCount(r, cols)
+	if r.err != nil {
+		return nil
 	}
 
-	buf := data
-	pos := 0
-
-	readUint16 := func() uint16 {
-		if pos+2 > len(buf) { return 0 }
-		v := binary.LittleEndian.Uint16(buf[pos : pos+2])
-		pos += 2
-		return v
+	// Jumlah baris belum tentu benar, jadi rows tidak dialokasikan sekaligus
+	// tetapi tumbuh selama baris benar-benar terbaca
+	rows := make([]tangki.Row, 0, min(numRows, 1024))
+	grow := func(n int) {
+		for len(rows) < n {
+			rows = append(rows, make(tangki.Row, len(cols)))
+		}
 	}
 
-	readString := func() string {
-		lenUint := readUint16()
-		l := int(lenUint)
-		if pos+l > len(buf) {
-			return "" 
+	if minor >= 4 {
+		for j, col := range cols {
+			enc := r.uint8("encoding kolom")
+			flags := r.uint8("encoding kolom")
+			size := r.uint32("ukuran kolom")
+			start := r.off
+			payload := r.bytes(int64(size), "kolom '"+col.Name+"'")
+			if r.err != nil {
+				return nil
+			}
+			// Kolom pertama menumbuhkan rows; sesudah kolom itu lolos,
+			// numRows sudah terbukti oleh isi file
+			set := func(i int, val interface{}) {
+				if j == 0 {
+					grow(i + 1)
+				}
+				rows[i][j] = val
+			}
+			if err := decodeColumn(enc, flags, payload, col, numRows, set); err != nil {
+				r.err = &CorruptError{Offset: start, Reason: err.Error()}
+				return nil
+			}
+			grow(numRows)
 		}
-		s := string(buf[pos : pos+l])
-		pos += l
-		return s
-	}
-	readUint32 := func() uint32 {
-		v := binary.LittleEndian.Uint32(buf[pos : pos+4])
-		pos += 4
-		return v
-	}
-	readByte := func() byte {
-		b := buf[pos]
-		pos++
-		return b
+		grow(numRows)
+		return rows
 	}
 
-	_ = readUint16() // verMajor
-	_ = readUint16() // verMinor
-
-	numTangki := int(readUint16())
-
-	for i := 0; i < numTangki; i++ {
-		tName := readString()
-		numCols := int(readUint16())
-		cols := make([]tangki.Column, numCols)
+	nullBitmap := make([]byte, (len(cols)+7)/8)
+	for i := 0; i < numRows; i++ {
+		row := make(tangki.Row, len(cols))
+		if minor >= 1 {
+			r.fill(nullBitmap, "bitmap NULL")
+		}
+		for j, col := range cols {
+			if minor >= 1 && nullBitmap[j/8]&(1<<(j%8)) != 0 {
+				continue
+			}
+			switch col.Type {
+			case "INT":
+				row[j] = int64(r.uint64("nilai INT"))
+			case "FLOAT":
+				row[j] = math.Float64frombits(r.uint64("nilai FLOAT"))
+			case "TEKS":
+				row[j] = r.string("nilai TEKS")
+			}
+		}
+		if r.err != nil {
+			return nil
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
 
-		for j := 0; j < numCols; j++ {
-			cname := readString()
-			ctype := readByte()
+// readRowCount membaca jumlah baris tangki. Tangki tanpa kolom tidak punya
+// isi yang bisa membuktikan jumlah itu, jadi setiap barisnya dihitung satu
+// byte seperti jumlah lain di file.
+func readRowCount(r *snapshotReader, cols []tangki.Column) int {
+	numRows := r.uint32("jumlah baris")
+	if len(cols) == 0 {
+		return r.count(uint64(numRows), 1, "baris")
+	}
+	return int(numRows)
+}
 
-			tstr := ""
-			switch ctype {
-			case TypeInt:
-				tstr = "INT"
-			case TypeFloat:
-				tstr = "FLOAT"
-			case TypeTeks:
-				tstr = "TEKS"
-			default:
-				return fmt.Errorf("unknown column type: %d", ctype)
-			}
+// skipRows melewati isi tangki berformat blok kolom dan mengembalikan
+// jumlah barisnya.
+func skipRows(r *snapshotReader, cols []tangki.Column) int {
+	numRows := readRowCount(r, cols)
+	for _, col := range cols {
+		r.skip(2, "encoding kolom")
+		size := r.uint32("ukuran kolom")
+		r.skip(int64(size), "kolom '"+col.Name+"'")
+	}
+	return numRows
+}
 
-			cols[j] = tangki.Column{Name: cname, Type: tstr}
+func readPageRefs(r *snapshotReader) []tangki.PageRef {
+	r.uint32("jumlah baris")
+	numPages := r.count(uint64(r.uint32("jumlah halaman")), 8, "halaman")
+	if r.err != nil {
+		return nil
+	}
+	refs := make([]tangki.PageRef, numPages)
+	for i := range refs {
+		refs[i] = tangki.PageRef{
+			ID:   pager.PageID(r.uint32("daftar halaman")),
+			Rows: int(r.uint16("daftar halaman")),
+			End:  int(r.uint16("daftar halaman")),
 		}
+	}
+	return refs
+}
 
-		tk := tangki.NewTangki(tName, cols)
-		eng.registerTangki(tk)
-
-		numRows := int(readUint32())
-
-		for r := 0; r < numRows; r++ {
-			row := make(tangki.Row, len(cols))
-			for j, col := range cols {
-            switch col.Type {
-            case "INT":
-                row[j] = int64(binary.LittleEndian.Uint64(buf[pos : pos+8]))
-                pos += 8
-            case "FLOAT":
-                bits := binary.LittleEndian.Uint64(buf[pos : pos+8])
-                row[j] = math.Float64frombits(bits)
-                pos += 8
-            case "TEKS":
-                row[j] = readString()
-            }
-        }
-        tk.Rows = append(tk.Rows, row)
-		}
+// loadLazy mendekode isi tangki yang dilewati load, dari byte start sampai
+// end di file yang dibuka saat engine dibuka. File itu tetap terbuka
+// sampai Close, jadi checkpoint yang mengganti file tidak mempengaruhinya.
+func (e *Engine) loadLazy(file *os.File, start, end int64, name string, cols []tangki.Column, minor uint16) (*tangki.Tangki, error) {
+	r := newSnapshotReader(io.NewSectionReader(file, start, end-start), start, end)
+	r.minor = minor
+	t := tangki.NewTangki(name, cols)
+	t.Rows = readRows(r, cols, minor)
+	if r.err != nil {
+		return nil, fmt.Errorf("memuat tangki '%s': %w", name, r.err)
+	}
+	if err := e.prepareTangki(t); err != nil {
+		return nil, err
 	}
+	return t, nil
+}
 
-	return nil
+func writeStatValue(w *bufio.Writer, v interface{}) {
+	switch val := v.(type) {
+	case int, int64:
+		w.WriteByte(statInt)
+		binary.Write(w, binary.LittleEndian, toInt64(val))
+	case float64:
+		w.WriteByte(statFloat)
+		binary.Write(w, binary.LittleEndian, math.Float64bits(val))
+	case string:
+		w.WriteByte(statTeks)
+		writeString(w, val)
+	default:
+		w.WriteByte(statNull)
+	}
+}
+
+// writeCount menulis jumlah sebagai uint16. Jumlah yang tidak muat ditolak
+// agar Save tidak pernah menulis file yang tidak bisa dibaca kembali.
+func writeCount(w *bufio.Writer, n int, what string) error {
+	if n > math.MaxUint16 {
+		return fmt.Errorf("terlalu banyak %s untuk disimpan: %d, maksimum %d", what, n, math.MaxUint16)
+	}
+	return binary.Write(w, binary.LittleEndian, uint16(n))
 }
 
 func writeString(w *bufio.Writer, s string) {
-	binary.Write(w, binary.LittleEndian, uint16(len(s)))
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))])
 	w.WriteString(s)
 }
 
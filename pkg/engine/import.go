@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// ImportResult adalah hasil ImportCSV, ImportNDJSON, dan ImportParquet.
+type ImportResult struct {
+	// Rows adalah jumlah baris yang masuk ke tangki.
+	Rows int
+	// Created berarti tangki dibuat oleh impor ini.
+	Created bool
+	// Errors berisi baris yang dilewati (lihat CSVOptions.MaxErrors).
+	Errors []*RowError
+}
+
+// RowError menjelaskan baris file impor yang tidak bisa dimasukkan. Line
+// adalah nomor baris di file, dimulai dari 1.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("baris %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// importSource adalah sumber IMPOR dari ImportCSV, ImportNDJSON, atau
+// ImportParquet. Untuk perintah FQL sumbernya nil dan file di q.Transfer
+// yang dibuka. Parquet dibaca lewat ra karena footernya di akhir file.
+type importSource struct {
+	format  string
+	r       io.Reader
+	ra      io.ReaderAt
+	size    int64
+	csv     CSVOptions
+	ndjson  NDJSONOptions
+	parquet ParquetOptions
+	result  *ImportResult
+}
+
+// importFrom menjalankan impor dari src seperti perintah Jalankan.
+func (e *Engine) importFrom(ctx context.Context, name string, src *importSource) (*ImportResult, error) {
+	if e.readOnly {
+		return nil, errReadOnly
+	}
+	q := &parser.Query{Type: "IMPORT", Tangki: name}
+
+	start := time.Now()
+	err := e.jalankan(ctx, q, src, "")
+	e.observe(q.Type, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return src.result, nil
+}
+
+// importData menjalankan IMPOR TANGKI. Baris masuk ke fork tangki yang
+// baru dipasang setelah semua baris terbaca, jadi impor yang gagal tidak
+// mengubah apa pun.
+// Asumsi: lock katalog eksklusif sudah diambil oleh caller
+func (e *Engine) importData(ctx context.Context, q *parser.Query, src *importSource) (*ImportResult, error) {
+	if src == nil {
+		file, err := os.Open(q.Transfer.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		src = &importSource{format: q.Transfer.Format, r: file, ra: file, size: info.Size(), csv: CSVOptions{Header: q.Transfer.Header}}
+	}
+
+	imp := &importer{e: e, ctx: ctx, result: &ImportResult{}}
+	var err error
+	switch src.format {
+	case "NDJSON":
+		imp.maxErrors = src.ndjson.MaxErrors
+		err = imp.ndjson(q.Tangki, src.r)
+	case "PARQUET":
+		imp.maxErrors = src.parquet.MaxErrors
+		err = imp.parquetFile(q.Tangki, src.ra, src.size)
+	default:
+		imp.maxErrors = src.csv.MaxErrors
+		err = imp.csv(q.Tangki, src.r, src.csv)
+	}
+	if err != nil {
+		e.stateMu.Lock()
+		e.memStale = true
+		e.stateMu.Unlock()
+		return nil, err
+	}
+	src.result = imp.result
+	return imp.result, nil
+}
+
+// importer adalah bagian impor yang sama untuk semua format.
+type importer struct {
+	e         *Engine
+	ctx       context.Context
+	maxErrors int
+	result    *ImportResult
+	read      int
+}
+
+// tick memeriksa ctx setiap 1024 baris yang dibaca.
+func (imp *importer) tick() error {
+	if imp.read++; imp.read%1024 == 0 {
+		return imp.ctx.Err()
+	}
+	return nil
+}
+
+// reject mencatat baris yang salah, atau mengembalikan error bila batas
+// MaxErrors sudah terlewati.
+func (imp *importer) reject(line int, err error) error {
+	rowErr := &RowError{Line: line, Err: err}
+	if imp.maxErrors >= 0 && len(imp.result.Errors) >= imp.maxErrors {
+		return rowErr
+	}
+	imp.result.Errors = append(imp.result.Errors, rowErr)
+	return nil
+}
+
+// add memasukkan satu baris yang sudah diurutkan sesuai kolom t. Nilai
+// dikonversi oleh AddRow seperti ISI.
+func (imp *importer) add(t *tangki.Tangki, values []interface{}, line int) error {
+	if err := imp.e.checkMemory(values); err != nil {
+		return err
+	}
+	if err := t.AddRow(values...); err != nil {
+		return imp.reject(line, err)
+	}
+	imp.result.Rows++
+	return nil
+}
+
+func (imp *importer) install(t *tangki.Tangki, created bool) error {
+	if err := imp.e.installTangki(t); err != nil {
+		return err
+	}
+	imp.result.Created = created
+	return nil
+}
+
+// newColumns membuat kolom tangki baru dari nama-nama di file. Tipenya
+// ditebak kemudian dengan widenType.
+func newColumns(names []string) ([]tangki.Column, error) {
+	columns := make([]tangki.Column, len(names))
+	for i, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("nama kolom ke-%d kosong", i+1)
+		}
+		for _, prev := range names[:i] {
+			if strings.EqualFold(prev, name) {
+				return nil, fmt.Errorf("kolom '%s' muncul dua kali", name)
+			}
+		}
+		columns[i] = tangki.Column{Name: name}
+	}
+	return columns, nil
+}
+
+// columnIndex mencocokkan nama kolom di file dengan kolom t. Hasilnya
+// posisi nilai di file untuk setiap kolom t, atau -1 bila kolom itu tidak
+// ada di file.
+func columnIndex(t *tangki.Tangki, names []string) ([]int, error) {
+	index := make([]int, len(t.Columns))
+	for i := range index {
+		index[i] = -1
+	}
+	for i, name := range names {
+		pos := t.GetColumnIndex(name)
+		if pos == -1 {
+			return nil, fmt.Errorf("kolom '%s' tidak ditemukan di tangki '%s'", name, t.Name)
+		}
+		if index[pos] != -1 {
+			return nil, fmt.Errorf("kolom '%s' muncul dua kali", name)
+		}
+		index[pos] = i
+	}
+	return index, nil
+}
+
+// widenType melebarkan tebakan tipe kolom agar nilai bertipe next juga
+// muat: INT -> FLOAT -> TEKS. Tipe kosong berarti belum ada nilai selain
+// NULL; kolom yang tetap kosong sampai akhir menjadi TEKS (lihat
+// finishTypes).
+func widenType(cur, next string) string {
+	switch {
+	case next == "" || cur == next:
+		return cur
+	case cur == "":
+		return next
+	case cur == "TEKS" || next == "TEKS":
+		return "TEKS"
+	}
+	return "FLOAT"
+}
+
+func finishTypes(columns []tangki.Column) {
+	for i := range columns {
+		if columns[i].Type == "" {
+			columns[i].Type = "TEKS"
+		}
+	}
+}
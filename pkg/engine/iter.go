@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Rows adalah hasil QueryIter yang dibaca baris demi baris. Rows membaca
+// snapshot saat QueryIter dipanggil, jadi Jalankan tetap bisa berjalan
+// selama Rows terbuka dan perubahannya tidak terlihat oleh Rows.
+type Rows struct {
+	it     query.Iterator
+	ctx    context.Context
+	err    error
+	closed bool
+	cancel context.CancelFunc
+	// observe melaporkan query ke Options.Metrics saat Rows selesai
+	observe func(error)
+}
+
+// QueryIter menjalankan query baca seperti Query, tetapi PILIH dieksekusi
+// secara streaming: baris dihitung saat Next dipanggil sehingga pemanggil
+// bisa berhenti lebih awal tanpa menampung seluruh hasil. Perintah lain
+// (JELASKAN, URUTKAN, GRUPKAN) tetap dihitung penuh lebih dulu.
+// Batas waktu bawaan Query berlaku sampai Rows ditutup, dan query baru
+// dilaporkan ke Options.Metrics saat baris habis atau Rows ditutup.
+func (e *Engine) QueryIter(ctx context.Context, fql string) (*Rows, error) {
+	p := parser.NewParser(fql)
+	q, err := p.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %v", err)
+	}
+
+	start := time.Now()
+	ctx, cancel := withTimeout(ctx, e.timeouts.Query)
+	it, err := e.snapshot().openNoLock(ctx, q)
+	if err != nil {
+		cancel()
+		e.observe(q.Type, start, err)
+		return nil, err
+	}
+	observe := func(err error) { e.observe(q.Type, start, err) }
+	return &Rows{it: it, ctx: ctx, cancel: cancel, observe: observe}, nil
+}
+
+func (e *Engine) openNoLock(ctx context.Context, q *parser.Query) (query.Iterator, error) {
+	if q.Type == "SELECT" && q.Select != nil {
+		ctes, err := e.evalWith(ctx, q.Select.With, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		op, err := e.planSelect(ctx, q.Select, ctes)
+		if err != nil {
+			return nil, err
+		}
+		return op.Open(&query.Env{Runner: subqueryRunner{e, ctes, ctx}, Ctx: ctx})
+	}
+
+	rows, err := e.queryNoLock(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return query.Iterate(&query.Relation{Schema: e.resultSchema(q), Rows: rows}), nil
+}
+
+// Next mengembalikan baris berikutnya, atau false bila baris habis,
+// terjadi error, atau ctx dibatalkan.
+func (r *Rows) Next() (tangki.Row, bool) {
+	if r.closed {
+		return nil, false
+	}
+	if err := r.ctx.Err(); err != nil {
+		r.err = err
+		r.Close()
+		return nil, false
+	}
+	row, ok := r.it.Next()
+	if !ok {
+		r.err = r.it.Err()
+		r.Close()
+	}
+	return row, ok
+}
+
+// Columns mengembalikan nama kolom hasil.
+func (r *Rows) Columns() []string {
+	schema := r.it.Schema()
+	names := make([]string, len(schema))
+	for i, f := range schema {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Err mengembalikan error yang menghentikan iterasi.
+func (r *Rows) Err() error { return r.err }
+
+// Close menghentikan iterasi dan batas waktunya. Aman dipanggil lebih dari
+// sekali.
+func (r *Rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.cancel()
+	r.observe(r.err)
+	return nil
+}
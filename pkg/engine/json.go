@@ -0,0 +1,428 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// NDJSONOptions mengatur ImportNDJSON.
+type NDJSONOptions struct {
+	// MaxErrors sama seperti CSVOptions.MaxErrors.
+	MaxErrors int
+}
+
+// ImportNDJSON menambahkan baris dari r ke tangki name. Setiap baris r
+// adalah satu objek JSON dengan kunci nama kolom; kolom yang tidak ada di
+// objek menjadi NULL dan baris kosong dilewati. Nilai dikonversi ke tipe
+// kolom seperti ISI. Bila tangki belum ada, kolomnya diambil dari urutan
+// kunci yang pertama muncul dan tipenya ditebak dari nilai JSON: INT untuk
+// bilangan bulat, FLOAT untuk angka lain, selain itu TEKS.
+//
+// Seperti ImportCSV, impor berjalan utuh atau tidak sama sekali.
+func (e *Engine) ImportNDJSON(ctx context.Context, name string, r io.Reader, opts NDJSONOptions) (*ImportResult, error) {
+	return e.importFrom(ctx, name, &importSource{format: "NDJSON", r: r, ndjson: opts})
+}
+
+// jsonRecord adalah satu objek dari file NDJSON, kunci sesuai urutan di
+// file.
+type jsonRecord struct {
+	keys   []string
+	values []interface{}
+	line   int
+}
+
+func (imp *importer) ndjson(name string, r io.Reader) error {
+	reader := bufio.NewReader(r)
+	line := 0
+	next := func() (*jsonRecord, error) {
+		for {
+			if err := imp.tick(); err != nil {
+				return nil, err
+			}
+			data, err := reader.ReadBytes('\n')
+			if err != nil && (err != io.EOF || len(data) == 0) {
+				return nil, err
+			}
+			line++
+			data = bytes.TrimSpace(data)
+			if len(data) == 0 {
+				continue
+			}
+			rec, perr := parseJSONObject(data)
+			if perr == nil {
+				rec.line = line
+				return rec, nil
+			}
+			if err := imp.reject(line, perr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, exists := imp.e.tangkis[name]; exists {
+		t, err := imp.e.forkTangki(name)
+		if err != nil {
+			return err
+		}
+		for {
+			rec, err := next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := imp.addJSON(t, rec); err != nil {
+				return err
+			}
+		}
+		return imp.install(t, false)
+	}
+
+	var names []string
+	types := map[string]string{}
+	var records []*jsonRecord
+	for {
+		rec, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for i, key := range rec.keys {
+			cur, seen := types[key]
+			if !seen {
+				names = append(names, key)
+			}
+			types[key] = widenType(cur, jsonType(rec.values[i]))
+		}
+		records = append(records, rec)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("tangki '%s' tidak ditemukan dan file tidak berisi kolom untuk membuatnya", name)
+	}
+	columns, err := newColumns(names)
+	if err != nil {
+		return err
+	}
+	for i := range columns {
+		columns[i].Type = types[columns[i].Name]
+	}
+	finishTypes(columns)
+
+	t := tangki.NewTangki(name, columns)
+	for _, rec := range records {
+		if err := imp.addJSON(t, rec); err != nil {
+			return err
+		}
+	}
+	return imp.install(t, true)
+}
+
+func (imp *importer) addJSON(t *tangki.Tangki, rec *jsonRecord) error {
+	index, err := columnIndex(t, rec.keys)
+	if err != nil {
+		return imp.reject(rec.line, err)
+	}
+	values := make([]interface{}, len(t.Columns))
+	for i, pos := range index {
+		if pos == -1 {
+			continue
+		}
+		values[i] = rec.values[pos]
+		if n, ok := values[i].(json.Number); ok {
+			values[i] = n.String()
+		}
+	}
+	return imp.add(t, values, rec.line)
+}
+
+// parseJSONObject membaca satu objek JSON datar. Angka dibiarkan sebagai
+// json.Number agar dikonversi AddRow sesuai tipe kolom tanpa kehilangan
+// presisi.
+func parseJSONObject(data []byte) (*jsonRecord, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("baris bukan objek JSON")
+	}
+
+	rec := &jsonRecord{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("JSON tidak valid: %v", err)
+		}
+		key := tok.(string)
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return nil, fmt.Errorf("JSON tidak valid: %v", err)
+		}
+		switch v := val.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("nilai kolom '%s' bertingkat, tidak didukung", key)
+		case bool:
+			val = strconv.FormatBool(v)
+		}
+		rec.keys = append(rec.keys, key)
+		rec.values = append(rec.values, val)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("JSON tidak valid: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("ada data setelah objek JSON")
+	}
+	return rec, nil
+}
+
+// jsonType menebak tipe nilai dari parseJSONObject. Teks tetap TEKS
+// walaupun isinya angka.
+func jsonType(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case json.Number:
+		return csvType(v.String())
+	}
+	return "TEKS"
+}
+
+// ExportNDJSON menulis isi tangki atau pandangan name ke w, satu objek
+// JSON per baris dengan kunci nama kolom, sama seperti EKSPOR TANGKI ke
+// file .ndjson.
+func (e *Engine) ExportNDJSON(ctx context.Context, name string, w io.Writer) error {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Query)
+	defer cancel()
+	return e.snapshot().exportNDJSON(ctx, name, w)
+}
+
+// Asumsi: e adalah snapshot
+func (e *Engine) exportNDJSON(ctx context.Context, name string, w io.Writer) error {
+	t, err := e.exportSource(name)
+	if err != nil {
+		return err
+	}
+	enc := newJSONRowEncoder(query.SchemaOf(t, ""), w)
+
+	n := 0
+	scanErr := t.Scan(func(row tangki.Row) bool {
+		if n++; n%1024 == 0 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+		err = enc.writeRow(row, "\n")
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return scanErr
+}
+
+// jsonRowEncoder menulis baris sebagai objek JSON dengan kunci nama kolom.
+type jsonRowEncoder struct {
+	keys [][]byte
+	w    io.Writer
+	buf  []byte
+	rows int
+}
+
+func newJSONRowEncoder(schema query.Schema, w io.Writer) *jsonRowEncoder {
+	names := resultColumns(schema)
+	keys := make([][]byte, len(names))
+	for i, name := range names {
+		keys[i] = appendJSONString(nil, name)
+	}
+	return &jsonRowEncoder{keys: keys, w: w}
+}
+
+func (enc *jsonRowEncoder) writeRow(row tangki.Row, suffix string) error {
+	enc.buf = appendJSONRow(enc.buf[:0], enc.keys, row)
+	enc.buf = append(enc.buf, suffix...)
+	enc.rows++
+	_, err := enc.w.Write(enc.buf)
+	return err
+}
+
+func appendJSONRow(b []byte, keys [][]byte, row tangki.Row) []byte {
+	b = append(b, '{')
+	for i, val := range row {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if i < len(keys) {
+			b = append(b, keys[i]...)
+		} else {
+			b = appendJSONString(b, fmt.Sprintf("kolom%d", i+1))
+		}
+		b = append(b, ':')
+		b = appendJSONValue(b, val)
+	}
+	return append(b, '}')
+}
+
+// appendJSONValue menulis satu nilai. FLOAT yang bukan bilangan hingga
+// (NaN, Inf) tidak punya bentuk JSON dan ditulis sebagai null.
+func appendJSONValue(b []byte, val interface{}) []byte {
+	switch v := val.(type) {
+	case nil:
+		return append(b, "null"...)
+	case string:
+		return appendJSONString(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return append(b, "null"...)
+		}
+		return strconv.AppendFloat(b, v, 'g', -1, 64)
+	}
+	return appendJSONString(b, fmt.Sprint(val))
+}
+
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b = append(b, '\\', c)
+			case c == '\n':
+				b = append(b, '\\', 'n')
+			case c == '\r':
+				b = append(b, '\\', 'r')
+			case c == '\t':
+				b = append(b, '\\', 't')
+			case c < 0x20:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				b = append(b, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, "\ufffd"...)
+		} else {
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(b, '"')
+}
+
+// resultColumns memberi nama unik untuk setiap kolom hasil. Nama yang
+// muncul lebih dari sekali (misalnya id dari dua tangki yang digabung)
+// ditulis lengkap sebagai tangki.kolom.
+func resultColumns(schema query.Schema) []string {
+	count := map[string]int{}
+	for _, f := range schema {
+		count[f.Name]++
+	}
+	names := make([]string, len(schema))
+	for i, f := range schema {
+		names[i] = f.Name
+		if count[f.Name] > 1 && f.Table != "" {
+			names[i] = f.Table + "." + f.Name
+		}
+	}
+	return names
+}
+
+// Result adalah hasil query lengkap beserta nama kolomnya. Result memenuhi
+// json.Marshaler: hasilnya array objek dengan kunci nama kolom.
+type Result struct {
+	Columns []string
+	Rows    []tangki.Row
+}
+
+// QueryResult menjalankan query seperti QueryContext dan mengembalikan
+// barisnya bersama nama kolom. Nama kolom yang kembar ditulis lengkap
+// sebagai tangki.kolom.
+func (e *Engine) QueryResult(ctx context.Context, fql string) (*Result, error) {
+	rows, err := e.QueryIter(ctx, fql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := &Result{Columns: resultColumns(rows.it.Schema())}
+	for row, ok := rows.Next(); ok; row, ok = rows.Next() {
+		res.Rows = append(res.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// MarshalJSON menulis r sebagai array objek JSON.
+func (r Result) MarshalJSON() ([]byte, error) {
+	keys := make([][]byte, len(r.Columns))
+	for i, name := range r.Columns {
+		keys[i] = appendJSONString(nil, name)
+	}
+	b := []byte{'['}
+	for i, row := range r.Rows {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONRow(b, keys, row)
+	}
+	return append(b, ']'), nil
+}
+
+// WriteJSON menulis sisa baris r ke w sebagai satu array objek JSON
+// dengan kunci nama kolom (lihat QueryResult), lalu menutup r. Baris
+// ditulis satu per satu tanpa menampung seluruh hasil.
+func (r *Rows) WriteJSON(w io.Writer) error {
+	return r.writeJSON(w, "[", ",", "", "]")
+}
+
+// WriteNDJSON seperti WriteJSON, tetapi setiap baris ditulis sebagai satu
+// objek JSON per baris teks.
+func (r *Rows) WriteNDJSON(w io.Writer) error {
+	return r.writeJSON(w, "", "", "\n", "")
+}
+
+func (r *Rows) writeJSON(w io.Writer, open, sep, term, close string) error {
+	defer r.Close()
+	if _, err := io.WriteString(w, open); err != nil {
+		return err
+	}
+
+	enc := newJSONRowEncoder(r.it.Schema(), w)
+	for row, ok := r.Next(); ok; row, ok = r.Next() {
+		if enc.rows > 0 {
+			if _, err := io.WriteString(w, sep); err != nil {
+				return err
+			}
+		}
+		if err := enc.writeRow(row, term); err != nil {
+			return err
+		}
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, close)
+	return err
+}
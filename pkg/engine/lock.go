@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrLocked dikembalikan OpenTangki bila file sedang dibuka engine lain
+// (di proses ini atau proses lain) dengan mode yang bentrok.
+var ErrLocked = errors.New("database locked")
+
+// fileLock adalah advisory lock pada file "<path>.lock". Lock tidak diambil
+// pada file .bensin itu sendiri karena penyimpanan mengganti file tersebut
+// lewat rename. File lock tidak dihapus saat dilepas: menghapusnya bisa
+// membuat dua proses memegang lock pada inode yang berbeda.
+type fileLock struct {
+	f *os.File
+}
+
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// acquireLock mengambil lock eksklusif untuk penulis, atau lock bersama bila
+// shared (mode hanya-baca). Tidak menunggu: bila lock dipegang engine lain
+// hasilnya ErrLocked.
+func acquireLock(path string, shared bool) (*fileLock, error) {
+	f, err := os.OpenFile(lockPath(path), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil && shared {
+		// Direktori hanya-baca: lock bersama cukup dengan file yang sudah ada
+		f, err = os.Open(lockPath(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka file lock '%s': %v", lockPath(path), err)
+	}
+
+	if err := lockFile(f, shared); err != nil {
+		f.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("%w: '%s' sedang dibuka oleh engine lain", ErrLocked, path)
+		}
+		return nil, fmt.Errorf("gagal mengunci '%s': %v", path, err)
+	}
+	return &fileLock{f: f}, nil
+}
+
+func (l *fileLock) release() error {
+	if l == nil {
+		return nil
+	}
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package engine
+
+import "os"
+
+// Di luar unix belum ada flock: file lock tetap dibuat, tetapi tidak
+// mencegah engine lain membuka database yang sama.
+func lockFile(f *os.File, shared bool) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
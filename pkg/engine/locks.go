@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+)
+
+// Urutan lock engine, dari luar ke dalam:
+//
+//	e.mu         lock katalog. Eksklusif untuk DDL (BUAT, pandangan,
+//	             DropTangki) dan untuk perubahan pada sumber pandangan
+//	             terwujud; bersama untuk perintah lain.
+//	tangkiLocks  satu mutex per tangki, diambil urut nama oleh ISI, ATUR,
+//	             BAKAR, ANALISIS, GABUNG, dan SATUKAN.
+//	stateMu, fileMu, statusMu
+//	             lock daun yang hanya ditahan sebentar.
+//
+// Karena semua perintah mengambil mutex tangki dengan urutan yang sama,
+// perintah multi-tangki tidak bisa saling menunggu (deadlock).
+
+// lockFor mengambil lock yang dibutuhkan q dan mengembalikan fungsi untuk
+// melepasnya, beserta tangki yang dikunci untuk q (nil bila q memegang
+// lock katalog eksklusif). target adalah tangki yang diubah q (lihat
+// writeTarget).
+func (e *Engine) lockFor(ctx context.Context, q *parser.Query, target string) (func(), []string, error) {
+	if names := lockedTangkis(q); names != nil {
+		if err := lockContext(ctx, e.mu.TryRLock, e.mu.RLock); err != nil {
+			return nil, nil, err
+		}
+		// Pandangan terwujud ikut diperbarui, jadi butuh lock eksklusif
+		if !e.feedsMaterialized(target) {
+			unlock, err := e.lockTangkis(ctx, names)
+			if err != nil {
+				e.mu.RUnlock()
+				return nil, nil, err
+			}
+			return func() {
+				unlock()
+				e.mu.RUnlock()
+			}, names, nil
+		}
+		e.mu.RUnlock()
+	}
+
+	if err := lockContext(ctx, e.mu.TryLock, e.mu.Lock); err != nil {
+		return nil, nil, err
+	}
+	return e.mu.Unlock, nil, nil
+}
+
+// lockedTangkis mengembalikan tangki yang dibaca atau ditulis q bila q
+// cukup memakai lock per tangki, atau nil bila q butuh lock katalog
+// eksklusif.
+func lockedTangkis(q *parser.Query) []string {
+	switch q.Type {
+	case "INSERT", "UPDATE", "DELETE", "ANALYZE":
+		return []string{q.Tangki}
+	case "JOIN":
+		return []string{q.JoinInfo.Tangki1, q.JoinInfo.Tangki2, q.JoinInfo.NewTangki}
+	case "UNION":
+		return append(append([]string{}, q.UnionInfo.Tangkis...), q.UnionInfo.NewTangki)
+	}
+	return nil
+}
+
+// feedsMaterialized berarti perubahan pada tangki name harus memperbarui
+// pandangan terwujud.
+// Asumsi: lock katalog sudah diambil oleh caller
+func (e *Engine) feedsMaterialized(name string) bool {
+	for _, v := range e.views {
+		if v.materialized && containsName(v.sources, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockTangkis mengunci mutex tangki names urut nama, sehingga dua perintah
+// yang menyentuh tangki yang sama selalu mengunci dengan urutan yang sama.
+func (e *Engine) lockTangkis(ctx context.Context, names []string) (func(), error) {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+
+	var held []*sync.Mutex
+	unlock := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Unlock()
+		}
+	}
+	for i, name := range sorted {
+		if i > 0 && name == sorted[i-1] {
+			continue
+		}
+		mu := e.tangkiLock(name)
+		if err := lockContext(ctx, mu.TryLock, mu.Lock); err != nil {
+			unlock()
+			return nil, err
+		}
+		held = append(held, mu)
+	}
+	return unlock, nil
+}
+
+func (e *Engine) tangkiLock(name string) *sync.Mutex {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if e.tangkiLocks == nil {
+		e.tangkiLocks = make(map[string]*sync.Mutex)
+	}
+	mu, ok := e.tangkiLocks[name]
+	if !ok {
+		mu = &sync.Mutex{}
+		e.tangkiLocks[name] = mu
+	}
+	return mu
+}
@@ -0,0 +1,252 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/pager"
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// MemoryPath membuka engine tanpa file: data hilang saat Close.
+const MemoryPath = ":memory:"
+
+// Durability menentukan kapan perubahan ditulis ke file.
+type Durability int
+
+const (
+	// DurabilitySnapshot menulis snapshot saat Close (bawaan).
+	DurabilitySnapshot Durability = iota
+	// DurabilityNone tidak pernah menulis otomatis; hanya Save eksplisit.
+	DurabilityNone
+	// DurabilityFsync mencatat setiap perubahan yang berhasil ke WAL
+	// (path+".wal") dan fsync sebelum Jalankan kembali. Snapshot tetap
+	// ditulis saat checkpoint dan Close; WAL dijalankan ulang saat file
+	// dibuka setelah crash. IMPOR menulis checkpoint penuh karena sumbernya
+	// tidak ikut tercatat.
+	DurabilityFsync
+)
+
+// Logger menerima pesan operasional engine (buka, simpan, autosave gagal).
+// *log.Logger memenuhi interface ini.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// Metrics dipanggil setelah setiap perintah dengan jenis query (misalnya
+// "SELECT", "INSERT"), durasi, dan error-nya.
+type Metrics interface {
+	ObserveStatement(kind string, duration time.Duration, err error)
+}
+
+// Options mengatur OpenTangkiWithOptions. Nilai nol setara OpenTangki:
+// file dibuat bila belum ada dan ditulis saat Close.
+type Options struct {
+	// ReadOnly menolak semua perubahan dan tidak pernah menulis file.
+	// Beberapa engine hanya-baca boleh membuka file yang sama bersamaan,
+	// sedangkan engine penulis memegang lock eksklusif (lihat ErrLocked).
+	ReadOnly bool
+	// MustExist gagal bila file belum ada, alih-alih membuat database baru.
+	MustExist  bool
+	Durability Durability
+	// AutosaveInterval > 0 menjalankan checkpoint di latar belakang setiap
+	// interval, dan AutosaveWrites > 0 setiap sekian perubahan.
+	AutosaveInterval time.Duration
+	AutosaveWrites   int
+	// MemoryLimit > 0 adalah perkiraan batas byte seluruh baris; ISI yang
+	// melewatinya ditolak.
+	MemoryLimit int64
+	// ColumnCache menyimpan cache per kolom di samping baris setiap tangki
+	// (lihat tangki.SetColumnCache) agar GRUPKAN, URUTKAN, dan filter
+	// DIMANA berjalan tervektorisasi. Cache ini salinan, jadi memakai
+	// memori kira-kira sebesar isi tangki lagi. ISI menambahkan barisnya ke
+	// cache yang ada; ATUR dan BAKAR membangunnya ulang saat pertama dibaca.
+	ColumnCache bool
+	// Compress mengompres blok kolom di file dengan DEFLATE bila hasilnya
+	// lebih kecil. File tetap terbaca oleh engine tanpa opsi ini.
+	Compress bool
+	// PageCache > 0 menyimpan baris tangki di halaman berukuran
+	// pager.PageSize dalam file path+".pages" dan hanya menahan sekian
+	// halaman di memori (buffer pool dengan eviksi clock). PILIH, QueryIter,
+	// ISI, ATUR, BAKAR, GRUPKAN, dan ANALISIS TANGKI membaca tangki per
+	// halaman. URUTKAN, GABUNG, dan SATUKAN masih memuat tangki yang
+	// dipakainya ke memori, begitu juga GetTangki. ATUR dan BAKAR menulis
+	// tangki ke halaman baru; halaman lama dipakai ulang setelah checkpoint
+	// berikutnya dan setelah tidak ada lagi snapshot atau QueryIter yang
+	// memegang versi lama itu. Diabaikan untuk database di memori.
+	PageCache int
+	// LazyLoad hanya membaca skema saat membuka file; isi setiap tangki
+	// dimuat saat tangki itu pertama dipakai. Tangki yang menjadi sumber
+	// pandangan tetap dimuat saat dibuka karena definisi pandangan
+	// dijalankan sekali untuk divalidasi.
+	LazyLoad bool
+	// WALArchive, bila tidak kosong, adalah file tempat catatan WAL yang
+	// dibuang checkpoint dipindahkan. Arsip ini bersama backup dipakai
+	// RestoreTo untuk memulihkan database ke titik waktu tertentu.
+	WALArchive string
+	Timeouts   Timeouts
+	Logger     Logger
+	Metrics    Metrics
+}
+
+// OpenTangkiWithOptions membuka database di path dengan opts. Path kosong
+// atau MemoryPath membuka database di memori saja.
+func OpenTangkiWithOptions(path string, opts Options) (*Engine, error) {
+	if path == MemoryPath {
+		path = ""
+	}
+
+	eng := &Engine{
+		tangkis: make(map[string]*tangki.Tangki),
+		views:   make(map[string]*view),
+		file:    path,
+		dirty:   false,
+
+		stats:            make(map[string]*query.TableStats),
+		changes:          make(map[string]int),
+		analyzeThreshold: defaultAnalyzeThreshold,
+
+		timeouts:    opts.Timeouts,
+		readOnly:    opts.ReadOnly,
+		durability:  opts.Durability,
+		memoryLimit: opts.MemoryLimit,
+		columnCache: opts.ColumnCache,
+		compress:    opts.Compress,
+		walArchive:  opts.WALArchive,
+		logger:      opts.Logger,
+		metrics:     opts.Metrics,
+	}
+
+	if path != "" {
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) && (opts.MustExist || opts.ReadOnly) {
+			return nil, fmt.Errorf("file '%s' tidak ditemukan", path)
+		}
+		if eng.lock, err = acquireLock(path, opts.ReadOnly); err != nil {
+			return nil, err
+		}
+		if opts.PageCache > 0 {
+			if err := eng.openPages(pagesPath(path), opts.PageCache); err != nil {
+				eng.lock.release()
+				return nil, err
+			}
+		}
+		fail := func(err error) (*Engine, error) {
+			if eng.lazyFile != nil {
+				eng.lazyFile.Close()
+			}
+			eng.closePages()
+			eng.lock.release()
+			return nil, err
+		}
+		if _, err := os.Stat(path); err == nil {
+			if err := load(eng, path, opts.LazyLoad); err != nil {
+				return fail(err)
+			}
+		}
+		if eng.pool != nil {
+			// Halaman yang tidak dirujuk file adalah sisa versi lama
+			eng.savedPages = eng.leasePages()
+			eng.pool.Pager().Sweep()
+		}
+		if err := eng.recoverWAL(path); err != nil {
+			return fail(err)
+		}
+		eng.logf("membuka %s (%d tangki)", path, len(eng.tangkis))
+	}
+	eng.dirty = false
+	eng.memStale = true
+	eng.publish()
+
+	if (opts.AutosaveInterval > 0 || opts.AutosaveWrites > 0) && eng.persistent() {
+		eng.startCheckpointer(opts.AutosaveInterval, opts.AutosaveWrites)
+	}
+	return eng, nil
+}
+
+func (e *Engine) logf(format string, args ...interface{}) {
+	if e.logger != nil {
+		e.logger.Printf(format, args...)
+	}
+}
+
+func (e *Engine) observe(kind string, start time.Time, err error) {
+	if e.metrics != nil {
+		e.metrics.ObserveStatement(kind, time.Since(start), err)
+	}
+}
+
+// defaultPageCache adalah ukuran buffer pool untuk file berhalaman yang
+// dibuka tanpa Options.PageCache.
+const defaultPageCache = 256
+
+// openPages membuka file halaman dan buffer pool-nya (mode halaman).
+func (e *Engine) openPages(path string, pages int) error {
+	p, err := pager.Open(path)
+	if err != nil {
+		return err
+	}
+	e.pool = pager.NewBufferPool(p, pages)
+	return nil
+}
+
+// closePages menulis halaman kotor lalu menutup file halaman.
+func (e *Engine) closePages() error {
+	if e.pool == nil {
+		return nil
+	}
+	var err error
+	if !e.readOnly {
+		err = e.pool.Flush()
+	}
+	if cerr := e.pool.Pager().Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// persistent berarti perubahan boleh ditulis ke file secara otomatis.
+func (e *Engine) persistent() bool {
+	return e.file != "" && !e.readOnly && e.durability != DurabilityNone
+}
+
+// checkMemory memastikan baris baru untuk tangki masih muat dalam
+// MemoryLimit. Ukuran dihitung ulang penuh setelah perubahan selain ISI.
+func (e *Engine) checkMemory(values []interface{}) error {
+	if e.memoryLimit <= 0 {
+		return nil
+	}
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if e.memStale {
+		e.memUsed = 0
+		for _, t := range e.tangkis {
+			for _, row := range t.Rows {
+				e.memUsed += rowSize(row)
+			}
+		}
+		e.memStale = false
+	}
+
+	size := rowSize(values)
+	if e.memUsed+size > e.memoryLimit {
+		return fmt.Errorf("batas memori %d byte terlampaui", e.memoryLimit)
+	}
+	e.memUsed += size
+	return nil
+}
+
+// rowSize memperkirakan memori satu baris: header slice ditambah 16 byte
+// per interface dan isi teks.
+func rowSize(row []interface{}) int64 {
+	size := int64(24 + 16*len(row))
+	for _, v := range row {
+		if s, ok := v.(string); ok {
+			size += int64(len(s))
+		}
+	}
+	return size
+}
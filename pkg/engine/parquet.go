@@ -0,0 +1,202 @@
+package engine
+
+import (
+	"context"
+	"io"
+
+	"github.com/Dziqha/BensinDB/pkg/parquet"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// ParquetOptions mengatur ImportParquet dan ExportParquet.
+type ParquetOptions struct {
+	// Compression adalah codec halaman saat ekspor: parquet.Uncompressed
+	// (bawaan), parquet.Snappy, atau parquet.Gzip. Saat impor codec dibaca
+	// dari file.
+	Compression parquet.Compression
+	// RowGroupSize adalah jumlah baris per row group saat ekspor; nol
+	// berarti parquet.DefaultRowGroupSize.
+	RowGroupSize int
+	// MaxErrors sama seperti CSVOptions.MaxErrors. RowError.Line adalah
+	// nomor baris data, dimulai dari 1.
+	MaxErrors int
+}
+
+// ImportParquet menambahkan baris dari file Parquet sebesar size byte di
+// r ke tangki name, sama seperti IMPOR TANGKI dari file .parquet. Kolom
+// dicocokkan lewat nama; bila tangki belum ada, tangki baru dibuat dengan
+// tipe dari skema file (lihat paket parquet).
+func (e *Engine) ImportParquet(ctx context.Context, name string, r io.ReaderAt, size int64, opts ParquetOptions) (*ImportResult, error) {
+	return e.importFrom(ctx, name, &importSource{format: "PARQUET", ra: r, size: size, parquet: opts})
+}
+
+func (imp *importer) parquetFile(name string, r io.ReaderAt, size int64) error {
+	file, err := parquet.Open(r, size)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(file.Columns()))
+	for i, col := range file.Columns() {
+		names[i] = col.Name
+	}
+
+	var t *tangki.Tangki
+	var index []int
+	_, exists := imp.e.tangkis[name]
+	if exists {
+		if t, err = imp.e.forkTangki(name); err != nil {
+			return err
+		}
+		if index, err = columnIndex(t, names); err != nil {
+			return err
+		}
+	} else {
+		columns, err := newColumns(names)
+		if err != nil {
+			return err
+		}
+		for i, col := range file.Columns() {
+			columns[i].Type = col.Type
+		}
+		t = tangki.NewTangki(name, columns)
+		index = make([]int, len(columns))
+		for i := range index {
+			index[i] = i
+		}
+	}
+
+	line := 0
+	scanErr := file.Scan(func(row tangki.Row) bool {
+		line++
+		if err = imp.tick(); err != nil {
+			return false
+		}
+		values := make([]interface{}, len(t.Columns))
+		for i := range values {
+			if index[i] != -1 {
+				values[i] = row[index[i]]
+			}
+		}
+		err = imp.add(t, values, line)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if scanErr != nil {
+		return scanErr
+	}
+	return imp.install(t, !exists)
+}
+
+// typedWriter adalah writer format bertipe seperti Parquet dan Arrow, yang
+// butuh tipe setiap kolom sebelum baris pertama ditulis.
+type typedWriter interface {
+	Write(row tangki.Row) error
+	Close() error
+}
+
+type openTyped func(w io.Writer, columns []tangki.Column) (typedWriter, error)
+
+func parquetWriter(opts ParquetOptions) openTyped {
+	return func(w io.Writer, columns []tangki.Column) (typedWriter, error) {
+		return parquet.NewWriter(w, columns, parquet.WriterOptions{
+			Compression:  opts.Compression,
+			RowGroupSize: opts.RowGroupSize,
+		})
+	}
+}
+
+// ExportParquet menulis isi tangki atau pandangan name ke w sebagai file
+// Parquet dari snapshot terakhir, sama seperti EKSPOR TANGKI ke file
+// .parquet.
+func (e *Engine) ExportParquet(ctx context.Context, name string, w io.Writer, opts ParquetOptions) error {
+	ctx, cancel := withTimeout(ctx, e.timeouts.Query)
+	defer cancel()
+	return e.snapshot().exportTyped(ctx, name, w, parquetWriter(opts))
+}
+
+// Asumsi: e adalah snapshot
+func (e *Engine) exportTyped(ctx context.Context, name string, w io.Writer, open openTyped) error {
+	t, err := e.exportSource(name)
+	if err != nil {
+		return err
+	}
+	tw, err := open(w, t.Columns)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	scanErr := t.Scan(func(row tangki.Row) bool {
+		if n++; n%1024 == 0 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+		err = tw.Write(row)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if scanErr != nil {
+		return scanErr
+	}
+	return tw.Close()
+}
+
+// WriteParquet menulis semua baris yang tersisa ke w sebagai file Parquet
+// dengan nama kolom seperti Result, lalu menutup r.
+func (r *Rows) WriteParquet(w io.Writer, opts ParquetOptions) error {
+	return r.writeTyped(w, parquetWriter(opts))
+}
+
+// writeTyped menulis baris r dengan writer bertipe. Kolom hasil yang
+// tipenya tidak diketahui (misalnya hasil GRUPKAN) ditebak dari baris
+// pertama.
+func (r *Rows) writeTyped(w io.Writer, open openTyped) error {
+	defer r.Close()
+	schema := r.it.Schema()
+	first, ok := r.Next()
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	columns := make([]tangki.Column, len(schema))
+	for i, name := range resultColumns(schema) {
+		columns[i] = tangki.Column{Name: name, Type: schema[i].Type}
+		if columns[i].Type == "" && ok && i < len(first) {
+			columns[i].Type = valueType(first[i])
+		}
+	}
+	finishTypes(columns)
+
+	tw, err := open(w, columns)
+	if err != nil {
+		return err
+	}
+	for row := first; ok; row, ok = r.Next() {
+		if err := tw.Write(row); err != nil {
+			return err
+		}
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// valueType mengembalikan tipe kolom untuk nilai hasil query; NULL tidak
+// punya tipe.
+func valueType(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return ""
+	case int, int64:
+		return "INT"
+	case float64:
+		return "FLOAT"
+	}
+	return "TEKS"
+}
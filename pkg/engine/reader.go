@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrCorrupt dikembalikan Load (lewat *CorruptError) bila file .bensin
+// terpotong atau isinya tidak masuk akal.
+var ErrCorrupt = errors.New("database corrupt")
+
+// CorruptError menjelaskan di byte ke berapa file .bensin rusak.
+// errors.Is(err, ErrCorrupt) bernilai true untuk error ini.
+type CorruptError struct {
+	Offset int64
+	Reason string
+}
+
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("file database rusak pada byte %d: %s", e.Offset, e.Reason)
+}
+
+func (e *CorruptError) Is(target error) bool { return target == ErrCorrupt }
+
+// snapshotReader membaca file snapshot secara berurutan lewat bufio.Reader.
+// Error pertama disimpan dan semua pembacaan berikutnya mengembalikan nilai
+// nol, jadi pemanggil cukup memeriksa err setelah satu bagian selesai.
+type snapshotReader struct {
+	r    *bufio.Reader
+	off  int64
+	end  int64 // offset akhir data, untuk menolak panjang yang mustahil
+	err  error
+	word [8]byte
+	// minor adalah versi minor file, yang menentukan format panjang string
+	minor uint16
+}
+
+func newSnapshotReader(r io.Reader, off, end int64) *snapshotReader {
+	return &snapshotReader{r: bufio.NewReaderSize(r, 64*1024), off: off, end: end}
+}
+
+// corrupt mencatat kerusakan pada offset saat ini.
+func (r *snapshotReader) corrupt(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = &CorruptError{Offset: r.off, Reason: fmt.Sprintf(format, args...)}
+	}
+}
+
+// fill membaca tepat len(buf) byte.
+func (r *snapshotReader) fill(buf []byte, what string) bool {
+	if r.err != nil {
+		return false
+	}
+	if int64(len(buf)) > r.end-r.off {
+		r.corrupt("%s butuh %d byte, tersisa %d", what, len(buf), r.end-r.off)
+		return false
+	}
+	n, err := io.ReadFull(r.r, buf)
+	if err != nil {
+		r.off += int64(n)
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			r.corrupt("file terpotong saat membaca %s", what)
+		} else {
+			r.err = err
+		}
+		return false
+	}
+	r.off += int64(n)
+	return true
+}
+
+func (r *snapshotReader) uint8(what string) byte {
+	if !r.fill(r.word[:1], what) {
+		return 0
+	}
+	return r.word[0]
+}
+
+func (r *snapshotReader) uint16(what string) uint16 {
+	if !r.fill(r.word[:2], what) {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(r.word[:2])
+}
+
+func (r *snapshotReader) uint32(what string) uint32 {
+	if !r.fill(r.word[:4], what) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(r.word[:4])
+}
+
+func (r *snapshotReader) uint64(what string) uint64 {
+	if !r.fill(r.word[:8], what) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(r.word[:8])
+}
+
+// bytes membaca n byte ke slice baru.
+func (r *snapshotReader) bytes(n int64, what string) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > r.end-r.off {
+		r.corrupt("%s butuh %d byte, tersisa %d", what, n, r.end-r.off)
+		return nil
+	}
+	buf := make([]byte, n)
+	if !r.fill(buf, what) {
+		return nil
+	}
+	return buf
+}
+
+// count memeriksa jumlah n yang dibaca dari file sebelum dipakai untuk
+// alokasi. Setiap unsur butuh paling sedikit size byte, jadi n yang tidak
+// muat di sisa file pasti rusak.
+func (r *snapshotReader) count(n uint64, size int64, what string) int {
+	if r.err != nil {
+		return 0
+	}
+	if n > uint64((r.end-r.off)/size) {
+		r.corrupt("%d %s melewati akhir file", n, what)
+		return 0
+	}
+	return int(n)
+}
+
+// skip melewati n byte tanpa menyimpannya.
+func (r *snapshotReader) skip(n int64, what string) {
+	if r.err != nil {
+		return
+	}
+	if n > r.end-r.off {
+		r.corrupt("%s butuh %d byte, tersisa %d", what, n, r.end-r.off)
+		return
+	}
+	discarded, err := r.r.Discard(int(n))
+	r.off += int64(discarded)
+	if err != nil {
+		r.corrupt("file terpotong saat membaca %s", what)
+	}
+}
+
+// uvarint membaca bilangan berformat binary.PutUvarint.
+func (r *snapshotReader) uvarint(what string) uint64 {
+	var v uint64
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		b := r.uint8(what)
+		if r.err != nil {
+			return 0
+		}
+		if i == binary.MaxVarintLen64-1 && b > 1 {
+			break
+		}
+		v |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return v
+		}
+	}
+	r.corrupt("panjang %s terlalu besar", what)
+	return 0
+}
+
+// string membaca string dengan panjang uint16 (format minor 5 ke bawah)
+// atau uvarint.
+func (r *snapshotReader) string(what string) string {
+	var n uint64
+	if r.minor >= 6 {
+		n = r.uvarint(what)
+	} else {
+		n = uint64(r.uint16(what))
+	}
+	if r.err == nil && n > uint64(r.end-r.off) {
+		r.corrupt("%s butuh %d byte, tersisa %d", what, n, r.end-r.off)
+	}
+	return string(r.bytes(int64(n), what))
+}
+
+func (r *snapshotReader) statValue() interface{} {
+	switch tag := r.uint8("statistik"); tag {
+	case statNull:
+		return nil
+	case statInt:
+		return int64(r.uint64("statistik"))
+	case statFloat:
+		return math.Float64frombits(r.uint64("statistik"))
+	case statTeks:
+		return r.string("statistik")
+	default:
+		r.corrupt("tag statistik %d tidak dikenal", tag)
+		return nil
+	}
+}
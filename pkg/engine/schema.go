@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Schema adalah struktur database untuk alat luar seperti BensinDB
+// Editor, siap di-encode dengan encoding/json. Untuk membaca struktur
+// file tanpa memuat barisnya, buka database dengan Options{ReadOnly: true,
+// LazyLoad: true}.
+type Schema struct {
+	// Format adalah versi format file .bensin, misalnya "1.5".
+	Format  string         `json:"format"`
+	Tangkis []TangkiSchema `json:"tangkis"`
+	Views   []ViewSchema   `json:"views"`
+}
+
+// TangkiSchema menjelaskan satu tangki.
+type TangkiSchema struct {
+	Name    string         `json:"name"`
+	Columns []ColumnSchema `json:"columns"`
+	Rows    int            `json:"rows"`
+	// Paged berarti baris tangki disimpan di file halaman.
+	Paged bool `json:"paged,omitempty"`
+}
+
+// ColumnSchema menjelaskan satu kolom; Type adalah INT, FLOAT, atau TEKS.
+type ColumnSchema struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ViewSchema menjelaskan satu pandangan. Rows hanya diisi untuk pandangan
+// terwujud.
+type ViewSchema struct {
+	Name         string         `json:"name"`
+	Materialized bool           `json:"materialized"`
+	Definition   string         `json:"definition"`
+	Sources      []string       `json:"sources"`
+	Columns      []ColumnSchema `json:"columns"`
+	Rows         int            `json:"rows,omitempty"`
+}
+
+// Schema mengembalikan struktur snapshot database terakhir. Tangki lazy
+// tidak dimuat.
+func (e *Engine) Schema() *Schema {
+	snap := e.snapshot()
+	schema := &Schema{
+		Format:  fmt.Sprintf("%d.%d", formatMajor, formatMinor),
+		Tangkis: []TangkiSchema{},
+		Views:   []ViewSchema{},
+	}
+
+	names := snap.listTangkiNoLock()
+	sort.Strings(names)
+	for _, name := range names {
+		if _, isView := snap.views[name]; isView {
+			continue
+		}
+		t := snap.tangkis[name]
+		schema.Tangkis = append(schema.Tangkis, TangkiSchema{
+			Name:    name,
+			Columns: columnSchemas(t.Columns),
+			Rows:    t.Len(),
+			Paged:   t.Paged(),
+		})
+	}
+
+	for _, v := range snap.viewsBySeq() {
+		vs := ViewSchema{
+			Name:         v.name,
+			Materialized: v.materialized,
+			Definition:   v.definition,
+			Sources:      append([]string{}, v.sources...),
+			Columns:      columnSchemas(unqualified(v.schema).Columns()),
+		}
+		if t, ok := snap.tangkis[v.name]; ok && v.materialized {
+			vs.Rows = t.Len()
+		}
+		schema.Views = append(schema.Views, vs)
+	}
+	return schema
+}
+
+func columnSchemas(columns []tangki.Column) []ColumnSchema {
+	out := make([]ColumnSchema, len(columns))
+	for i, col := range columns {
+		out[i] = ColumnSchema{Name: col.Name, Type: col.Type}
+	}
+	return out
+}
+
+// resultSchema memberi nama kolom hasil untuk perintah baca selain PILIH,
+// agar Rows.Columns dan keluaran JSON tetap punya kunci.
+// Asumsi: e adalah snapshot
+func (e *Engine) resultSchema(q *parser.Query) query.Schema {
+	switch q.Type {
+	case "EXPLAIN":
+		return query.Schema{{Name: "rencana", Type: "TEKS"}}
+	case "ORDER":
+		if v, ok := e.views[q.Tangki]; ok {
+			return unqualified(v.schema)
+		}
+		if t, ok := e.tangkis[q.Tangki]; ok {
+			return query.SchemaOf(t, "")
+		}
+	case "GROUP":
+		info := q.GroupInfo
+		agg := "COUNT(*)"
+		if info.AggregateFunc != "" {
+			agg = fmt.Sprintf("%s(%s)", info.AggregateFunc, info.AggregateCol)
+		}
+		return query.Schema{{Name: info.Column}, {Name: agg}}
+	}
+	return nil
+}
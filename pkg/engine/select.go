@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// execSelect menjalankan PILIH tanpa membuat tangki baru di e.tangkis.
+// outer berisi baris query luar bila stmt adalah subquery berkorelasi dan
+// ctes berisi hasil DENGAN dari query yang melingkupinya.
+// Asumsi: read lock sudah diambil oleh caller
+func (e *Engine) execSelect(ctx context.Context, stmt *parser.SelectStmt, outer *query.Scope, ctes *cteScope) (*query.Relation, error) {
+	ctes, err := e.evalWith(ctx, stmt.With, outer, ctes)
+	if err != nil {
+		return nil, err
+	}
+
+	op, err := e.planSelect(ctx, stmt, ctes)
+	if err != nil {
+		return nil, err
+	}
+	return query.Execute(op, &query.Env{Outer: outer, Runner: subqueryRunner{e, ctes, ctx}, Ctx: ctx})
+}
+
+// execSimpleSelect menjalankan satu blok PILIH tanpa DENGAN dan operasi
+// himpunan; dipakai oleh CTE rekursif untuk setiap putaran.
+func (e *Engine) execSimpleSelect(ctx context.Context, stmt *parser.SelectStmt, outer *query.Scope, ctes *cteScope) (*query.Relation, error) {
+	plan, err := e.buildBlock(ctx, stmt, ctes)
+	if err != nil {
+		return nil, err
+	}
+	op := query.Physical(query.Optimize(plan))
+	return query.Execute(op, &query.Env{Outer: outer, Runner: subqueryRunner{e, ctes, ctx}, Ctx: ctx})
+}
+
+// planSelect menyusun rencana logis, mengoptimasinya, lalu mengubahnya
+// menjadi pohon operator fisik.
+func (e *Engine) planSelect(ctx context.Context, stmt *parser.SelectStmt, ctes *cteScope) (query.Operator, error) {
+	plan, err := e.buildBlock(ctx, stmt, ctes)
+	if err != nil {
+		return nil, err
+	}
+	for _, clause := range stmt.SetOps {
+		right, err := e.buildBlock(ctx, clause.Select, ctes)
+		if err != nil {
+			return nil, err
+		}
+		plan = &query.SetOpPlan{Left: plan, Right: right, Op: clause.Operation, All: clause.All}
+	}
+	if stmt.Limit != nil {
+		plan = &query.LimitPlan{Input: plan, Count: *stmt.Limit}
+	}
+	return query.Physical(query.Optimize(plan)), nil
+}
+
+func (e *Engine) buildBlock(ctx context.Context, stmt *parser.SelectStmt, ctes *cteScope) (query.Plan, error) {
+	refs := append([]*parser.TableRef{stmt.From}, joinTables(stmt.Joins)...)
+	scans := make([]*query.ScanPlan, len(refs))
+	for i, ref := range refs {
+		src, err := e.source(ctx, ref.Name, ctes)
+		if err != nil {
+			return nil, err
+		}
+		scans[i] = query.NewScan(src, ref.RefName())
+	}
+	return query.BuildSelectPlan(stmt, scans), nil
+}
+
+// source mencari sumber DARI/GABUNG. Nama CTE menutupi pandangan dan
+// tangki dengan nama yang sama.
+func (e *Engine) source(ctx context.Context, name string, ctes *cteScope) (*query.Source, error) {
+	if rel, ok := ctes.lookup(name); ok {
+		return &query.Source{
+			Kind:   query.SourceCTE,
+			Name:   name,
+			Schema: rel.Schema,
+			Rows:   len(rel.Rows),
+			Load:   func() (*query.Relation, error) { return rel, nil },
+		}, nil
+	}
+
+	if v, ok := e.views[name]; ok && !v.materialized {
+		return &query.Source{
+			Kind:   query.SourcePandangan,
+			Name:   name,
+			Schema: v.schema,
+			Rows:   -1,
+			Load:   func() (*query.Relation, error) { return e.scanView(ctx, v, "") },
+		}, nil
+	}
+
+	t, exists := e.tangkis[name]
+	if !exists {
+		return nil, fmt.Errorf("tangki '%s' tidak ditemukan", name)
+	}
+	t, err := t.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	schema := query.SchemaOf(t, "")
+	src := &query.Source{
+		Kind:    query.SourceTangki,
+		Name:    name,
+		Schema:  schema,
+		Rows:    t.Len(),
+		Stats:   e.stats[name],
+		Load:    func() (*query.Relation, error) { return &query.Relation{Schema: schema, Rows: t.Rows}, nil },
+		Vectors: t.Columnar,
+	}
+	if t.Paged() {
+		src.Cursor = t.Cursor
+	}
+	return src, nil
+}
+
+// sourceSchema menghitung schema DARI + GABUNG tanpa menjalankan query.
+// CTE milik stmt sendiri tetap harus dievaluasi untuk mengetahui schemanya.
+func (e *Engine) sourceSchema(ctx context.Context, stmt *parser.SelectStmt, ctes *cteScope) (query.Schema, error) {
+	ctes, err := e.evalWith(ctx, stmt.With, nil, ctes)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := append([]*parser.TableRef{stmt.From}, joinTables(stmt.Joins)...)
+
+	var schema query.Schema
+	for _, ref := range refs {
+		src, err := e.source(ctx, ref.Name, ctes)
+		if err != nil {
+			return nil, err
+		}
+		schema = append(schema, qualify(src.Schema, ref.RefName())...)
+	}
+	return schema, nil
+}
+
+// explainSelect menjalankan stmt lalu mengembalikan rencana fisiknya,
+// satu baris teks per operator, beserta perkiraan dan jumlah baris aktual.
+func (e *Engine) explainSelect(ctx context.Context, stmt *parser.SelectStmt) ([]tangki.Row, error) {
+	ctes, err := e.evalWith(ctx, stmt.With, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, cte := range stmt.With {
+		rel, _ := ctes.lookup(cte.Name)
+		lines = append(lines, fmt.Sprintf("CTE %s (aktual=%d)", cte.Name, len(rel.Rows)))
+	}
+
+	op, err := e.planSelect(ctx, stmt, ctes)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := query.Execute(op, &query.Env{Runner: subqueryRunner{e, ctes, ctx}, Ctx: ctx}); err != nil {
+		return nil, err
+	}
+	lines = append(lines, query.Explain(op)...)
+
+	rows := make([]tangki.Row, len(lines))
+	for i, line := range lines {
+		rows[i] = tangki.Row{line}
+	}
+	return rows, nil
+}
+
+func joinTables(joins []*parser.JoinClause) []*parser.TableRef {
+	refs := make([]*parser.TableRef, len(joins))
+	for i, j := range joins {
+		refs[i] = j.Table
+	}
+	return refs
+}
+
+// subqueryRunner menghubungkan evaluator ekspresi di pkg/query dengan
+// tangki milik engine dan CTE yang terlihat dari query luar.
+type subqueryRunner struct {
+	e    *Engine
+	ctes *cteScope
+	ctx  context.Context
+}
+
+func (r subqueryRunner) SourceSchema(stmt *parser.SelectStmt) (query.Schema, error) {
+	return r.e.sourceSchema(r.ctx, stmt, r.ctes)
+}
+
+func (r subqueryRunner) RunSubquery(stmt *parser.SelectStmt, outer *query.Scope) (*query.Relation, error) {
+	return r.e.execSelect(r.ctx, stmt, outer, r.ctes)
+}
@@ -0,0 +1,237 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Snapshot (MVCC): pembaca memakai salinan katalog (peta tangki,
+// pandangan, statistik) dari e.current tanpa menahan lock engine, sehingga
+// query panjang tidak menahan penulis dan tetap melihat keadaan pada satu
+// titik waktu. Penulis cukup membuang snapshot lama lewat commit; salinan
+// baru dibuat oleh pembaca berikutnya, jadi rentetan ISI tidak menyalin
+// katalog berulang kali.
+//
+// Tangki dalam snapshot tidak pernah diubah lagi: penulis mengubah salinan
+// dari forkTangki lalu memasangnya dengan installTangki setelah berhasil,
+// dan ATUR/BAKAR menyalin baris yang disentuhnya. Versi lama dibebaskan
+// garbage collector Go begitu tidak ada lagi snapshot yang memegangnya.
+//
+// Penulis yang berjalan bersamaan bisa membuang snapshot sebelum penulis
+// lain selesai mencatat perubahannya di WAL. Karena itu setiap entri
+// katalog yang diubah lebih dulu dicatat isi lamanya dengan stage, dan
+// snapshot memakai isi lama itu sampai penulisnya memanggil commit. Bila
+// penulisan WAL gagal, rollback memasang kembali isi lama tersebut.
+
+// snapshot mengembalikan keadaan database terbaru yang sudah selesai
+// ditulis. Hasilnya hanya boleh dibaca.
+func (e *Engine) snapshot() *Engine {
+	if snap := e.current.Load(); snap != nil {
+		return snap
+	}
+
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	snap := e.current.Load()
+	if snap == nil {
+		snap = e.snapshotNoLock()
+		e.current.Store(snap)
+	}
+	return snap
+}
+
+// publish membuat semua perubahan yang sudah dipasang terlihat oleh
+// pembaca berikutnya, misalnya setelah database selesai dimuat.
+func (e *Engine) publish() {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	e.pending = nil
+	e.current.Store(nil)
+}
+
+// snapshotNoLock menyalin peta katalog. Isinya cukup disalin pointernya
+// karena tangki, pandangan, dan statistik tidak diubah setelah diterbitkan.
+// Asumsi: stateMu sudah diambil oleh caller
+func (e *Engine) snapshotNoLock() *Engine {
+	snap := &Engine{
+		tangkis:    make(map[string]*tangki.Tangki, len(e.tangkis)),
+		views:      make(map[string]*view, len(e.views)),
+		stats:      make(map[string]*query.TableStats, len(e.stats)),
+		changes:    make(map[string]int, len(e.changes)),
+		durability: e.durability,
+		file:       e.file,
+		pool:       e.pool,
+		compress:   e.compress,
+		version:    e.version,
+	}
+	for name, t := range e.tangkis {
+		snap.tangkis[name] = t
+	}
+	for name, v := range e.views {
+		snap.views[name] = v
+	}
+	for name, s := range e.stats {
+		snap.stats[name] = s
+		snap.changes[name] = e.changes[name]
+	}
+	for name, p := range e.pending {
+		p.restore(name, snap)
+	}
+	return snap
+}
+
+// pendingEntry adalah isi katalog untuk satu nama sebelum diubah oleh
+// penulis yang belum commit.
+type pendingEntry struct {
+	tangki  *tangki.Tangki
+	view    *view
+	stats   *query.TableStats
+	changes int
+}
+
+// restore memasang isi lama p untuk name ke peta katalog dst.
+func (p pendingEntry) restore(name string, dst *Engine) {
+	delete(dst.tangkis, name)
+	delete(dst.views, name)
+	delete(dst.stats, name)
+	delete(dst.changes, name)
+	if p.tangki != nil {
+		dst.tangkis[name] = p.tangki
+	}
+	if p.view != nil {
+		dst.views[name] = p.view
+	}
+	if p.stats != nil {
+		dst.stats[name] = p.stats
+		dst.changes[name] = p.changes
+	}
+}
+
+// stage mencatat isi katalog untuk name sebelum penulis mengubahnya.
+// Hanya perubahan pertama yang dicatat, sampai commit atau rollback.
+// Asumsi: stateMu sudah diambil oleh caller
+func (e *Engine) stage(name string) {
+	if _, ok := e.pending[name]; ok {
+		return
+	}
+	if e.pending == nil {
+		e.pending = make(map[string]pendingEntry)
+	}
+	e.pending[name] = pendingEntry{
+		tangki:  e.tangkis[name],
+		view:    e.views[name],
+		stats:   e.stats[name],
+		changes: e.changes[name],
+	}
+}
+
+// commit menerbitkan perubahan penulis pada names (semua bila nil) dan
+// menandai database kotor. Dipanggil setelah perubahan tercatat di WAL.
+func (e *Engine) commit(names []string, memStale bool) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	e.markDirtyNoLock(memStale)
+	e.forgetPending(names)
+	e.current.Store(nil)
+}
+
+// rollback membatalkan perubahan penulis pada names (semua bila nil)
+// yang belum di-commit.
+func (e *Engine) rollback(names []string) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	for name, p := range e.pending {
+		if names == nil || containsName(names, name) {
+			p.restore(name, e)
+		}
+	}
+	e.forgetPending(names)
+	e.memStale = true
+}
+
+// Asumsi: stateMu sudah diambil oleh caller
+func (e *Engine) forgetPending(names []string) {
+	if names == nil {
+		e.pending = nil
+		return
+	}
+	for _, name := range names {
+		delete(e.pending, name)
+	}
+}
+
+// lookupTangki mengembalikan versi terbaru tangki name milik penulis.
+// Tangki lazy (Options.LazyLoad) dimuat dulu dan menggantikan tangki
+// penggantinya; snapshot lama tetap memegang pengganti yang Resolve-nya
+// menghasilkan tangki yang sama.
+func (e *Engine) lookupTangki(name string) (*tangki.Tangki, error) {
+	e.stateMu.Lock()
+	t, exists := e.tangkis[name]
+	e.stateMu.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("tangki '%s' tidak ditemukan", name)
+	}
+	if !t.Lazy() {
+		return t, nil
+	}
+
+	loaded, err := t.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	e.stateMu.Lock()
+	if e.tangkis[name] == t {
+		e.tangkis[name] = loaded
+	}
+	e.stateMu.Unlock()
+	return loaded, nil
+}
+
+// forkTangki mengembalikan versi baru tangki name yang boleh diubah tanpa
+// terlihat oleh pembaca sampai dipasang dengan installTangki.
+// Asumsi: lock tangki name sudah diambil oleh caller
+func (e *Engine) forkTangki(name string) (*tangki.Tangki, error) {
+	t, err := e.lookupTangki(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.Fork(), nil
+}
+
+// installTangki memasang t sebagai versi terbaru tangki t.Name. Pembaca
+// melihatnya setelah commit. Pada mode halaman, baris t yang
+// masih di memori dipindahkan dulu ke halaman baru.
+func (e *Engine) installTangki(t *tangki.Tangki) error {
+	if err := e.prepareTangki(t); err != nil {
+		return err
+	}
+
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	e.stage(t.Name)
+	e.tangkis[t.Name] = t
+	return nil
+}
+
+// prepareTangki menyesuaikan t dengan mode engine: baris dipindahkan ke
+// halaman pada mode halaman dan cache kolom dinyalakan bila ColumnCache.
+func (e *Engine) prepareTangki(t *tangki.Tangki) error {
+	if t.Lazy() {
+		return nil
+	}
+	if e.pool != nil {
+		if err := t.WritePages(e.pool); err != nil {
+			return err
+		}
+	}
+	if e.columnCache && !t.ColumnCache() {
+		t.SetColumnCache(true)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/query"
+)
+
+// defaultAnalyzeThreshold adalah bagian baris yang boleh berubah sebelum
+// statistik sebuah tangki dihitung ulang otomatis.
+const defaultAnalyzeThreshold = 0.2
+
+// SetAnalyzeThreshold mengatur bagian baris (0..1) yang harus berubah sejak
+// ANALISIS terakhir sebelum statistik dihitung ulang otomatis. Nilai <= 0
+// mematikan penghitungan ulang otomatis.
+func (e *Engine) SetAnalyzeThreshold(share float64) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	e.analyzeThreshold = share
+}
+
+// GetStatistik mengembalikan statistik terakhir hasil ANALISIS TANGKI.
+func (e *Engine) GetStatistik(name string) (*query.TableStats, bool) {
+	stats, ok := e.snapshot().stats[name]
+	return stats, ok
+}
+
+// ANALISIS TANGKI nama
+func (e *Engine) analyzeTangki(q *parser.Query) error {
+	t, err := e.lookupTangki(q.Tangki)
+	if err != nil {
+		return err
+	}
+
+	stats, err := query.Analyze(t)
+	if err != nil {
+		return err
+	}
+	e.stateMu.Lock()
+	e.stage(q.Tangki)
+	e.stats[q.Tangki] = stats
+	e.changes[q.Tangki] = 0
+	e.stateMu.Unlock()
+	return nil
+}
+
+// trackChanges mencatat perubahan pada tangki target dan menghitung ulang
+// statistiknya bila bagian baris yang berubah sudah melewati ambang.
+// Tangki yang dibuat ulang (BUAT, GABUNG, SATUKAN) kehilangan statistiknya.
+// Asumsi: lock tangki target sudah diambil oleh caller
+func (e *Engine) trackChanges(q *parser.Query, target string, changed int) {
+	e.stateMu.Lock()
+	if _, ok := e.stats[target]; !ok {
+		e.stateMu.Unlock()
+		return
+	}
+	switch q.Type {
+	case "CREATE", "JOIN", "UNION":
+		e.forgetStats(target)
+		e.stateMu.Unlock()
+		return
+	}
+
+	e.stage(target)
+	e.changes[target] += changed
+	rows := e.stats[target].Rows
+	if rows < 1 {
+		rows = 1
+	}
+	stale := e.analyzeThreshold > 0 && float64(e.changes[target])/float64(rows) >= e.analyzeThreshold
+	t := e.tangkis[target]
+	e.stateMu.Unlock()
+	if !stale {
+		return
+	}
+
+	// Analyze dijalankan di luar stateMu agar penulis tangki lain tidak
+	// menunggu
+	stats, err := query.Analyze(t)
+	if err != nil {
+		// Statistik lama tetap dipakai sampai ANALISIS berikutnya
+		return
+	}
+	e.stateMu.Lock()
+	e.stats[target] = stats
+	e.changes[target] = 0
+	e.stateMu.Unlock()
+}
+
+// Asumsi: stateMu sudah diambil oleh caller
+func (e *Engine) forgetStats(name string) {
+	e.stage(name)
+	delete(e.stats, name)
+	delete(e.changes, name)
+}
@@ -0,0 +1,327 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// view adalah pandangan yang disimpan sebagai query bernama. Pandangan
+// terwujud juga punya tangki dengan nama yang sama di e.tangkis.
+type view struct {
+	name         string
+	definition   string
+	stmt         *parser.SelectStmt
+	materialized bool
+	sources      []string
+	schema       query.Schema
+	seq          int
+}
+
+// simple berarti hasil pandangan bisa diperbarui per baris saat ISI:
+// satu tangki sumber tanpa DENGAN, join, subquery, fungsi window, atau
+// operasi himpunan.
+func (v *view) simple() bool {
+	s := v.stmt
+	return len(s.With) == 0 && len(s.Joins) == 0 && len(s.SetOps) == 0 && s.Limit == nil && !s.HasSubquery() && !s.HasWindow()
+}
+
+func (e *Engine) createView(ctx context.Context, q *parser.Query) error {
+	info := q.ViewInfo
+	if _, exists := e.tangkis[info.Name]; exists {
+		return fmt.Errorf("tangki '%s' sudah ada", info.Name)
+	}
+	if _, exists := e.views[info.Name]; exists {
+		return fmt.Errorf("pandangan '%s' sudah ada", info.Name)
+	}
+
+	_, err := e.registerView(ctx, info.Name, info.Definition, info.Select, info.Materialized)
+	return err
+}
+
+// registerView memvalidasi definisi dengan menjalankannya sekali, lalu
+// mendaftarkan pandangan (dan tangki hasilnya bila terwujud).
+func (e *Engine) registerView(ctx context.Context, name, definition string, stmt *parser.SelectStmt, materialized bool) (*view, error) {
+	rel, err := e.execSelect(ctx, stmt, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("definisi pandangan '%s' tidak valid: %v", name, err)
+	}
+
+	e.viewSeq++
+	v := &view{
+		name:         name,
+		definition:   definition,
+		stmt:         stmt,
+		materialized: materialized,
+		sources:      e.viewSources(stmt),
+		schema:       unqualified(rel.Schema),
+		seq:          e.viewSeq,
+	}
+
+	if materialized {
+		var t *tangki.Tangki
+		if _, ok := e.tangkis[name]; ok {
+			// Dimuat dari file: baris terwujud sudah ada di tangki
+			if t, err = e.forkTangki(name); err != nil {
+				return nil, err
+			}
+			t.Columns = v.schema.Columns()
+		} else {
+			t = materialize(name, rel)
+		}
+		if err := e.installTangki(t); err != nil {
+			return nil, err
+		}
+	}
+
+	e.stateMu.Lock()
+	e.stage(name)
+	e.views[name] = v
+	e.stateMu.Unlock()
+	return v, nil
+}
+
+// viewSources mengembalikan tangki yang perubahannya harus memicu refresh.
+// Pandangan biasa diekspansi ke sumbernya; pandangan terwujud dihitung
+// sebagai sumber tersendiri agar refresh berantai.
+func (e *Engine) viewSources(stmt *parser.SelectStmt) []string {
+	seen := make(map[string]bool)
+	var sources []string
+	for _, name := range stmt.Tables() {
+		names := []string{name}
+		if v, ok := e.views[name]; ok && !v.materialized {
+			names = v.sources
+		}
+		for _, n := range names {
+			if !seen[n] {
+				seen[n] = true
+				sources = append(sources, n)
+			}
+		}
+	}
+	return sources
+}
+
+func (e *Engine) refreshView(ctx context.Context, q *parser.Query) error {
+	v, exists := e.views[q.ViewInfo.Name]
+	if !exists {
+		return fmt.Errorf("pandangan '%s' tidak ditemukan", q.ViewInfo.Name)
+	}
+	if !v.materialized {
+		return fmt.Errorf("pandangan '%s' bukan pandangan terwujud", v.name)
+	}
+	if err := e.rematerialize(ctx, v); err != nil {
+		return err
+	}
+	return e.refreshDependents(v.name, 0)
+}
+
+func (e *Engine) rematerialize(ctx context.Context, v *view) error {
+	rel, err := e.execSelect(ctx, v.stmt, nil, nil)
+	if err != nil {
+		return fmt.Errorf("refresh pandangan '%s': %v", v.name, err)
+	}
+
+	return e.installTangki(materialize(v.name, rel))
+}
+
+// refreshDependents memperbarui pandangan terwujud yang bersumber dari
+// tangki name setelah tangki itu berubah. inserted > 0 berarti perubahan
+// hanya berupa baris baru di akhir tangki, sehingga pandangan sederhana
+// cukup menambahkan baris-baris itu saja.
+func (e *Engine) refreshDependents(name string, inserted int) error {
+	for _, v := range e.viewsBySeq() {
+		if !v.materialized || !containsName(v.sources, name) {
+			continue
+		}
+
+		var err error
+		if inserted > 0 && v.simple() && v.stmt.From.Name == name {
+			err = e.appendToView(v, inserted)
+		} else {
+			// Perubahan sumber sudah terjadi, jadi refresh tidak boleh
+			// dibatalkan di tengah jalan
+			err = e.rematerialize(context.Background(), v)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := e.refreshDependents(v.name, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) appendToView(v *view, inserted int) error {
+	src, err := e.lookupTangki(v.stmt.From.Name)
+	if err != nil {
+		return err
+	}
+	target, err := e.forkTangki(v.name)
+	if err != nil {
+		return err
+	}
+	env := &query.Env{Runner: subqueryRunner{e: e, ctx: context.Background()}}
+
+	rows, err := src.RowRange(src.Len()-inserted, src.Len())
+	if err != nil {
+		return fmt.Errorf("refresh pandangan '%s': %v", v.name, err)
+	}
+	rel := &query.Relation{
+		Schema: query.SchemaOf(src, v.stmt.From.RefName()),
+		Rows:   rows,
+	}
+	rel, err = query.Filter(rel, v.stmt.Where, env)
+	if err != nil {
+		return fmt.Errorf("refresh pandangan '%s': %v", v.name, err)
+	}
+	rel, err = query.Project(rel, v.stmt.Items, env)
+	if err != nil {
+		return fmt.Errorf("refresh pandangan '%s': %v", v.name, err)
+	}
+
+	for _, row := range rel.Rows {
+		if err := target.AppendRow(row.Clone()); err != nil {
+			return fmt.Errorf("refresh pandangan '%s': %v", v.name, err)
+		}
+	}
+	return e.installTangki(target)
+}
+
+// scanView menjalankan pandangan biasa saat dibaca.
+func (e *Engine) scanView(ctx context.Context, v *view, alias string) (*query.Relation, error) {
+	rel, err := e.execSelect(ctx, v.stmt, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pandangan '%s': %v", v.name, err)
+	}
+	return &query.Relation{Schema: qualify(rel.Schema, alias), Rows: rel.Rows}, nil
+}
+
+func (e *Engine) viewsBySeq() []*view {
+	views := make([]*view, 0, len(e.views))
+	for _, v := range e.views {
+		views = append(views, v)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].seq < views[j].seq })
+	return views
+}
+
+// ensureWritable menolak perubahan langsung pada pandangan.
+func (e *Engine) ensureWritable(name string) error {
+	if _, ok := e.views[name]; ok {
+		return fmt.Errorf("'%s' adalah pandangan dan tidak bisa diubah langsung", name)
+	}
+	return nil
+}
+
+// ListPandangan mengembalikan nama semua pandangan.
+func (e *Engine) ListPandangan() []string {
+	snap := e.snapshot()
+	names := make([]string, 0, len(snap.views))
+	for _, v := range snap.viewsBySeq() {
+		names = append(names, v.name)
+	}
+	return names
+}
+
+// DropPandangan menghapus pandangan beserta tangki hasilnya bila terwujud.
+func (e *Engine) DropPandangan(name string) error {
+	if e.readOnly {
+		return errReadOnly
+	}
+	return e.dropPandangan(name)
+}
+
+func (e *Engine) dropPandangan(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	v, exists := e.views[name]
+	if !exists {
+		return fmt.Errorf("pandangan '%s' tidak ditemukan", name)
+	}
+	for _, other := range e.views {
+		if other != v && containsName(other.stmt.Tables(), name) {
+			return fmt.Errorf("pandangan '%s' masih dipakai oleh '%s'", name, other.name)
+		}
+	}
+
+	e.stateMu.Lock()
+	e.stage(name)
+	delete(e.views, name)
+	if v.materialized {
+		delete(e.tangkis, name)
+	}
+	e.stateMu.Unlock()
+	if err := e.logChange(walDropView, name); err != nil {
+		e.rollback(nil)
+		return err
+	}
+	e.commit(nil, v.materialized)
+	return nil
+}
+
+func materialize(name string, rel *query.Relation) *tangki.Tangki {
+	t := tangki.NewTangki(name, unqualified(rel.Schema).Columns())
+	t.Rows = make([]tangki.Row, len(rel.Rows))
+	for i, row := range rel.Rows {
+		t.Rows[i] = row.Clone()
+	}
+	return t
+}
+
+func unqualified(schema query.Schema) query.Schema {
+	return qualify(schema, "")
+}
+
+func qualify(schema query.Schema, table string) query.Schema {
+	out := make(query.Schema, len(schema))
+	for i, f := range schema {
+		out[i] = query.Field{Table: table, Name: f.Name, Type: f.Type}
+	}
+	return out
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadView mendaftarkan ulang pandangan dari file. Baris pandangan
+// terwujud sudah dimuat sebagai tangki biasa.
+func (e *Engine) loadView(name string, materialized bool, definition string) error {
+	q, err := parser.NewParser(definition).Parse()
+	if err != nil || q.Select == nil {
+		return fmt.Errorf("definisi pandangan '%s' rusak: %v", name, err)
+	}
+	_, err = e.registerView(context.Background(), name, definition, q.Select, materialized)
+	return err
+}
+
+// readTangki mengembalikan tangki untuk perintah lama (URUTKAN, GRUPKAN).
+// Pandangan biasa dijalankan dan dibungkus sebagai tangki sementara;
+// tangki berhalaman tidak dimuat.
+func (e *Engine) readTangki(name string) (*tangki.Tangki, error) {
+	if v, ok := e.views[name]; ok && !v.materialized {
+		rel, err := e.scanView(context.Background(), v, "")
+		if err != nil {
+			return nil, err
+		}
+		return materialize(name, rel), nil
+	}
+	t, exists := e.tangkis[name]
+	if !exists {
+		return nil, fmt.Errorf("tangki '%s' tidak ditemukan", name)
+	}
+	return t.Resolve()
+}
@@ -0,0 +1,384 @@
+package engine
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+)
+
+// WAL (write-ahead log) dipakai DurabilityFsync. Setiap perubahan yang
+// berhasil ditambahkan ke file path+".wal" sebagai satu catatan lalu
+// di-fsync, jadi biaya per perintah sebanding dengan panjang perintahnya,
+// bukan ukuran database. Saat dibuka, catatan yang belum ada di snapshot
+// dijalankan ulang; checkpoint menulis snapshot lalu membuang catatan yang
+// sudah tercakup.
+//
+// File diawali walMagic dan posisi (LSN) byte sesudah header. LSN adalah
+// offset logis yang terus bertambah melewati checkpoint, dan snapshot
+// menyimpan LSN akhir WAL yang sudah tercakup di dalamnya (format minor
+// 7). Catatan: panjang isi (uint32), CRC-32 isi (uint32), lalu isi: waktu
+// commit (int64 UnixNano), jenis catatan, dan teksnya. Catatan terakhir
+// yang terpotong atau CRC-nya salah berarti crash saat menulis dan
+// diabaikan.
+const walMagic = "BWAL"
+
+const walHeaderSize = len(walMagic) + 8
+
+// Jenis catatan WAL.
+const (
+	walStatement  = 0 // perintah FQL dari Jalankan
+	walDropTangki = 1 // DropTangki, teks adalah nama tangki
+	walDropView   = 2 // DropPandangan, teks adalah nama pandangan
+)
+
+// walPath adalah file WAL milik database di path.
+func walPath(path string) string {
+	return path + ".wal"
+}
+
+// walRecord adalah satu catatan WAL beserta posisinya.
+type walRecord struct {
+	lsn  int64
+	time time.Time
+	kind byte
+	text string
+}
+
+// wal adalah file WAL yang terbuka untuk ditambah.
+type wal struct {
+	mu      sync.Mutex
+	path    string
+	archive string // Options.WALArchive
+	file    *os.File
+	base    int64 // LSN byte sesudah header
+	size    int64 // byte catatan sesudah header
+}
+
+// openWAL membuka WAL di path untuk ditambah. File yang belum ada dibuat
+// mulai dari LSN base; catatan terpotong di ujung file lama dibuang.
+func openWAL(path, archive string, base int64) (*wal, error) {
+	wf, err := readWAL(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &wal{path: path, archive: archive}
+	if wf == nil {
+		if err := w.rewrite(base, nil); err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+	size := wf.end - wf.base
+	if err := os.Truncate(path, int64(walHeaderSize)+size); err != nil {
+		return nil, err
+	}
+	w.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	w.base, w.size = wf.base, size
+	return w, nil
+}
+
+// rewrite mengganti isi file dengan header base dan catatan tail lewat
+// rename, sehingga crash di tengah jalan tidak merusak WAL lama.
+// Asumsi: w.mu sudah diambil oleh caller, atau w belum dipakai
+func (w *wal) rewrite(base int64, tail []byte) error {
+	file, err := os.CreateTemp(tempPath(w.path))
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+	data := binary.LittleEndian.AppendUint64([]byte(walMagic), uint64(base))
+	data = append(data, tail...)
+	if _, err := file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, w.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	file, err = os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.file, w.base, w.size = file, base, int64(len(tail))
+	return nil
+}
+
+func encodeWALRecord(kind byte, text string, at time.Time) []byte {
+	body := binary.LittleEndian.AppendUint64(nil, uint64(at.UnixNano()))
+	body = append(body, kind)
+	body = append(body, text...)
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(body)))
+	b = binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(body))
+	return append(b, body...)
+}
+
+// append menulis satu catatan lalu fsync.
+func (w *wal) append(kind byte, text string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b := encodeWALRecord(kind, text, time.Now())
+	n, err := w.file.Write(b)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("menulis WAL: %v", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("menulis WAL: %v", err)
+	}
+	return nil
+}
+
+// end mengembalikan LSN sesudah catatan terakhir.
+func (w *wal) end() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.base + w.size
+}
+
+// trim membuang catatan sebelum LSN lsn, yang sudah tercakup oleh
+// snapshot yang baru tertulis. Dengan arsip, catatan itu dipindah ke arsip.
+func (w *wal) trim(lsn int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if lsn <= w.base {
+		return nil
+	}
+	data := make([]byte, w.size)
+	f, err := os.Open(w.path)
+	if err != nil {
+		return err
+	}
+	_, err = f.ReadAt(data, int64(walHeaderSize))
+	f.Close()
+	if err != nil {
+		return err
+	}
+	cut := min(lsn-w.base, w.size)
+	if w.archive != "" {
+		if err := archiveWAL(w.archive, w.base, data[:cut]); err != nil {
+			return fmt.Errorf("mengarsipkan WAL: %v", err)
+		}
+	}
+	return w.rewrite(lsn, data[cut:])
+}
+
+// archiveWAL menambahkan catatan data, yang dimulai di LSN base, ke arsip
+// WAL path. Arsip berformat sama dengan WAL dengan LSN yang bersambung;
+// arsip lama yang tidak bersambung dengan base dipindah ke path+"."+LSN
+// awalnya dan arsip baru dimulai.
+func archiveWAL(path string, base int64, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() > 0 {
+		header := make([]byte, walHeaderSize)
+		if _, err := f.ReadAt(header, 0); err != nil || string(header[:len(walMagic)]) != walMagic {
+			return fmt.Errorf("header arsip WAL '%s' tidak dikenal", path)
+		}
+		start := int64(binary.LittleEndian.Uint64(header[len(walMagic):]))
+		if start+info.Size()-int64(walHeaderSize) == base {
+			return appendSync(f, data)
+		}
+		f.Close()
+		if err := os.Rename(path, fmt.Sprintf("%s.%d", path, start)); err != nil {
+			return err
+		}
+		if f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644); err != nil {
+			return err
+		}
+	}
+	header := binary.LittleEndian.AppendUint64([]byte(walMagic), uint64(base))
+	return appendSync(f, append(header, data...))
+}
+
+func appendSync(f *os.File, data []byte) error {
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// close menutup file WAL dan menghapusnya bila tidak ada catatan tersisa.
+func (w *wal) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.file.Close()
+	if err == nil && w.size == 0 {
+		err = os.Remove(w.path)
+	}
+	return err
+}
+
+// walFile adalah isi file WAL: catatan utuh dari LSN base sampai end.
+type walFile struct {
+	base, end int64
+	records   []walRecord
+}
+
+// readWAL membaca semua catatan utuh di file WAL (atau arsip WAL) path.
+// Hasilnya nil bila file tidak ada.
+func readWAL(path string) (*walFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < walHeaderSize || string(data[:len(walMagic)]) != walMagic {
+		return nil, &CorruptError{Reason: fmt.Sprintf("header WAL '%s' tidak dikenal", path)}
+	}
+	wf := &walFile{base: int64(binary.LittleEndian.Uint64(data[len(walMagic):]))}
+
+	pos := walHeaderSize
+	for len(data)-pos >= 8 {
+		size := int(binary.LittleEndian.Uint32(data[pos:]))
+		sum := binary.LittleEndian.Uint32(data[pos+4:])
+		if size < 9 || size > len(data)-pos-8 {
+			break
+		}
+		body := data[pos+8 : pos+8+size]
+		if crc32.ChecksumIEEE(body) != sum {
+			break
+		}
+		wf.records = append(wf.records, walRecord{
+			lsn:  wf.base + int64(pos-walHeaderSize),
+			time: time.Unix(0, int64(binary.LittleEndian.Uint64(body))),
+			kind: body[8],
+			text: string(body[9:]),
+		})
+		pos += 8 + size
+	}
+	wf.end = wf.base + int64(pos-walHeaderSize)
+	return wf, nil
+}
+
+// replayWAL menjalankan ulang catatan WAL path yang belum tercakup snapshot
+// (LSN e.walLSN ke atas) dan, bila until tidak nol, yang waktunya tidak
+// sesudah until. e.walLSN menjadi LSN sesudah catatan terakhir yang
+// dijalankan. stopped berarti ada catatan sesudah until.
+func (e *Engine) replayWAL(path string, until time.Time) (n int, stopped bool, err error) {
+	wf, err := readWAL(path)
+	if err != nil || wf == nil {
+		return 0, false, err
+	}
+	if wf.base > e.walLSN {
+		return 0, false, fmt.Errorf("WAL '%s' dimulai di LSN %d, sesudah posisi snapshot %d: ada perubahan yang hilang", path, wf.base, e.walLSN)
+	}
+	for _, rec := range wf.records {
+		if rec.lsn < e.walLSN {
+			continue
+		}
+		if !until.IsZero() && rec.time.After(until) {
+			return n, true, nil
+		}
+		if err := e.applyWAL(rec); err != nil {
+			return n, false, fmt.Errorf("menjalankan ulang WAL pada LSN %d: %v", rec.lsn, err)
+		}
+		e.walLSN = rec.lsn + int64(8+9+len(rec.text))
+		n++
+	}
+	return n, false, nil
+}
+
+func (e *Engine) applyWAL(rec walRecord) error {
+	switch rec.kind {
+	case walStatement:
+		q, err := parser.NewParser(rec.text).Parse()
+		if err != nil {
+			return err
+		}
+		return e.jalankan(context.Background(), q, nil, "")
+	case walDropTangki:
+		return e.dropTangki(rec.text)
+	case walDropView:
+		return e.dropPandangan(rec.text)
+	}
+	return fmt.Errorf("jenis catatan %d tidak dikenal", rec.kind)
+}
+
+// walEnd adalah LSN yang tercakup oleh snapshot yang ditulis sekarang.
+func (e *Engine) walEnd() int64 {
+	if e.wal == nil {
+		return e.walLSN
+	}
+	return e.wal.end()
+}
+
+// logChange mencatat perubahan yang sudah terpasang ke WAL. Tidak ada apa
+// pun yang dicatat tanpa WAL, termasuk saat WAL sedang dijalankan ulang.
+// Asumsi: lock yang dipakai untuk perubahan itu masih dipegang caller
+func (e *Engine) logChange(kind byte, text string) error {
+	if e.wal == nil {
+		return nil
+	}
+	return e.wal.append(kind, text)
+}
+
+// recoverWAL menjalankan ulang WAL milik path setelah snapshot dimuat.
+// Engine penulis lalu membuang catatan yang sudah tercakup lewat checkpoint
+// (juga pada DurabilityNone) agar tidak dijalankan ulang lagi, dan tetap
+// membuka WAL bila DurabilityFsync.
+func (e *Engine) recoverWAL(path string) error {
+	n, _, err := e.replayWAL(walPath(path), time.Time{})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		e.logf("menjalankan ulang %d catatan WAL", n)
+	}
+	if e.readOnly {
+		return nil
+	}
+	if _, err := os.Stat(walPath(path)); os.IsNotExist(err) && e.durability != DurabilityFsync {
+		return nil
+	}
+
+	if e.wal, err = openWAL(walPath(path), e.walArchive, e.walLSN); err != nil {
+		return err
+	}
+	if n > 0 {
+		err = e.saveNoLock()
+	} else {
+		err = e.wal.trim(e.walLSN)
+	}
+	if err != nil || e.durability != DurabilityFsync {
+		if cerr := e.wal.close(); err == nil {
+			err = cerr
+		}
+		e.wal = nil
+	}
+	return err
+}
@@ -0,0 +1,373 @@
+// Package pager menyimpan data dalam halaman berukuran tetap di satu file
+// dan menyediakan buffer pool dengan eviksi clock untuk membacanya.
+package pager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// PageSize adalah ukuran setiap halaman dalam byte.
+const PageSize = 8192
+
+// PageID adalah nomor halaman; halaman id berada di offset id*PageSize.
+type PageID uint32
+
+// ErrPoolFull dikembalikan bila semua halaman di buffer pool sedang
+// di-pin sehingga tidak ada yang bisa dikeluarkan.
+var ErrPoolFull = errors.New("semua halaman di buffer pool sedang dipakai")
+
+// Pager membaca dan menulis halaman ke file. Halaman yang tidak lagi
+// ditahan Lease mana pun masuk daftar bebas dan dipakai ulang oleh
+// alokasi berikutnya, sehingga file tidak terus membesar.
+type Pager struct {
+	mu    sync.Mutex
+	file  *os.File
+	pages PageID
+	refs  map[PageID]int
+	free  []PageID
+}
+
+// Open membuka atau membuat file halaman di path.
+func Open(path string) (*Pager, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	pages := (info.Size() + PageSize - 1) / PageSize
+	return &Pager{file: file, pages: PageID(pages), refs: make(map[PageID]int)}, nil
+}
+
+// NumPages mengembalikan jumlah halaman yang sudah dialokasikan.
+func (p *Pager) NumPages() PageID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pages
+}
+
+// NumFree mengembalikan jumlah halaman di daftar bebas.
+func (p *Pager) NumFree() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.free)
+}
+
+// allocate memesan halaman dari daftar bebas, atau nomor halaman baru di
+// akhir file. Isinya baru tertulis saat halaman itu di-flush.
+func (p *Pager) allocate() PageID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n := len(p.free); n > 0 {
+		id := p.free[n-1]
+		p.free = p.free[:n-1]
+		return id
+	}
+	id := p.pages
+	p.pages++
+	return id
+}
+
+// Sweep menjadikan setiap halaman yang tidak ditahan Lease mana pun
+// sebagai halaman bebas. Dipanggil sekali setelah semua pemegang halaman
+// dimuat, misalnya untuk membuang halaman versi lama dari sesi sebelumnya.
+func (p *Pager) Sweep() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.free = p.free[:0]
+	for id := p.pages; id > 0; id-- {
+		if p.refs[id-1] == 0 {
+			p.free = append(p.free, id-1)
+		}
+	}
+}
+
+// Lease menahan sekumpulan halaman agar tidak dipakai ulang. Satu halaman
+// boleh ditahan beberapa Lease sekaligus; halaman menjadi bebas setelah
+// Lease terakhir yang menahannya di-Release.
+type Lease struct {
+	pager *Pager
+	mu    sync.Mutex
+	ids   []PageID
+}
+
+// Lease membuat Lease baru yang menahan ids.
+func (p *Pager) Lease(ids []PageID) *Lease {
+	l := &Lease{pager: p}
+	for _, id := range ids {
+		l.Add(id)
+	}
+	return l
+}
+
+// Add menahan halaman id.
+func (l *Lease) Add(id PageID) {
+	l.pager.mu.Lock()
+	l.pager.refs[id]++
+	l.pager.mu.Unlock()
+
+	l.mu.Lock()
+	l.ids = append(l.ids, id)
+	l.mu.Unlock()
+}
+
+// Clone membuat Lease baru yang menahan halaman yang sama dengan l.
+func (l *Lease) Clone() *Lease {
+	l.mu.Lock()
+	ids := append([]PageID(nil), l.ids...)
+	l.mu.Unlock()
+	return l.pager.Lease(ids)
+}
+
+// Release melepas semua halaman l. Release kedua tidak berbuat apa-apa.
+func (l *Lease) Release() {
+	l.mu.Lock()
+	ids := l.ids
+	l.ids = nil
+	l.mu.Unlock()
+
+	p := l.pager
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, id := range ids {
+		if p.refs[id]--; p.refs[id] <= 0 {
+			delete(p.refs, id)
+			p.free = append(p.free, id)
+		}
+	}
+}
+
+// ReadPage membaca halaman id ke buf. Halaman yang sudah dialokasikan
+// tetapi belum pernah ditulis terbaca sebagai nol.
+func (p *Pager) ReadPage(id PageID, buf []byte) error {
+	if id >= p.NumPages() {
+		return fmt.Errorf("halaman %d di luar file (%d halaman)", id, p.NumPages())
+	}
+	n, err := p.file.ReadAt(buf[:PageSize], int64(id)*PageSize)
+	if err == io.EOF {
+		err = nil
+	}
+	for i := n; i < PageSize; i++ {
+		buf[i] = 0
+	}
+	return err
+}
+
+// WritePage menulis buf sebagai halaman id.
+func (p *Pager) WritePage(id PageID, buf []byte) error {
+	_, err := p.file.WriteAt(buf[:PageSize], int64(id)*PageSize)
+	return err
+}
+
+// Sync memastikan halaman yang sudah ditulis sampai ke disk.
+func (p *Pager) Sync() error {
+	return p.file.Sync()
+}
+
+// Close menutup file halaman.
+func (p *Pager) Close() error {
+	return p.file.Close()
+}
+
+// Page adalah satu halaman di buffer pool. Data hanya boleh dibaca
+// selama halaman di-pin dan RLock dipegang, dan diubah selama Lock
+// dipegang.
+type Page struct {
+	sync.RWMutex
+	ID   PageID
+	Data []byte
+
+	pins  int
+	dirty bool
+	ref   bool
+}
+
+// BufferPool menampung paling banyak capacity halaman di memori. Halaman
+// yang tidak di-pin dikeluarkan dengan algoritma clock, dan yang kotor
+// ditulis dulu ke pager.
+type BufferPool struct {
+	mu     sync.Mutex
+	pager  *Pager
+	frames []*Page
+	table  map[PageID]int
+	hand   int
+	cap    int
+
+	hits, misses uint64
+}
+
+// NewBufferPool membuat buffer pool di atas pager dengan kapasitas
+// capacity halaman (minimal 1).
+func NewBufferPool(pager *Pager, capacity int) *BufferPool {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &BufferPool{pager: pager, table: make(map[PageID]int), cap: capacity}
+}
+
+// Pager mengembalikan pager milik bp.
+func (bp *BufferPool) Pager() *Pager { return bp.pager }
+
+// Fetch mengembalikan halaman id dalam keadaan di-pin. Panggil Unpin
+// setelah selesai.
+func (bp *BufferPool) Fetch(id PageID) (*Page, error) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	if i, ok := bp.table[id]; ok {
+		pg := bp.frames[i]
+		pg.pins++
+		pg.ref = true
+		bp.hits++
+		return pg, nil
+	}
+	bp.misses++
+
+	pg, err := bp.frame(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := bp.pager.ReadPage(id, pg.Data); err != nil {
+		bp.drop(id)
+		return nil, err
+	}
+	return pg, nil
+}
+
+// New mengalokasikan halaman kosong baru dalam keadaan di-pin.
+func (bp *BufferPool) New() (*Page, error) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	id := bp.pager.allocate()
+	var pg *Page
+	if i, ok := bp.table[id]; ok {
+		// Halaman bebas yang isinya masih ada di pool dipakai ulang
+		pg = bp.frames[i]
+		pg.pins++
+		pg.ref = true
+	} else {
+		var err error
+		if pg, err = bp.frame(id); err != nil {
+			return nil, err
+		}
+	}
+	for i := range pg.Data {
+		pg.Data[i] = 0
+	}
+	pg.dirty = true
+	return pg, nil
+}
+
+// Unpin melepas pin dari Fetch atau New. dirty berarti isi halaman
+// berubah dan harus ditulis sebelum dikeluarkan.
+func (bp *BufferPool) Unpin(pg *Page, dirty bool) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if dirty {
+		pg.dirty = true
+	}
+	if pg.pins > 0 {
+		pg.pins--
+	}
+}
+
+// Flush menulis semua halaman kotor lalu sync file.
+func (bp *BufferPool) Flush() error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	for _, pg := range bp.frames {
+		if err := bp.writeBack(pg); err != nil {
+			return err
+		}
+	}
+	return bp.pager.Sync()
+}
+
+// Stats mengembalikan jumlah Fetch yang menemukan halaman di memori (hits)
+// dan yang harus membaca dari file (misses).
+func (bp *BufferPool) Stats() (hits, misses uint64) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	return bp.hits, bp.misses
+}
+
+// frame menyiapkan frame yang sudah di-pin untuk halaman id, mengeluarkan
+// halaman lain bila pool penuh.
+// Asumsi: bp.mu sudah diambil oleh caller
+func (bp *BufferPool) frame(id PageID) (*Page, error) {
+	var pg *Page
+	if len(bp.frames) < bp.cap {
+		pg = &Page{Data: make([]byte, PageSize)}
+		bp.frames = append(bp.frames, pg)
+		bp.table[id] = len(bp.frames) - 1
+	} else {
+		i, err := bp.victim()
+		if err != nil {
+			return nil, err
+		}
+		pg = bp.frames[i]
+		if err := bp.writeBack(pg); err != nil {
+			return nil, err
+		}
+		delete(bp.table, pg.ID)
+		bp.table[id] = i
+	}
+	pg.ID = id
+	pg.pins = 1
+	pg.ref = true
+	pg.dirty = false
+	return pg, nil
+}
+
+// victim memilih frame yang akan dikeluarkan: jarum clock berputar dan
+// memberi kesempatan kedua pada halaman yang baru dipakai.
+func (bp *BufferPool) victim() (int, error) {
+	for step := 0; step < 2*len(bp.frames); step++ {
+		i := bp.hand
+		bp.hand = (bp.hand + 1) % len(bp.frames)
+		pg := bp.frames[i]
+		if pg.pins > 0 {
+			continue
+		}
+		if pg.ref {
+			pg.ref = false
+			continue
+		}
+		return i, nil
+	}
+	return 0, ErrPoolFull
+}
+
+func (bp *BufferPool) writeBack(pg *Page) error {
+	if !pg.dirty {
+		return nil
+	}
+	pg.RLock()
+	err := bp.pager.WritePage(pg.ID, pg.Data)
+	pg.RUnlock()
+	if err != nil {
+		return err
+	}
+	pg.dirty = false
+	return nil
+}
+
+// drop membuang frame halaman id yang gagal dibaca.
+func (bp *BufferPool) drop(id PageID) {
+	i := bp.table[id]
+	delete(bp.table, id)
+	pg := bp.frames[i]
+	pg.pins = 0
+	pg.ref = false
+	pg.dirty = false
+	// Frame dipakai ulang untuk halaman yang tidak ada
+	pg.ID = ^PageID(0)
+}
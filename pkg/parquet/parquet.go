@@ -0,0 +1,67 @@
+// Package parquet membaca dan menulis file Apache Parquet tanpa pustaka
+// luar. Yang didukung adalah skema datar (tanpa kolom bersarang atau
+// berulang), cukup untuk bertukar tangki dengan alat analitik.
+//
+// Saat menulis, INT menjadi INT64, FLOAT menjadi DOUBLE, dan TEKS menjadi
+// BYTE_ARRAY bertipe logis STRING. Semua kolom OPTIONAL sehingga NULL
+// tetap NULL. Saat membaca, semua tipe bilangan bulat (termasuk BOOLEAN,
+// tanggal, dan timestamp) menjadi INT, FLOAT/DOUBLE dan DECIMAL menjadi
+// FLOAT, serta BYTE_ARRAY menjadi TEKS.
+package parquet
+
+import "fmt"
+
+const magic = "PAR1"
+
+// Compression adalah codec kompresi halaman.
+type Compression int32
+
+const (
+	Uncompressed Compression = 0
+	Snappy       Compression = 1
+	Gzip         Compression = 2
+)
+
+// Tipe fisik Parquet.
+const (
+	typeBoolean   = 0
+	typeInt32     = 1
+	typeInt64     = 2
+	typeInt96     = 3
+	typeFloat     = 4
+	typeDouble    = 5
+	typeByteArray = 6
+	typeFixed     = 7
+)
+
+// Encoding Parquet.
+const (
+	encPlain          = 0
+	encPlainDict      = 2
+	encRLE            = 3
+	encRLEDict        = 8
+	convertedUTF8     = 0
+	convertedDecimal  = 5
+	repetitionReq     = 0
+	repetitionOpt     = 1
+	repetitionRepeat  = 2
+	pageData          = 0
+	pageDictionary    = 2
+	pageDataV2        = 3
+	logicalString     = 1
+	logicalDecimal    = 5
+	fileFormatVersion = 1
+)
+
+// physicalType mengembalikan tipe fisik untuk tipe kolom tangki.
+func physicalType(colType string) (int32, error) {
+	switch colType {
+	case "INT":
+		return typeInt64, nil
+	case "FLOAT":
+		return typeDouble, nil
+	case "TEKS":
+		return typeByteArray, nil
+	}
+	return 0, fmt.Errorf("tipe kolom %s belum didukung", colType)
+}
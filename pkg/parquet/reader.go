@@ -0,0 +1,480 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// File adalah file Parquet yang dibuka untuk dibaca. Hanya footer yang
+// dibaca oleh Open; baris dibaca per row group oleh Scan.
+type File struct {
+	r       io.ReaderAt
+	size    int64
+	columns []tangki.Column
+	leaves  []leaf
+	groups  []thriftFields
+	rows    int64
+}
+
+// leaf adalah cara membaca nilai satu kolom.
+type leaf struct {
+	typ      int64
+	length   int
+	optional bool
+	scale    int // >= 0 untuk DECIMAL
+}
+
+// Open membaca footer file Parquet sebesar size byte dari r.
+func Open(r io.ReaderAt, size int64) (*File, error) {
+	if size < int64(2*len(magic)+4) {
+		return nil, fmt.Errorf("bukan file parquet: terlalu kecil")
+	}
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	head := make([]byte, 4)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	if string(tail[4:]) != magic || string(head) != magic {
+		return nil, fmt.Errorf("bukan file parquet: magic PAR1 tidak ditemukan")
+	}
+	n := int64(binary.LittleEndian.Uint32(tail))
+	if n > size-12 {
+		return nil, fmt.Errorf("footer parquet rusak: panjang %d", n)
+	}
+	footer := make([]byte, n)
+	if _, err := r.ReadAt(footer, size-8-n); err != nil {
+		return nil, err
+	}
+	meta, err := (&thriftReader{data: footer}).readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("footer parquet rusak: %v", err)
+	}
+
+	f := &File{r: r, size: size}
+	f.rows, _ = meta.int(3)
+	if err := f.readSchema(meta.list(2)); err != nil {
+		return nil, err
+	}
+	for _, g := range meta.list(4) {
+		group, ok := g.(thriftFields)
+		if !ok || len(group.list(1)) != len(f.columns) {
+			return nil, fmt.Errorf("footer parquet rusak: row group tidak cocok dengan skema")
+		}
+		f.groups = append(f.groups, group)
+	}
+	return f, nil
+}
+
+func (f *File) readSchema(elements []interface{}) error {
+	if len(elements) == 0 {
+		return fmt.Errorf("footer parquet rusak: skema kosong")
+	}
+	root, _ := elements[0].(thriftFields)
+	if n, _ := root.int(5); int(n) != len(elements)-1 {
+		return fmt.Errorf("kolom bersarang belum didukung")
+	}
+	for _, e := range elements[1:] {
+		el, _ := e.(thriftFields)
+		name := string(el.bytes(4))
+		if n, _ := el.int(5); n > 0 {
+			return fmt.Errorf("kolom bersarang '%s' belum didukung", name)
+		}
+		rep, _ := el.int(3)
+		if rep == repetitionRepeat {
+			return fmt.Errorf("kolom berulang '%s' belum didukung", name)
+		}
+		typ, ok := el.int(1)
+		if !ok {
+			return fmt.Errorf("kolom '%s' tidak punya tipe", name)
+		}
+		length, _ := el.int(2)
+		l := leaf{typ: typ, length: int(length), optional: rep == repetitionOpt, scale: -1}
+
+		converted, hasConverted := el.int(6)
+		if hasConverted && converted == convertedDecimal {
+			scale, _ := el.int(7)
+			l.scale = int(scale)
+		}
+		if dec := el.child(10).child(logicalDecimal); dec != nil {
+			scale, _ := dec.int(1)
+			l.scale = int(scale)
+		}
+
+		col := tangki.Column{Name: name}
+		switch {
+		case l.scale >= 0:
+			col.Type = "FLOAT"
+		case typ == typeBoolean || typ == typeInt32 || typ == typeInt64:
+			col.Type = "INT"
+		case typ == typeFloat || typ == typeDouble:
+			col.Type = "FLOAT"
+		case typ == typeByteArray || typ == typeFixed:
+			col.Type = "TEKS"
+		default:
+			return fmt.Errorf("kolom '%s': tipe parquet %d belum didukung", name, typ)
+		}
+		if typ == typeFixed && l.length <= 0 {
+			return fmt.Errorf("kolom '%s': panjang FIXED_LEN_BYTE_ARRAY tidak valid", name)
+		}
+		f.columns = append(f.columns, col)
+		f.leaves = append(f.leaves, l)
+	}
+	return nil
+}
+
+// Columns mengembalikan kolom file dengan tipe tangki yang sesuai.
+func (f *File) Columns() []tangki.Column {
+	return f.columns
+}
+
+// NumRows mengembalikan jumlah baris di file.
+func (f *File) NumRows() int64 {
+	return f.rows
+}
+
+// Scan memanggil fn untuk setiap baris sampai fn mengembalikan false.
+// Satu row group dibaca utuh ke memori sebelum barisnya diberikan. Baris
+// yang diberikan ke fn boleh disimpan.
+func (f *File) Scan(fn func(row tangki.Row) bool) error {
+	for g, group := range f.groups {
+		n, _ := group.int(3)
+		columns := make([][]interface{}, len(f.leaves))
+		for i, c := range group.list(1) {
+			chunk, _ := c.(thriftFields)
+			values, err := f.readChunk(f.leaves[i], chunk, int(n))
+			if err != nil {
+				return fmt.Errorf("row group %d, kolom '%s': %v", g, f.columns[i].Name, err)
+			}
+			columns[i] = values
+		}
+		for r := 0; r < int(n); r++ {
+			row := make(tangki.Row, len(columns))
+			for i := range columns {
+				row[i] = columns[i][r]
+			}
+			if !fn(row) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// readChunk membaca semua nilai satu column chunk; NULL menjadi nil.
+func (f *File) readChunk(l leaf, chunk thriftFields, rows int) ([]interface{}, error) {
+	if chunk.bytes(1) != nil {
+		return nil, fmt.Errorf("column chunk di file lain belum didukung")
+	}
+	meta := chunk.child(3)
+	if meta == nil {
+		return nil, fmt.Errorf("metadata column chunk tidak ada")
+	}
+	codec, _ := meta.int(4)
+	total, _ := meta.int(5)
+	start, _ := meta.int(9)
+	if dict, ok := meta.int(11); ok && dict > 0 && dict < start {
+		start = dict
+	}
+	length, _ := meta.int(7)
+	if total != int64(rows) {
+		return nil, fmt.Errorf("jumlah nilai %d tidak sama dengan jumlah baris %d", total, rows)
+	}
+	if start < 0 || length < 0 || start+length > f.size {
+		return nil, fmt.Errorf("posisi column chunk di luar file")
+	}
+	data := make([]byte, length)
+	if _, err := f.r.ReadAt(data, start); err != nil {
+		return nil, err
+	}
+
+	out := make([]interface{}, 0, rows)
+	var dict []interface{}
+	pos := 0
+	for len(out) < rows {
+		if pos >= len(data) {
+			return nil, fmt.Errorf("halaman data kurang: %d dari %d nilai", len(out), rows)
+		}
+		tr := &thriftReader{data: data[pos:]}
+		header, err := tr.readStruct()
+		if err != nil {
+			return nil, fmt.Errorf("header halaman rusak: %v", err)
+		}
+		pos += tr.pos
+		size, _ := header.int(3)
+		usize, _ := header.int(2)
+		if size < 0 || size > int64(len(data)-pos) {
+			return nil, fmt.Errorf("halaman melewati akhir column chunk")
+		}
+		page := data[pos : pos+int(size)]
+		pos += int(size)
+
+		pageType, _ := header.int(1)
+		switch pageType {
+		case pageDictionary:
+			h := header.child(7)
+			n, _ := h.int(1)
+			raw, err := decompress(codec, page, usize)
+			if err != nil {
+				return nil, err
+			}
+			if dict, err = l.plain(raw, int(n)); err != nil {
+				return nil, fmt.Errorf("dictionary: %v", err)
+			}
+		case pageData:
+			h := header.child(5)
+			n, _ := h.int(1)
+			enc, _ := h.int(2)
+			raw, err := decompress(codec, page, usize)
+			if err != nil {
+				return nil, err
+			}
+			defs, raw, err := l.levels(raw, int(n), -1)
+			if err != nil {
+				return nil, err
+			}
+			if out, err = l.page(out, raw, defs, int(n), enc, dict); err != nil {
+				return nil, err
+			}
+		case pageDataV2:
+			h := header.child(8)
+			n, _ := h.int(1)
+			enc, _ := h.int(4)
+			defLen, _ := h.int(5)
+			repLen, _ := h.int(6)
+			if defLen < 0 || repLen != 0 || defLen > int64(len(page)) {
+				return nil, fmt.Errorf("header halaman v2 tidak valid")
+			}
+			defs, _, err := l.levels(page[:defLen], int(n), int(defLen))
+			if err != nil {
+				return nil, err
+			}
+			raw := page[defLen:]
+			if compressed, ok := h[7].(bool); !ok || compressed {
+				if raw, err = decompress(codec, raw, usize-defLen); err != nil {
+					return nil, err
+				}
+			}
+			if out, err = l.page(out, raw, defs, int(n), enc, dict); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(out) != rows {
+		return nil, fmt.Errorf("jumlah nilai di halaman tidak sama dengan jumlah baris")
+	}
+	return out, nil
+}
+
+func decompress(codec int64, data []byte, size int64) ([]byte, error) {
+	var out []byte
+	switch codec {
+	case int64(Uncompressed):
+		return data, nil
+	case int64(Snappy):
+		var err error
+		if out, err = snappyDecode(data); err != nil {
+			return nil, err
+		}
+	case int64(Gzip):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if out, err = io.ReadAll(io.LimitReader(zr, size+1)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("kompresi %d tidak didukung", codec)
+	}
+	if int64(len(out)) != size {
+		return nil, fmt.Errorf("ukuran halaman setelah dekompresi %d, seharusnya %d", len(out), size)
+	}
+	return out, nil
+}
+
+// levels membaca definition level n nilai. Pada halaman v1 levels diawali
+// panjang 4 byte (length -1); pada halaman v2 panjangnya ada di header.
+// Kolom REQUIRED tidak punya level dan hasilnya nil.
+func (l leaf) levels(data []byte, n, length int) ([]uint32, []byte, error) {
+	if !l.optional {
+		return nil, data, nil
+	}
+	if length < 0 {
+		if len(data) < 4 {
+			return nil, nil, fmt.Errorf("definition level terpotong")
+		}
+		length = int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+		if length > len(data) {
+			return nil, nil, fmt.Errorf("definition level terpotong")
+		}
+	}
+	defs := make([]uint32, n)
+	if err := rleDecode(data[:length], 1, defs); err != nil {
+		return nil, nil, fmt.Errorf("definition level: %v", err)
+	}
+	return defs, data[length:], nil
+}
+
+// page menambahkan n nilai satu halaman data ke out.
+func (l leaf) page(out []interface{}, data []byte, defs []uint32, n int, enc int64, dict []interface{}) ([]interface{}, error) {
+	count := n
+	if defs != nil {
+		count = 0
+		for _, d := range defs {
+			count += int(d)
+		}
+	}
+
+	var values []interface{}
+	var err error
+	switch enc {
+	case encPlain:
+		values, err = l.plain(data, count)
+	case encPlainDict, encRLEDict:
+		if dict == nil {
+			return nil, fmt.Errorf("halaman dictionary tidak ditemukan")
+		}
+		if len(data) < 1 {
+			return nil, fmt.Errorf("indeks dictionary terpotong")
+		}
+		indices := make([]uint32, count)
+		if err = rleDecode(data[1:], int(data[0]), indices); err != nil {
+			return nil, err
+		}
+		values = make([]interface{}, count)
+		for i, idx := range indices {
+			if int(idx) >= len(dict) {
+				return nil, fmt.Errorf("indeks dictionary %d di luar batas", idx)
+			}
+			values[i] = dict[idx]
+		}
+	case encRLE:
+		if l.typ != typeBoolean || len(data) < 4 {
+			return nil, fmt.Errorf("encoding RLE untuk tipe %d tidak didukung", l.typ)
+		}
+		bits := make([]uint32, count)
+		if err = rleDecode(data[4:], 1, bits); err != nil {
+			return nil, err
+		}
+		values = make([]interface{}, count)
+		for i, b := range bits {
+			values[i] = int(b)
+		}
+	default:
+		return nil, fmt.Errorf("encoding %d tidak didukung", enc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if defs == nil {
+		return append(out, values...), nil
+	}
+	k := 0
+	for _, d := range defs {
+		if d == 0 {
+			out = append(out, nil)
+			continue
+		}
+		out = append(out, values[k])
+		k++
+	}
+	return out, nil
+}
+
+var errPlain = fmt.Errorf("nilai PLAIN terpotong")
+
+// plain membaca n nilai berencoding PLAIN.
+func (l leaf) plain(data []byte, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	pos := 0
+	fixed := func(size int) ([]byte, error) {
+		if size > len(data)-pos {
+			return nil, errPlain
+		}
+		b := data[pos : pos+size]
+		pos += size
+		return b, nil
+	}
+
+	for i := range values {
+		switch l.typ {
+		case typeBoolean:
+			if i/8 >= len(data) {
+				return nil, errPlain
+			}
+			values[i] = int(data[i/8] >> (i % 8) & 1)
+		case typeInt32:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = l.integer(int64(int32(binary.LittleEndian.Uint32(b))))
+		case typeInt64:
+			b, err := fixed(8)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = l.integer(int64(binary.LittleEndian.Uint64(b)))
+		case typeFloat:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case typeDouble:
+			b, err := fixed(8)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case typeByteArray, typeFixed:
+			size := l.length
+			if l.typ == typeByteArray {
+				b, err := fixed(4)
+				if err != nil {
+					return nil, err
+				}
+				size = int(binary.LittleEndian.Uint32(b))
+			}
+			b, err := fixed(size)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = l.bytes(b)
+		}
+	}
+	return values, nil
+}
+
+func (l leaf) integer(v int64) interface{} {
+	if l.scale >= 0 {
+		return float64(v) / math.Pow10(l.scale)
+	}
+	return int(v)
+}
+
+// bytes mengubah BYTE_ARRAY menjadi TEKS, atau DECIMAL (bilangan
+// komplemen dua big-endian) menjadi FLOAT.
+func (l leaf) bytes(b []byte) interface{} {
+	if l.scale < 0 {
+		return string(b)
+	}
+	v := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(v), new(big.Float).SetFloat64(math.Pow10(l.scale))).Float64()
+	return f
+}
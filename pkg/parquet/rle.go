@@ -0,0 +1,135 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Encoding hybrid RLE/bit-packing dipakai untuk definition level dan
+// indeks dictionary. Run dengan nilai sama minimal 8 ditulis sebagai RLE,
+// sisanya sebagai grup bit-packed berisi 8 nilai.
+
+func bitWidth(max uint64) int {
+	if max == 0 {
+		return 1
+	}
+	return bits.Len64(max)
+}
+
+func rleEncode(dst []byte, values []uint32, width int) []byte {
+	runLen := func(i int) int {
+		j := i + 1
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+		return j - i
+	}
+
+	for i := 0; i < len(values); {
+		if n := runLen(i); n >= 8 {
+			dst = binary.AppendUvarint(dst, uint64(n)<<1)
+			for b, v := 0, values[i]; b < (width+7)/8; b++ {
+				dst = append(dst, byte(v>>(8*b)))
+			}
+			i += n
+			continue
+		}
+
+		// Grup bit-packed hanya boleh tidak penuh di akhir data
+		start := i
+		for i < len(values) {
+			if i += 8; i > len(values) {
+				i = len(values)
+			}
+			if i < len(values) && runLen(i) >= 8 {
+				break
+			}
+		}
+		groups := (i - start + 7) / 8
+		dst = binary.AppendUvarint(dst, uint64(groups)<<1|1)
+		dst = bitPack(dst, values[start:i], groups*8, width)
+	}
+	return dst
+}
+
+// bitPack menulis n nilai (sisa setelah values diisi nol) dengan lebar
+// width bit, bit terendah lebih dulu.
+func bitPack(dst []byte, values []uint32, n, width int) []byte {
+	var acc uint64
+	var used int
+	for k := 0; k < n; k++ {
+		var v uint32
+		if k < len(values) {
+			v = values[k]
+		}
+		acc |= uint64(v) << used
+		used += width
+		for used >= 8 {
+			dst = append(dst, byte(acc))
+			acc >>= 8
+			used -= 8
+		}
+	}
+	if used > 0 {
+		dst = append(dst, byte(acc))
+	}
+	return dst
+}
+
+var errRLE = fmt.Errorf("data RLE rusak")
+
+// rleDecode membaca tepat len(out) nilai.
+func rleDecode(data []byte, width int, out []uint32) error {
+	if width < 0 || width > 32 {
+		return fmt.Errorf("lebar bit %d tidak valid", width)
+	}
+	mask := uint64(1)<<width - 1
+	pos := 0
+	for i := 0; i < len(out); {
+		header, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return errRLE
+		}
+		pos += n
+		if header&1 == 0 {
+			count := header >> 1
+			size := (width + 7) / 8
+			if pos+size > len(data) || count == 0 {
+				return errRLE
+			}
+			var v uint32
+			for b := 0; b < size; b++ {
+				v |= uint32(data[pos+b]) << (8 * b)
+			}
+			pos += size
+			for ; count > 0 && i < len(out); count-- {
+				out[i] = v
+				i++
+			}
+			continue
+		}
+
+		count := int(header>>1) * 8
+		size := int(header>>1) * width
+		if count == 0 || size > len(data)-pos {
+			return errRLE
+		}
+		var acc uint64
+		var have int
+		p := pos
+		for k := 0; k < count && i < len(out); k++ {
+			for have < width {
+				acc |= uint64(data[p]) << have
+				p++
+				have += 8
+			}
+			out[i] = uint32(acc & mask)
+			acc >>= width
+			have -= width
+			i++
+		}
+		pos += size
+	}
+	return nil
+}
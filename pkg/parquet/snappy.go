@@ -0,0 +1,147 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Parquet memakai blok snappy mentah (tanpa framing). Encoder di bawah
+// sederhana: mencari pasangan 4 byte lewat tabel hash dan tidak mencoba
+// sekeras pustaka snappy asli, tetapi hasilnya bisa dibaca decoder mana
+// pun.
+
+const snappyTableBits = 14
+
+func snappyEncode(dst, src []byte) []byte {
+	dst = binary.AppendUvarint(dst[:0], uint64(len(src)))
+	if len(src) < 8 {
+		return snappyLiteral(dst, src)
+	}
+
+	var table [1 << snappyTableBits]int32
+	lit := 0
+	for i := 0; i+4 <= len(src); {
+		cur := binary.LittleEndian.Uint32(src[i:])
+		h := (cur * 0x1e35a7bd) >> (32 - snappyTableBits)
+		cand := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if cand < 0 || i-cand > 0xffff || binary.LittleEndian.Uint32(src[cand:]) != cur {
+			i++
+			continue
+		}
+		dst = snappyLiteral(dst, src[lit:i])
+		n := 4
+		for i+n < len(src) && src[cand+n] == src[i+n] {
+			n++
+		}
+		dst = snappyCopy(dst, i-cand, n)
+		i += n
+		lit = i
+	}
+	return snappyLiteral(dst, src[lit:])
+}
+
+func snappyLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := uint32(len(lit) - 1)
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2)
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(dst, lit...)
+}
+
+// snappyCopy menulis salinan dengan offset 2 byte; panjang tiap elemen
+// paling banyak 64.
+func snappyCopy(dst []byte, offset, n int) []byte {
+	for n >= 68 {
+		dst = append(dst, 63<<2|2, byte(offset), byte(offset>>8))
+		n -= 64
+	}
+	if n > 64 {
+		dst = append(dst, 59<<2|2, byte(offset), byte(offset>>8))
+		n -= 60
+	}
+	return append(dst, byte(n-1)<<2|2, byte(offset), byte(offset>>8))
+}
+
+var errSnappy = fmt.Errorf("data snappy rusak")
+
+func snappyDecode(src []byte) ([]byte, error) {
+	size, n := binary.Uvarint(src)
+	if n <= 0 || size > 1<<31 {
+		return nil, errSnappy
+	}
+	src = src[n:]
+	dst := make([]byte, 0, size)
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+		switch tag & 3 {
+		case 0:
+			length = int(tag>>2) + 1
+			src = src[1:]
+			if length > 60 {
+				extra := length - 60
+				if len(src) < extra {
+					return nil, errSnappy
+				}
+				length = 0
+				for i := extra - 1; i >= 0; i-- {
+					length = length<<8 | int(src[i])
+				}
+				length++
+				src = src[extra:]
+			}
+			if length > len(src) || uint64(len(dst)+length) > size {
+				return nil, errSnappy
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case 1:
+			if len(src) < 2 {
+				return nil, errSnappy
+			}
+			length = 4 + int(tag>>2&7)
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+		case 2:
+			if len(src) < 3 {
+				return nil, errSnappy
+			}
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 3:
+			if len(src) < 5 {
+				return nil, errSnappy
+			}
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst) || uint64(len(dst)+length) > size {
+			return nil, errSnappy
+		}
+		// Salinan boleh tumpang tindih, jadi disalin per byte
+		start := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+	if uint64(len(dst)) != size {
+		return nil, errSnappy
+	}
+	return dst, nil
+}
@@ -0,0 +1,468 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+const (
+	// DefaultRowGroupSize adalah jumlah baris per row group bawaan.
+	DefaultRowGroupSize = 64 * 1024
+	// pageRows adalah jumlah baris per halaman data.
+	pageRows = 8192
+)
+
+// WriterOptions mengatur Writer.
+type WriterOptions struct {
+	// Compression adalah codec untuk semua halaman; bawaannya Uncompressed.
+	Compression Compression
+	// RowGroupSize adalah jumlah baris yang ditampung sebelum satu row
+	// group ditulis; nol berarti DefaultRowGroupSize.
+	RowGroupSize int
+}
+
+// Writer menulis baris ke file Parquet. Baris ditampung per row group,
+// jadi memori yang dipakai sebanding dengan RowGroupSize, bukan dengan
+// jumlah seluruh baris. Footer baru ditulis oleh Close.
+type Writer struct {
+	w       io.Writer
+	opts    WriterOptions
+	columns []tangki.Column
+	types   []int32
+	chunks  []*columnChunk
+	buffer  int
+	offset  int64
+	groups  []rowGroupMeta
+	rows    int64
+	err     error
+}
+
+// columnChunk menampung nilai satu kolom untuk row group yang sedang diisi.
+// defs berisi 1 untuk nilai dan 0 untuk NULL.
+type columnChunk struct {
+	defs   []uint32
+	ints   []int64
+	floats []float64
+	texts  []string
+}
+
+type rowGroupMeta struct {
+	offset int64
+	rows   int64
+	size   int64
+	chunks []chunkMeta
+}
+
+type chunkMeta struct {
+	typ          int32
+	encodings    []int32
+	values       int64
+	uncompressed int64
+	compressed   int64
+	dataOffset   int64
+	dictOffset   int64 // nol bila tanpa dictionary
+	nulls        int64
+	min, max     []byte
+}
+
+// NewWriter membuat Writer untuk kolom columns. Kolom dengan tipe yang
+// belum didukung ditolak di sini.
+func NewWriter(w io.Writer, columns []tangki.Column, opts WriterOptions) (*Writer, error) {
+	if opts.RowGroupSize <= 0 {
+		opts.RowGroupSize = DefaultRowGroupSize
+	}
+	switch opts.Compression {
+	case Uncompressed, Snappy, Gzip:
+	default:
+		return nil, fmt.Errorf("kompresi %d tidak didukung", opts.Compression)
+	}
+	pw := &Writer{w: w, opts: opts, columns: columns}
+	for _, col := range columns {
+		typ, err := physicalType(col.Type)
+		if err != nil {
+			return nil, fmt.Errorf("kolom '%s': %v", col.Name, err)
+		}
+		pw.types = append(pw.types, typ)
+		pw.chunks = append(pw.chunks, &columnChunk{})
+	}
+	return pw, nil
+}
+
+// Write menambahkan satu baris. Nilai disesuaikan dengan tipe kolom
+// seperti AddRow; nil ditulis sebagai NULL.
+func (pw *Writer) Write(row tangki.Row) error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("jumlah nilai %d, seharusnya %d", len(row), len(pw.columns))
+	}
+	for i, val := range row {
+		if err := pw.chunks[i].add(pw.columns[i].Type, val); err != nil {
+			// Kolom sebelumnya sudah terisi, jadi baris ini dibatalkan
+			for k, c := range pw.chunks[:i] {
+				c.drop(pw.columns[k].Type)
+			}
+			return fmt.Errorf("kolom '%s': %v", pw.columns[i].Name, err)
+		}
+	}
+	if pw.buffer++; pw.buffer >= pw.opts.RowGroupSize {
+		return pw.flush()
+	}
+	return nil
+}
+
+func (c *columnChunk) add(colType string, val interface{}) error {
+	if val == nil {
+		c.defs = append(c.defs, 0)
+		return nil
+	}
+	switch colType {
+	case "INT":
+		v, err := tangki.ToInt64(val)
+		if err != nil {
+			return err
+		}
+		c.ints = append(c.ints, v)
+	case "FLOAT":
+		v, err := tangki.ToFloat64(val)
+		if err != nil {
+			return err
+		}
+		c.floats = append(c.floats, v)
+	default:
+		c.texts = append(c.texts, tangki.ToText(val))
+	}
+	c.defs = append(c.defs, 1)
+	return nil
+}
+
+// drop membuang nilai terakhir yang ditambahkan.
+func (c *columnChunk) drop(colType string) {
+	last := len(c.defs) - 1
+	if c.defs[last] == 1 {
+		switch colType {
+		case "INT":
+			c.ints = c.ints[:len(c.ints)-1]
+		case "FLOAT":
+			c.floats = c.floats[:len(c.floats)-1]
+		default:
+			c.texts = c.texts[:len(c.texts)-1]
+		}
+	}
+	c.defs = c.defs[:last]
+}
+
+func (pw *Writer) write(b []byte) error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if pw.offset == 0 {
+		if _, pw.err = io.WriteString(pw.w, magic); pw.err != nil {
+			return pw.err
+		}
+		pw.offset = int64(len(magic))
+	}
+	_, pw.err = pw.w.Write(b)
+	pw.offset += int64(len(b))
+	return pw.err
+}
+
+// flush menulis row group yang sedang ditampung.
+func (pw *Writer) flush() error {
+	if pw.buffer == 0 {
+		return nil
+	}
+	if err := pw.write(nil); err != nil {
+		return err
+	}
+	group := rowGroupMeta{offset: pw.offset, rows: int64(pw.buffer)}
+	for i, c := range pw.chunks {
+		meta, err := pw.writeChunk(pw.types[i], c)
+		if err != nil {
+			return err
+		}
+		group.size += meta.uncompressed
+		group.chunks = append(group.chunks, meta)
+		pw.chunks[i] = &columnChunk{}
+	}
+	pw.groups = append(pw.groups, group)
+	pw.rows += group.rows
+	pw.buffer = 0
+	return nil
+}
+
+func (pw *Writer) writeChunk(typ int32, c *columnChunk) (chunkMeta, error) {
+	meta := chunkMeta{typ: typ, values: int64(len(c.defs))}
+	for _, d := range c.defs {
+		if d == 0 {
+			meta.nulls++
+		}
+	}
+	c.stats(&meta)
+
+	// Teks yang banyak berulang ditulis dengan dictionary
+	var dict map[string]uint32
+	var dictValues []string
+	if typ == typeByteArray && len(c.texts) > 0 {
+		dict = map[string]uint32{}
+		for _, s := range c.texts {
+			if _, ok := dict[s]; !ok {
+				dict[s] = uint32(len(dictValues))
+				dictValues = append(dictValues, s)
+			}
+			if len(dictValues) > len(c.texts)/2 || len(dictValues) > math.MaxUint16 {
+				dict, dictValues = nil, nil
+				break
+			}
+		}
+	}
+
+	valueEncoding := int32(encPlain)
+	if dict != nil {
+		var plain []byte
+		for _, s := range dictValues {
+			plain = appendByteArray(plain, s)
+		}
+		meta.dictOffset = pw.offset
+		if err := pw.writePage(&meta, plain, func(t *thriftWriter) {
+			t.begin(7)
+			t.i32(1, int32(len(dictValues)))
+			t.i32(2, encPlain)
+			t.end()
+		}, pageDictionary); err != nil {
+			return meta, err
+		}
+		valueEncoding = encRLEDict
+		meta.encodings = append(meta.encodings, encPlain)
+	}
+	meta.encodings = append(meta.encodings, encRLE, valueEncoding)
+
+	meta.dataOffset = pw.offset
+	values := 0
+	for from := 0; from < len(c.defs); from += pageRows {
+		to := min(from+pageRows, len(c.defs))
+		defs := c.defs[from:to]
+		count := 0
+		for _, d := range defs {
+			count += int(d)
+		}
+
+		levels := rleEncode(nil, defs, 1)
+		body := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+		body = append(body, levels...)
+		switch {
+		case dict != nil:
+			indices := make([]uint32, count)
+			for k, s := range c.texts[values : values+count] {
+				indices[k] = dict[s]
+			}
+			width := bitWidth(uint64(len(dictValues) - 1))
+			body = append(body, byte(width))
+			body = rleEncode(body, indices, width)
+		case typ == typeInt64:
+			for _, v := range c.ints[values : values+count] {
+				body = binary.LittleEndian.AppendUint64(body, uint64(v))
+			}
+		case typ == typeDouble:
+			for _, v := range c.floats[values : values+count] {
+				body = binary.LittleEndian.AppendUint64(body, math.Float64bits(v))
+			}
+		default:
+			for _, s := range c.texts[values : values+count] {
+				body = appendByteArray(body, s)
+			}
+		}
+		values += count
+
+		if err := pw.writePage(&meta, body, func(t *thriftWriter) {
+			t.begin(5)
+			t.i32(1, int32(len(defs)))
+			t.i32(2, valueEncoding)
+			t.i32(3, encRLE)
+			t.i32(4, encRLE)
+			t.end()
+		}, pageData); err != nil {
+			return meta, err
+		}
+	}
+	return meta, nil
+}
+
+// writePage mengompres body lalu menulis header dan isinya. header
+// menulis field khusus jenis halaman.
+func (pw *Writer) writePage(meta *chunkMeta, body []byte, header func(*thriftWriter), pageType int32) error {
+	data, err := compress(pw.opts.Compression, body)
+	if err != nil {
+		return err
+	}
+	t := &thriftWriter{}
+	t.begin(0)
+	t.i32(1, pageType)
+	t.i32(2, int32(len(body)))
+	t.i32(3, int32(len(data)))
+	header(t)
+	t.end()
+
+	meta.uncompressed += int64(len(t.buf) + len(body))
+	meta.compressed += int64(len(t.buf) + len(data))
+	if err := pw.write(t.buf); err != nil {
+		return err
+	}
+	return pw.write(data)
+}
+
+func compress(codec Compression, body []byte) ([]byte, error) {
+	switch codec {
+	case Snappy:
+		return snappyEncode(nil, body), nil
+	case Gzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return body, nil
+}
+
+func appendByteArray(b []byte, s string) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// stats mengisi nilai terkecil dan terbesar kolom INT dan FLOAT. NaN
+// tidak dihitung.
+func (c *columnChunk) stats(meta *chunkMeta) {
+	switch {
+	case len(c.ints) > 0:
+		lo, hi := c.ints[0], c.ints[0]
+		for _, v := range c.ints {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		meta.min = binary.LittleEndian.AppendUint64(nil, uint64(lo))
+		meta.max = binary.LittleEndian.AppendUint64(nil, uint64(hi))
+	case len(c.floats) > 0:
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, v := range c.floats {
+			if !math.IsNaN(v) {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+		}
+		if lo <= hi {
+			meta.min = binary.LittleEndian.AppendUint64(nil, math.Float64bits(lo))
+			meta.max = binary.LittleEndian.AppendUint64(nil, math.Float64bits(hi))
+		}
+	}
+}
+
+// Close menulis row group terakhir dan footer. Close tidak menutup writer
+// di bawahnya.
+func (pw *Writer) Close() error {
+	if err := pw.flush(); err != nil {
+		return err
+	}
+	footer := pw.footer()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, magic...)
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+	pw.err = fmt.Errorf("parquet writer sudah ditutup")
+	return nil
+}
+
+// footer menulis FileMetaData.
+func (pw *Writer) footer() []byte {
+	t := &thriftWriter{}
+	t.begin(0)
+	t.i32(1, fileFormatVersion)
+
+	t.list(2, thriftStruct, len(pw.columns)+1)
+	t.begin(0)
+	t.string(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.end()
+	for i, col := range pw.columns {
+		t.begin(0)
+		t.i32(1, pw.types[i])
+		t.i32(3, repetitionOpt)
+		t.string(4, col.Name)
+		if pw.types[i] == typeByteArray {
+			t.i32(6, convertedUTF8)
+			t.begin(10)
+			t.begin(logicalString)
+			t.end()
+			t.end()
+		}
+		t.end()
+	}
+
+	t.i64(3, pw.rows)
+	t.list(4, thriftStruct, len(pw.groups))
+	for _, g := range pw.groups {
+		t.begin(0)
+		t.list(1, thriftStruct, len(g.chunks))
+		var compressed int64
+		for i, c := range g.chunks {
+			compressed += c.compressed
+			t.begin(0)
+			if c.dictOffset > 0 {
+				t.i64(2, c.dictOffset)
+			} else {
+				t.i64(2, c.dataOffset)
+			}
+			t.begin(3)
+			t.i32(1, c.typ)
+			t.list(2, thriftI32, len(c.encodings))
+			for _, enc := range c.encodings {
+				t.zigzag(int64(enc))
+			}
+			t.list(3, thriftBinary, 1)
+			t.varint(uint64(len(pw.columns[i].Name)))
+			t.buf = append(t.buf, pw.columns[i].Name...)
+			t.i32(4, int32(pw.opts.Compression))
+			t.i64(5, c.values)
+			t.i64(6, c.uncompressed)
+			t.i64(7, c.compressed)
+			t.i64(9, c.dataOffset)
+			if c.dictOffset > 0 {
+				t.i64(11, c.dictOffset)
+			}
+			t.begin(12)
+			t.i64(3, c.nulls)
+			if c.min != nil {
+				t.binary(5, c.max)
+				t.binary(6, c.min)
+			}
+			t.end()
+			t.end()
+			t.end()
+		}
+		t.i64(2, g.size)
+		t.i64(3, g.rows)
+		t.i64(5, g.offset)
+		t.i64(6, compressed)
+		t.end()
+	}
+	t.string(6, "BensinDB")
+
+	// Urutan kolom TYPE_ORDER agar min_value/max_value dipakai pembaca
+	t.list(7, thriftStruct, len(pw.columns))
+	for range pw.columns {
+		t.begin(0)
+		t.begin(1)
+		t.end()
+		t.end()
+	}
+	t.end()
+	return t.buf
+}
@@ -0,0 +1,291 @@
+package parser
+
+// Expr is a node in an FQL expression tree
+type Expr interface {
+	exprNode()
+}
+
+// ColumnRef represents kolom or tangki.kolom
+type ColumnRef struct {
+	Table  string
+	Column string
+}
+
+// Literal represents a constant value (nil means NULL)
+type Literal struct {
+	Value interface{}
+}
+
+// BinaryExpr represents comparisons, arithmetic, DAN and ATAU
+type BinaryExpr struct {
+	Left     Expr
+	Operator string
+	Right    Expr
+}
+
+// SubqueryExpr represents a scalar subquery (PILIH ...)
+type SubqueryExpr struct {
+	Select *SelectStmt
+}
+
+// InExpr represents expr [TIDAK] DI DALAM (PILIH ...) or (nilai, ...)
+type InExpr struct {
+	Expr     Expr
+	Not      bool
+	Subquery *SelectStmt
+	List     []Expr
+}
+
+// ExistsExpr represents [TIDAK] ADA (PILIH ...)
+type ExistsExpr struct {
+	Not      bool
+	Subquery *SelectStmt
+}
+
+// WindowExpr represents fungsi(...) OVER (PARTISI BERDASARKAN ... URUTKAN BERDASARKAN ...)
+type WindowExpr struct {
+	Func        string
+	Args        []Expr
+	PartitionBy []Expr
+	OrderBy     []OrderItem
+}
+
+// OrderItem is one URUTKAN BERDASARKAN key
+type OrderItem struct {
+	Expr Expr
+	Desc bool
+}
+
+func (*ColumnRef) exprNode()    {}
+func (*Literal) exprNode()      {}
+func (*BinaryExpr) exprNode()   {}
+func (*SubqueryExpr) exprNode() {}
+func (*InExpr) exprNode()       {}
+func (*ExistsExpr) exprNode()   {}
+func (*WindowExpr) exprNode()   {}
+
+// SelectStmt represents [DENGAN ...] PILIH ... DARI ... [GABUNG ...] [DIMANA ...]
+// followed by optional SATUKAN/IRISAN/KECUALI clauses
+type SelectStmt struct {
+	With   []*CTE
+	Items  []SelectItem
+	From   *TableRef
+	Joins  []*JoinClause
+	Where  Expr
+	SetOps []*SetOpClause
+	Limit  *int // BATAS n, nil bila tidak dibatasi
+}
+
+// CTE represents one nama [(kolom, ...)] SEBAGAI (PILIH ...) in DENGAN.
+// A recursive CTE may refer to itself in the set operations after its
+// first (anchor) PILIH
+type CTE struct {
+	Name      string
+	Columns   []string
+	Recursive bool
+	Select    *SelectStmt
+}
+
+// SetOpClause represents SATUKAN [SEMUA] PILIH ..., IRISAN ... or KECUALI ...
+// Set operations are evaluated left to right; unlike standard SQL, IRISAN
+// does not bind tighter than SATUKAN and KECUALI
+type SetOpClause struct {
+	Operation string // "UNION", "INTERSECT", "EXCEPT"
+	All       bool
+	Select    *SelectStmt
+}
+
+// SelectItem is one projected expression. Star is true for * or tangki.*
+type SelectItem struct {
+	Expr  Expr
+	Alias string
+	Star  bool
+	Table string
+}
+
+// TableRef represents a tangki in DARI or GABUNG with an optional alias
+type TableRef struct {
+	Name  string
+	Alias string
+
+	implicit bool // alias ditulis tanpa SEBAGAI
+}
+
+// RefName returns the name used to qualify columns of this tangki
+func (t *TableRef) RefName() string {
+	if t.Alias != "" {
+		return t.Alias
+	}
+	return t.Name
+}
+
+// JoinClause represents GABUNG [KIRI|KANAN|PENUH|SILANG] tangki PADA kondisi
+type JoinClause struct {
+	Type  string
+	Table *TableRef
+	On    Expr
+}
+
+// Tables returns every tangki name referenced by the statement,
+// including those inside subqueries and set operations
+func (s *SelectStmt) Tables() []string {
+	var names []string
+	var visitExpr func(Expr)
+	visitExpr = func(expr Expr) {
+		switch e := expr.(type) {
+		case *BinaryExpr:
+			visitExpr(e.Left)
+			visitExpr(e.Right)
+		case *SubqueryExpr:
+			names = append(names, e.Select.Tables()...)
+		case *InExpr:
+			visitExpr(e.Expr)
+			for _, item := range e.List {
+				visitExpr(item)
+			}
+			if e.Subquery != nil {
+				names = append(names, e.Subquery.Tables()...)
+			}
+		case *ExistsExpr:
+			names = append(names, e.Subquery.Tables()...)
+		case *WindowExpr:
+			for _, arg := range e.Args {
+				visitExpr(arg)
+			}
+		}
+	}
+
+	for _, cte := range s.With {
+		names = append(names, cte.Select.Tables()...)
+	}
+	names = append(names, s.From.Name)
+	for _, join := range s.Joins {
+		names = append(names, join.Table.Name)
+		visitExpr(join.On)
+	}
+	for _, item := range s.Items {
+		visitExpr(item.Expr)
+	}
+	visitExpr(s.Where)
+	for _, clause := range s.SetOps {
+		names = append(names, clause.Select.Tables()...)
+	}
+	return names
+}
+
+// tableRefs returns the tangki in DARI and GABUNG of this select only
+func (s *SelectStmt) tableRefs() []*TableRef {
+	refs := []*TableRef{s.From}
+	for _, join := range s.Joins {
+		refs = append(refs, join.Table)
+	}
+	return refs
+}
+
+// walk calls onSelect for s and every nested select (CTEs, subqueries,
+// set operations) and onRef for every column reference
+func (s *SelectStmt) walk(onSelect func(*SelectStmt), onRef func(*ColumnRef)) {
+	var visitExpr func(Expr)
+	visitExpr = func(expr Expr) {
+		switch e := expr.(type) {
+		case *ColumnRef:
+			onRef(e)
+		case *BinaryExpr:
+			visitExpr(e.Left)
+			visitExpr(e.Right)
+		case *SubqueryExpr:
+			e.Select.walk(onSelect, onRef)
+		case *InExpr:
+			visitExpr(e.Expr)
+			for _, item := range e.List {
+				visitExpr(item)
+			}
+			if e.Subquery != nil {
+				e.Subquery.walk(onSelect, onRef)
+			}
+		case *ExistsExpr:
+			e.Subquery.walk(onSelect, onRef)
+		case *WindowExpr:
+			for _, arg := range e.Args {
+				visitExpr(arg)
+			}
+			for _, part := range e.PartitionBy {
+				visitExpr(part)
+			}
+			for _, item := range e.OrderBy {
+				visitExpr(item.Expr)
+			}
+		}
+	}
+
+	onSelect(s)
+	for _, cte := range s.With {
+		cte.Select.walk(onSelect, onRef)
+	}
+	for _, item := range s.Items {
+		if item.Star && item.Table != "" {
+			onRef(&ColumnRef{Table: item.Table, Column: "*"})
+		}
+		visitExpr(item.Expr)
+	}
+	for _, join := range s.Joins {
+		visitExpr(join.On)
+	}
+	visitExpr(s.Where)
+	for _, clause := range s.SetOps {
+		clause.Select.walk(onSelect, onRef)
+	}
+}
+
+// HasSubquery reports whether any expression in the statement contains a subquery
+func (s *SelectStmt) HasSubquery() bool {
+	var found bool
+	var visitExpr func(Expr)
+	visitExpr = func(expr Expr) {
+		switch e := expr.(type) {
+		case *BinaryExpr:
+			visitExpr(e.Left)
+			visitExpr(e.Right)
+		case *SubqueryExpr, *ExistsExpr:
+			found = true
+		case *InExpr:
+			if e.Subquery != nil {
+				found = true
+			}
+			visitExpr(e.Expr)
+		case *WindowExpr:
+			for _, arg := range e.Args {
+				visitExpr(arg)
+			}
+		}
+	}
+
+	for _, item := range s.Items {
+		visitExpr(item.Expr)
+	}
+	for _, join := range s.Joins {
+		visitExpr(join.On)
+	}
+	visitExpr(s.Where)
+	return found
+}
+
+// HasWindow reports whether the select list contains a window function
+func (s *SelectStmt) HasWindow() bool {
+	var found bool
+	var visitExpr func(Expr)
+	visitExpr = func(expr Expr) {
+		switch e := expr.(type) {
+		case *BinaryExpr:
+			visitExpr(e.Left)
+			visitExpr(e.Right)
+		case *WindowExpr:
+			found = true
+		}
+	}
+
+	for _, item := range s.Items {
+		visitExpr(item.Expr)
+	}
+	return found
+}
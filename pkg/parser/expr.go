@@ -0,0 +1,294 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseExpr parses an expression with precedence
+// ATAU < DAN < perbandingan < +,- < *,/
+func (p *Parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *Parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().Type == TOKEN_ATAU {
+		p.nextToken()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Left: left, Operator: "ATAU", Right: right}
+	}
+	return left, nil
+}
+
+func (p *Parser) parseAnd() (Expr, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().Type == TOKEN_DAN {
+		p.nextToken()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Left: left, Operator: "DAN", Right: right}
+	}
+	return left, nil
+}
+
+func (p *Parser) parseComparison() (Expr, error) {
+	if p.peek().Type == TOKEN_TIDAK || p.peek().Type == TOKEN_ADA {
+		return p.parseExists()
+	}
+
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().Type == TOKEN_TIDAK || p.peek().Type == TOKEN_DI {
+		return p.parseIn(left)
+	}
+	if op, ok := p.parseOperator(); ok {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpr{Left: left, Operator: op, Right: right}, nil
+	}
+	return left, nil
+}
+
+// [TIDAK] ADA (PILIH ...)
+func (p *Parser) parseExists() (Expr, error) {
+	not := false
+	if p.peek().Type == TOKEN_TIDAK {
+		p.consume(TOKEN_TIDAK)
+		not = true
+	}
+	p.consume(TOKEN_ADA)
+	p.consume(TOKEN_LPAREN)
+	sub, err := p.parseSelectStmt()
+	if err != nil {
+		return nil, err
+	}
+	p.consume(TOKEN_RPAREN)
+	return &ExistsExpr{Not: not, Subquery: sub}, nil
+}
+
+// expr [TIDAK] DI DALAM (PILIH ...) atau expr [TIDAK] DI DALAM (nilai, ...)
+func (p *Parser) parseIn(left Expr) (Expr, error) {
+	in := &InExpr{Expr: left}
+	if p.peek().Type == TOKEN_TIDAK {
+		p.consume(TOKEN_TIDAK)
+		in.Not = true
+	}
+	p.consume(TOKEN_DI)
+	p.consume(TOKEN_DALAM)
+	p.consume(TOKEN_LPAREN)
+
+	if startsSelect(p.peek().Type) {
+		sub, err := p.parseSelectStmt()
+		if err != nil {
+			return nil, err
+		}
+		in.Subquery = sub
+	} else {
+		for {
+			item, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			in.List = append(in.List, item)
+			if p.peek().Type != TOKEN_COMMA {
+				break
+			}
+			p.consume(TOKEN_COMMA)
+		}
+	}
+
+	p.consume(TOKEN_RPAREN)
+	return in, nil
+}
+
+func (p *Parser) parseAdditive() (Expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().Type == TOKEN_PLUS || p.peek().Type == TOKEN_MINUS {
+		op := p.current().Value
+		p.nextToken()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Left: left, Operator: op, Right: right}
+	}
+	return left, nil
+}
+
+func (p *Parser) parseMultiplicative() (Expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().Type == TOKEN_ASTERISK || p.peek().Type == TOKEN_DIVIDE {
+		op := p.current().Value
+		p.nextToken()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Left: left, Operator: op, Right: right}
+	}
+	return left, nil
+}
+
+func (p *Parser) parsePrimary() (Expr, error) {
+	token := p.current()
+
+	switch token.Type {
+	case TOKEN_NUMBER:
+		p.nextToken()
+		return &Literal{Value: parseNumber(token.Value)}, nil
+	case TOKEN_STRING:
+		p.nextToken()
+		return &Literal{Value: token.Value}, nil
+	case TOKEN_MINUS:
+		p.nextToken()
+		num := p.consume(TOKEN_NUMBER)
+		return &Literal{Value: parseNumber("-" + num.Value)}, nil
+	case TOKEN_LPAREN:
+		p.nextToken()
+		if startsSelect(p.peek().Type) {
+			sub, err := p.parseSelectStmt()
+			if err != nil {
+				return nil, err
+			}
+			p.consume(TOKEN_RPAREN)
+			return &SubqueryExpr{Select: sub}, nil
+		}
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		p.consume(TOKEN_RPAREN)
+		return expr, nil
+	case TOKEN_SUM, TOKEN_AVG, TOKEN_COUNT, TOKEN_MAX, TOKEN_MIN:
+		p.nextToken()
+		return p.parseWindow(token.Value)
+	case TOKEN_IDENTIFIER:
+		p.nextToken()
+		if p.peek().Type == TOKEN_LPAREN {
+			return p.parseWindow(token.Value)
+		}
+		if p.peek().Type != TOKEN_DOT {
+			return &ColumnRef{Column: token.Value}, nil
+		}
+		p.consume(TOKEN_DOT)
+		if p.peek().Type == TOKEN_ASTERISK {
+			p.consume(TOKEN_ASTERISK)
+			return &ColumnRef{Table: token.Value, Column: "*"}, nil
+		}
+		column := p.consume(TOKEN_IDENTIFIER).Value
+		return &ColumnRef{Table: token.Value, Column: column}, nil
+	}
+
+	return nil, fmt.Errorf("ekspresi tidak valid di dekat '%s'", token.Value)
+}
+
+// parseWindow parses fungsi(arg, ...) OVER ([PARTISI BERDASARKAN expr, ...]
+// [URUTKAN BERDASARKAN expr [MENAIK|MENURUN], ...]). Nama fungsi sudah dibaca.
+func (p *Parser) parseWindow(name string) (Expr, error) {
+	w := &WindowExpr{Func: strings.ToUpper(name)}
+
+	p.consume(TOKEN_LPAREN)
+	if p.peek().Type == TOKEN_ASTERISK {
+		// COUNT(*) sama dengan COUNT() tanpa argumen
+		p.consume(TOKEN_ASTERISK)
+	} else if p.peek().Type != TOKEN_RPAREN {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			w.Args = append(w.Args, arg)
+			if p.peek().Type != TOKEN_COMMA {
+				break
+			}
+			p.consume(TOKEN_COMMA)
+		}
+	}
+	p.consume(TOKEN_RPAREN)
+
+	if p.peek().Type != TOKEN_OVER {
+		return nil, fmt.Errorf("fungsi %s membutuhkan OVER (...)", w.Func)
+	}
+	p.consume(TOKEN_OVER)
+	p.consume(TOKEN_LPAREN)
+
+	if p.peek().Type == TOKEN_PARTISI {
+		p.consume(TOKEN_PARTISI)
+		p.consume(TOKEN_BERDASARKAN)
+		for {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			w.PartitionBy = append(w.PartitionBy, expr)
+			if p.peek().Type != TOKEN_COMMA {
+				break
+			}
+			p.consume(TOKEN_COMMA)
+		}
+	}
+
+	if p.peek().Type == TOKEN_URUTKAN {
+		p.consume(TOKEN_URUTKAN)
+		p.consume(TOKEN_BERDASARKAN)
+		for {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := OrderItem{Expr: expr}
+			switch p.peek().Type {
+			case TOKEN_MENURUN:
+				p.consume(TOKEN_MENURUN)
+				item.Desc = true
+			case TOKEN_MENAIK:
+				p.consume(TOKEN_MENAIK)
+			}
+			w.OrderBy = append(w.OrderBy, item)
+			if p.peek().Type != TOKEN_COMMA {
+				break
+			}
+			p.consume(TOKEN_COMMA)
+		}
+	}
+
+	p.consume(TOKEN_RPAREN)
+	return w, nil
+}
+
+// startsSelect reports whether t can start a (sub)query
+func startsSelect(t TokenType) bool {
+	return t == TOKEN_PILIH || t == TOKEN_DENGAN
+}
+
+func parseNumber(s string) interface{} {
+	if strings.Contains(s, ".") {
+		f, _ := strconv.ParseFloat(s, 64)
+		return f
+	}
+	i, _ := strconv.Atoi(s)
+	return i
+}
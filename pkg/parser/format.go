@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatExpr renders an expression back to FQL, used by JELASKAN output
+func FormatExpr(expr Expr) string {
+	switch e := expr.(type) {
+	case nil:
+		return ""
+	case *ColumnRef:
+		if e.Table != "" {
+			return e.Table + "." + e.Column
+		}
+		return e.Column
+	case *Literal:
+		switch v := e.Value.(type) {
+		case nil:
+			return "NULL"
+		case string:
+			return "'" + v + "'"
+		}
+		return fmt.Sprint(e.Value)
+	case *BinaryExpr:
+		return formatOperand(e.Left, e.Operator) + " " + e.Operator + " " + formatOperand(e.Right, e.Operator)
+	case *SubqueryExpr:
+		return "(subquery)"
+	case *InExpr:
+		not := ""
+		if e.Not {
+			not = "TIDAK "
+		}
+		if e.Subquery != nil {
+			return FormatExpr(e.Expr) + " " + not + "DI DALAM (subquery)"
+		}
+		items := make([]string, len(e.List))
+		for i, item := range e.List {
+			items[i] = FormatExpr(item)
+		}
+		return FormatExpr(e.Expr) + " " + not + "DI DALAM (" + strings.Join(items, ", ") + ")"
+	case *ExistsExpr:
+		if e.Not {
+			return "TIDAK ADA (subquery)"
+		}
+		return "ADA (subquery)"
+	case *WindowExpr:
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = FormatExpr(arg)
+		}
+		return e.Func + "(" + strings.Join(args, ", ") + ") OVER (...)"
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+// formatOperand adds parentheses around DAN/ATAU operands of a different operator
+func formatOperand(expr Expr, parentOp string) string {
+	if bin, ok := expr.(*BinaryExpr); ok && bin.Operator != parentOp && (bin.Operator == "DAN" || bin.Operator == "ATAU") {
+		return "(" + FormatExpr(expr) + ")"
+	}
+	return FormatExpr(expr)
+}
+
+// FormatSelectItem renders one item of the PILIH list
+func FormatSelectItem(item SelectItem) string {
+	switch {
+	case item.Star && item.Table != "":
+		return item.Table + ".*"
+	case item.Star:
+		return "*"
+	case item.Alias != "":
+		return FormatExpr(item.Expr) + " SEBAGAI " + item.Alias
+	}
+	return FormatExpr(item.Expr)
+}
@@ -33,6 +33,33 @@ var keywords = map[string]TokenType{
 		"GRUPKAN":     TOKEN_GRUPKAN,
 		"MENAIK":      TOKEN_MENAIK,
 		"MENURUN":     TOKEN_MENURUN,
+		"KIRI":        TOKEN_KIRI,
+		"KANAN":       TOKEN_KANAN,
+		"PENUH":       TOKEN_PENUH,
+		"SILANG":      TOKEN_SILANG,
+		"PADA":        TOKEN_PADA,
+		"SEBAGAI":     TOKEN_SEBAGAI,
+		"ATAU":        TOKEN_ATAU,
+		"DI":          TOKEN_DI,
+		"DALAM":       TOKEN_DALAM,
+		"ADA":         TOKEN_ADA,
+		"TIDAK":       TOKEN_TIDAK,
+		"IRISAN":      TOKEN_IRISAN,
+		"KECUALI":     TOKEN_KECUALI,
+		"SEMUA":       TOKEN_SEMUA,
+		"PANDANGAN":   TOKEN_PANDANGAN,
+		"TERWUJUD":    TOKEN_TERWUJUD,
+		"SEGARKAN":    TOKEN_SEGARKAN,
+		"PARTISI":     TOKEN_PARTISI,
+		"OVER":        TOKEN_OVER,
+		"DENGAN":      TOKEN_DENGAN,
+		"REKURSIF":    TOKEN_REKURSIF,
+		"JELASKAN":    TOKEN_JELASKAN,
+		"ANALISIS":    TOKEN_ANALISIS,
+		"BATAS":       TOKEN_BATAS,
+		"IMPOR":       TOKEN_IMPOR,
+		"EKSPOR":      TOKEN_EKSPOR,
+		"HEADER":      TOKEN_HEADER,
 		"INT":         TOKEN_INT,
 		"FLOAT":       TOKEN_FLOAT,
 		"TEKS":        TOKEN_TEKS,
@@ -75,6 +102,10 @@ func (l *Lexer) NextToken() Token {
 		token := Token{Type: TOKEN_DOT, Value: ".", Pos: l.pos}
 		l.advance()
 		return token
+	case ';':
+		token := Token{Type: TOKEN_SEMICOLON, Value: ";", Pos: l.pos}
+		l.advance()
+		return token
 	case '*':
 		token := Token{Type: TOKEN_ASTERISK, Value: "*", Pos: l.pos}
 		l.advance()
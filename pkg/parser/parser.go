@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -25,25 +26,69 @@ func (p *Parser) nextToken() {
 }
 
 // Parse parses the FQL query
-func (p *Parser) Parse() (*Query, error) {
+func (p *Parser) Parse() (q *Query, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			q = nil
+			err = pe
+		}
+	}()
+
 	if p.currentPoint.Type == TOKEN_EOF {
 		return nil, fmt.Errorf("query kosong")
 	}
 
+	q, err = p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	// Perintah harus habis di sini; token sisa tidak boleh diabaikan diam-diam
+	if p.peek().Type == TOKEN_SEMICOLON {
+		p.consume(TOKEN_SEMICOLON)
+	}
+	if p.peek().Type != TOKEN_EOF {
+		return nil, fmt.Errorf("token tidak terduga: %s", p.peek().Value)
+	}
+	if q.Select != nil {
+		if err := checkAliases(q.Select); err != nil {
+			return nil, err
+		}
+	}
+	if q.ViewInfo != nil && q.ViewInfo.Select != nil {
+		if err := checkAliases(q.ViewInfo.Select); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+func (p *Parser) parseStatement() (*Query, error) {
 	switch p.currentPoint.Type {
 	case TOKEN_BUAT:
 		return p.parseCreate()
+	case TOKEN_SEGARKAN:
+		return p.parseRefreshView()
 	case TOKEN_ISI:
 		return p.parseInsert()
-	case TOKEN_PILIH:
+	case TOKEN_PILIH, TOKEN_DENGAN:
 		return p.parseSelect()
+	case TOKEN_JELASKAN:
+		return p.parseExplain()
+	case TOKEN_ANALISIS:
+		return p.parseAnalyze()
+	case TOKEN_IMPOR, TOKEN_EKSPOR:
+		return p.parseTransfer()
 	case TOKEN_ATUR:
 		return p.parseUpdate()
 	case TOKEN_BAKAR:
 		return p.parseDelete()
 	case TOKEN_GABUNG:
 		return p.parseJoin()
-	case TOKEN_CAMPUR, TOKEN_SATUKAN:
+	case TOKEN_CAMPUR, TOKEN_SATUKAN, TOKEN_IRISAN, TOKEN_KECUALI:
 		return p.parseUnion()
 	case TOKEN_URUTKAN:
 		return p.parseOrder()
@@ -57,6 +102,9 @@ func (p *Parser) Parse() (*Query, error) {
 // BUAT TANGKI nama (kolom1 TIPE, kolom2 TIPE, ...)
 func (p *Parser) parseCreate() (*Query, error) {
 	p.consume(TOKEN_BUAT)
+	if p.peek().Type == TOKEN_PANDANGAN {
+		return p.parseCreateView()
+	}
 	p.consume(TOKEN_TANGKI)
 	
 	tangki := p.consume(TOKEN_IDENTIFIER).Value
@@ -82,6 +130,54 @@ func (p *Parser) parseCreate() (*Query, error) {
 	}, nil
 }
 
+// BUAT PANDANGAN [TERWUJUD] nama SEBAGAI PILIH ...
+func (p *Parser) parseCreateView() (*Query, error) {
+	p.consume(TOKEN_PANDANGAN)
+
+	materialized := false
+	if p.peek().Type == TOKEN_TERWUJUD {
+		p.consume(TOKEN_TERWUJUD)
+		materialized = true
+	}
+
+	name := p.consume(TOKEN_IDENTIFIER).Value
+	p.consume(TOKEN_SEBAGAI)
+
+	start := p.current().Pos
+	stmt, err := p.parseSelectStmt()
+	if err != nil {
+		return nil, err
+	}
+	end := len(p.lexer.input)
+	if p.peek().Type != TOKEN_EOF {
+		end = p.peek().Pos
+	}
+
+	return &Query{
+		Type:   "CREATE_VIEW",
+		Tangki: name,
+		ViewInfo: &ViewInfo{
+			Name:         name,
+			Materialized: materialized,
+			Definition:   strings.TrimSpace(p.lexer.input[start:end]),
+			Select:       stmt,
+		},
+	}, nil
+}
+
+// SEGARKAN PANDANGAN nama
+func (p *Parser) parseRefreshView() (*Query, error) {
+	p.consume(TOKEN_SEGARKAN)
+	p.consume(TOKEN_PANDANGAN)
+	name := p.consume(TOKEN_IDENTIFIER).Value
+
+	return &Query{
+		Type:     "REFRESH_VIEW",
+		Tangki:   name,
+		ViewInfo: &ViewInfo{Name: name},
+	}, nil
+}
+
 // ISI TANGKI nama NILAI (val1, val2, ...)
 func (p *Parser) parseInsert() (*Query, error) {
     p.consume(TOKEN_ISI)
@@ -116,41 +212,355 @@ func (p *Parser) parseInsert() (*Query, error) {
     }, nil
 }
 
-// PILIH kolom1, kolom2 DARI tangki [DIMANA kondisi]
+// PILIH kolom1, t.kolom2 [SEBAGAI alias] DARI tangki [alias]
+// [GABUNG [KIRI|KANAN|PENUH|SILANG] tangki2 [alias] PADA kondisi ...] [DIMANA kondisi]
 func (p *Parser) parseSelect() (*Query, error) {
-	p.consume(TOKEN_PILIH)
-	
-	columns := []string{}
-	if p.peek().Type == TOKEN_ASTERISK {
-		p.consume(TOKEN_ASTERISK)
-		columns = append(columns, "*")
+	stmt, err := p.parseSelectStmt()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(stmt.Items))
+	for i, item := range stmt.Items {
+		columns[i] = selectItemName(item)
+	}
+
+	return &Query{
+		Type:    "SELECT",
+		Tangki:  stmt.From.Name,
+		Columns: columns,
+		Select:  stmt,
+	}, nil
+}
+
+// ANALISIS TANGKI nama
+func (p *Parser) parseAnalyze() (*Query, error) {
+	p.consume(TOKEN_ANALISIS)
+	p.consume(TOKEN_TANGKI)
+	name := p.consume(TOKEN_IDENTIFIER).Value
+
+	return &Query{
+		Type:   "ANALYZE",
+		Tangki: name,
+	}, nil
+}
+
+// IMPOR TANGKI nama DARI 'file.csv' [DENGAN HEADER]
+// EKSPOR TANGKI nama KE 'file.csv' [DENGAN HEADER]
+// File .ndjson dan .jsonl dibaca dan ditulis sebagai NDJSON, .parquet
+// sebagai Parquet, dan .arrow/.feather (format file) atau .arrows (format
+// stream) sebagai Arrow IPC. Arrow hanya untuk EKSPOR.
+func (p *Parser) parseTransfer() (*Query, error) {
+	queryType := "IMPORT"
+	if p.peek().Type == TOKEN_EKSPOR {
+		queryType = "EXPORT"
+	}
+	p.nextToken()
+	p.consume(TOKEN_TANGKI)
+	name := p.consume(TOKEN_IDENTIFIER).Value
+	if queryType == "IMPORT" {
+		p.consume(TOKEN_DARI)
 	} else {
-		for {
-			col := p.consume(TOKEN_IDENTIFIER).Value
-			columns = append(columns, col)
-			
-			if p.peek().Type != TOKEN_COMMA {
-				break
+		p.consume(TOKEN_KE)
+	}
+	info := &TransferInfo{Path: p.consume(TOKEN_STRING).Value, Format: "CSV"}
+	switch strings.ToLower(filepath.Ext(info.Path)) {
+	case ".ndjson", ".jsonl":
+		info.Format = "NDJSON"
+	case ".parquet":
+		info.Format = "PARQUET"
+	case ".arrow", ".feather":
+		info.Format = "ARROW"
+	case ".arrows":
+		info.Format = "ARROW_STREAM"
+	}
+	if queryType == "IMPORT" && strings.HasPrefix(info.Format, "ARROW") {
+		return nil, fmt.Errorf("IMPOR dari file Arrow belum didukung")
+	}
+
+	if p.peek().Type == TOKEN_DENGAN {
+		p.consume(TOKEN_DENGAN)
+		p.consume(TOKEN_HEADER)
+		if info.Format != "CSV" {
+			return nil, fmt.Errorf("DENGAN HEADER hanya untuk file CSV")
+		}
+		info.Header = true
+	}
+	return &Query{
+		Type:     queryType,
+		Tangki:   name,
+		Transfer: info,
+	}, nil
+}
+
+// JELASKAN PILIH ...
+func (p *Parser) parseExplain() (*Query, error) {
+	p.consume(TOKEN_JELASKAN)
+	stmt, err := p.parseSelectStmt()
+	if err != nil {
+		return nil, err
+	}
+	return &Query{
+		Type:   "EXPLAIN",
+		Tangki: stmt.From.Name,
+		Select: stmt,
+	}, nil
+}
+
+// parseSelectStmt parses [DENGAN ...] PILIH ... [SATUKAN|CAMPUR|IRISAN|KECUALI [SEMUA] PILIH ...] [BATAS n]
+func (p *Parser) parseSelectStmt() (*SelectStmt, error) {
+	var with []*CTE
+	if p.peek().Type == TOKEN_DENGAN {
+		var err error
+		if with, err = p.parseWith(); err != nil {
+			return nil, err
+		}
+	}
+
+	stmt, err := p.parseSimpleSelect()
+	if err != nil {
+		return nil, err
+	}
+	stmt.With = with
+
+	for {
+		op, ok := setOperation(p.peek().Type)
+		if !ok {
+			break
+		}
+		p.nextToken()
+
+		clause := &SetOpClause{Operation: op}
+		if p.peek().Type == TOKEN_SEMUA {
+			p.consume(TOKEN_SEMUA)
+			clause.All = true
+		}
+		if clause.Select, err = p.parseSimpleSelect(); err != nil {
+			return nil, err
+		}
+		stmt.SetOps = append(stmt.SetOps, clause)
+	}
+
+	if p.peek().Type == TOKEN_BATAS {
+		p.consume(TOKEN_BATAS)
+		n, err := strconv.Atoi(p.consume(TOKEN_NUMBER).Value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("BATAS harus bilangan bulat tidak negatif")
+		}
+		stmt.Limit = &n
+	}
+	return stmt, nil
+}
+
+// DENGAN [REKURSIF] nama [(kolom, ...)] SEBAGAI (PILIH ...) [, nama SEBAGAI (...)]
+func (p *Parser) parseWith() ([]*CTE, error) {
+	p.consume(TOKEN_DENGAN)
+
+	recursive := false
+	if p.peek().Type == TOKEN_REKURSIF {
+		p.consume(TOKEN_REKURSIF)
+		recursive = true
+	}
+
+	var ctes []*CTE
+	for {
+		cte := &CTE{Name: p.consume(TOKEN_IDENTIFIER).Value, Recursive: recursive}
+		if p.peek().Type == TOKEN_LPAREN {
+			p.consume(TOKEN_LPAREN)
+			for {
+				cte.Columns = append(cte.Columns, p.consume(TOKEN_IDENTIFIER).Value)
+				if p.peek().Type != TOKEN_COMMA {
+					break
+				}
+				p.consume(TOKEN_COMMA)
 			}
-			p.consume(TOKEN_COMMA)
+			p.consume(TOKEN_RPAREN)
+		}
+
+		p.consume(TOKEN_SEBAGAI)
+		p.consume(TOKEN_LPAREN)
+		sel, err := p.parseSelectStmt()
+		if err != nil {
+			return nil, err
+		}
+		p.consume(TOKEN_RPAREN)
+		cte.Select = sel
+		ctes = append(ctes, cte)
+
+		if p.peek().Type != TOKEN_COMMA {
+			break
 		}
+		p.consume(TOKEN_COMMA)
 	}
-	
+	return ctes, nil
+}
+
+func setOperation(t TokenType) (string, bool) {
+	switch t {
+	case TOKEN_SATUKAN, TOKEN_CAMPUR:
+		return "UNION", true
+	case TOKEN_IRISAN:
+		return "INTERSECT", true
+	case TOKEN_KECUALI:
+		return "EXCEPT", true
+	}
+	return "", false
+}
+
+func (p *Parser) parseSimpleSelect() (*SelectStmt, error) {
+	p.consume(TOKEN_PILIH)
+
+	stmt := &SelectStmt{}
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Items = append(stmt.Items, item)
+
+		if p.peek().Type != TOKEN_COMMA {
+			break
+		}
+		p.consume(TOKEN_COMMA)
+	}
+
 	p.consume(TOKEN_DARI)
-	tangki := p.consume(TOKEN_IDENTIFIER).Value
-	
-	var condition *Condition
+	stmt.From = p.parseTableRef()
+
+	for p.peek().Type == TOKEN_GABUNG {
+		join, err := p.parseJoinClause()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Joins = append(stmt.Joins, join)
+	}
+
 	if p.peek().Type == TOKEN_DIMANA {
 		p.consume(TOKEN_DIMANA)
-		condition = p.parseCondition()
+		where, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Where = where
 	}
-	
-	return &Query{
-		Type:      "SELECT",
-		Tangki:    tangki,
-		Columns:   columns,
-		Condition: condition,
-	}, nil
+
+	return stmt, nil
+}
+
+func (p *Parser) parseSelectItem() (SelectItem, error) {
+	if p.peek().Type == TOKEN_ASTERISK {
+		p.consume(TOKEN_ASTERISK)
+		return SelectItem{Star: true}, nil
+	}
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return SelectItem{}, err
+	}
+
+	// tangki.* dibaca oleh parsePrimary sebagai ColumnRef dengan kolom "*"
+	if ref, ok := expr.(*ColumnRef); ok && ref.Column == "*" {
+		return SelectItem{Star: true, Table: ref.Table}, nil
+	}
+
+	item := SelectItem{Expr: expr}
+	if p.peek().Type == TOKEN_SEBAGAI {
+		p.consume(TOKEN_SEBAGAI)
+		item.Alias = p.consume(TOKEN_IDENTIFIER).Value
+	}
+	return item, nil
+}
+
+// tangki [SEBAGAI] [alias]
+func (p *Parser) parseTableRef() *TableRef {
+	ref := &TableRef{Name: p.consume(TOKEN_IDENTIFIER).Value}
+	if p.peek().Type == TOKEN_SEBAGAI {
+		p.consume(TOKEN_SEBAGAI)
+		ref.Alias = p.consume(TOKEN_IDENTIFIER).Value
+	} else if p.peek().Type == TOKEN_IDENTIFIER {
+		ref.Alias = p.consume(TOKEN_IDENTIFIER).Value
+		ref.implicit = true
+	}
+	return ref
+}
+
+// checkAliases menolak alias tanpa SEBAGAI yang tidak pernah dipakai
+// sebagai alias.kolom. Alias seperti itu hampir selalu token sisa yang
+// salah ketik (PILIH * DARI a sampah), bukan alias yang disengaja.
+func checkAliases(stmt *SelectStmt) error {
+	used := make(map[string]bool)
+	var implicit []*TableRef
+	stmt.walk(func(s *SelectStmt) {
+		for _, ref := range s.tableRefs() {
+			if ref.implicit {
+				implicit = append(implicit, ref)
+			}
+		}
+	}, func(ref *ColumnRef) {
+		used[ref.Table] = true
+	})
+	for _, ref := range implicit {
+		if !used[ref.Alias] {
+			return fmt.Errorf("token tidak terduga: %s (alias tanpa SEBAGAI untuk '%s' tidak dipakai)", ref.Alias, ref.Name)
+		}
+	}
+	return nil
+}
+
+// GABUNG [KIRI|KANAN|PENUH|SILANG] tangki [alias] [PADA kondisi]
+func (p *Parser) parseJoinClause() (*JoinClause, error) {
+	p.consume(TOKEN_GABUNG)
+
+	join := &JoinClause{Type: p.parseJoinType()}
+	join.Table = p.parseTableRef()
+
+	if join.Type == "CROSS" {
+		return join, nil
+	}
+
+	p.consume(TOKEN_PADA)
+	on, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	join.On = on
+	return join, nil
+}
+
+func (p *Parser) parseJoinType() string {
+	joinType := "INNER"
+	switch p.peek().Type {
+	case TOKEN_KIRI:
+		joinType = "LEFT"
+	case TOKEN_KANAN:
+		joinType = "RIGHT"
+	case TOKEN_PENUH:
+		joinType = "FULL"
+	case TOKEN_SILANG:
+		joinType = "CROSS"
+	}
+	if joinType != "INNER" {
+		p.nextToken()
+	}
+	return joinType
+}
+
+func selectItemName(item SelectItem) string {
+	switch {
+	case item.Star && item.Table != "":
+		return item.Table + ".*"
+	case item.Star:
+		return "*"
+	case item.Alias != "":
+		return item.Alias
+	}
+	switch e := item.Expr.(type) {
+	case *ColumnRef:
+		return e.Column
+	case *WindowExpr:
+		return strings.ToLower(e.Func)
+	}
+	return "?kolom?"
 }
 
 // ATUR TANGKI nama SET kolom=nilai DIMANA kondisi
@@ -195,58 +605,141 @@ func (p *Parser) parseDelete() (*Query, error) {
 	}, nil
 }
 
-// GABUNG tangki1 DAN tangki2 MENJADI tangki_baru DIMANA tangki1.kolom=tangki2.kolom
+// GABUNG [KIRI|KANAN|PENUH|SILANG] tangki1 DAN tangki2 MENJADI tangki_baru
+// DIMANA tangki1.kolom=tangki2.kolom [DAN tangki1.kolom<op>tangki2.kolom ...]
+// GABUNG SILANG tidak memakai DIMANA.
 func (p *Parser) parseJoin() (*Query, error) {
 	p.consume(TOKEN_GABUNG)
-	
+	joinType := p.parseJoinType()
+
 	tangki1 := p.consume(TOKEN_IDENTIFIER).Value
 	p.consume(TOKEN_DAN)
 	tangki2 := p.consume(TOKEN_IDENTIFIER).Value
 	p.consume(TOKEN_MENJADI)
 	newTangki := p.consume(TOKEN_IDENTIFIER).Value
-	p.consume(TOKEN_DIMANA)
-	
-	col1Tangki := p.consume(TOKEN_IDENTIFIER).Value
-	if col1Tangki != tangki1 {
-		return nil, fmt.Errorf("expected tangki name '%s', got '%s'", tangki1, col1Tangki)
+
+	info := &JoinInfo{
+		Type:      joinType,
+		Tangki1:   tangki1,
+		Tangki2:   tangki2,
+		NewTangki: newTangki,
 	}
-	p.consume(TOKEN_DOT)
-	col1 := p.consume(TOKEN_IDENTIFIER).Value
 
-	p.consume(TOKEN_EQUALS)
+	if joinType == "CROSS" {
+		return &Query{Type: "JOIN", JoinInfo: info}, nil
+	}
+
+	p.consume(TOKEN_DIMANA)
+	for {
+		cond, err := p.parseJoinCondition(tangki1, tangki2)
+		if err != nil {
+			return nil, err
+		}
+		info.Conditions = append(info.Conditions, cond)
 
-	col2Tangki := p.consume(TOKEN_IDENTIFIER).Value
-	if col2Tangki != tangki2 {
-		return nil, fmt.Errorf("expected tangki name '%s', got '%s'", tangki2, col2Tangki)
+		if p.peek().Type != TOKEN_DAN {
+			break
+		}
+		p.consume(TOKEN_DAN)
 	}
-	p.consume(TOKEN_DOT)
-	col2 := p.consume(TOKEN_IDENTIFIER).Value
-	
+
+	for _, cond := range info.Conditions {
+		if cond.Operator == "=" {
+			info.OnColumn1 = cond.Column1
+			info.OnColumn2 = cond.Column2
+			break
+		}
+	}
+
 	return &Query{
-		Type: "JOIN",
-		JoinInfo: &JoinInfo{
-			Tangki1:   tangki1,
-			Tangki2:   tangki2,
-			NewTangki: newTangki,
-			OnColumn1: col1,
-			OnColumn2: col2,
-		},
+		Type:     "JOIN",
+		JoinInfo: info,
 	}, nil
 }
 
-// CAMPUR TANGKI tangki1 + tangki2 MENJADI tangki_baru
-// or SATUKAN tangki1, tangki2, ... MENJADI tangki_baru
+// parseJoinCondition membaca a.kolom <op> b.kolom. Urutan sisi boleh
+// terbalik; hasilnya selalu dinormalisasi menjadi tangki1 <op> tangki2.
+func (p *Parser) parseJoinCondition(tangki1, tangki2 string) (JoinCondition, error) {
+	leftTangki := p.consume(TOKEN_IDENTIFIER).Value
+	p.consume(TOKEN_DOT)
+	leftCol := p.consume(TOKEN_IDENTIFIER).Value
+
+	operator, ok := p.parseOperator()
+	if !ok {
+		return JoinCondition{}, fmt.Errorf("operator perbandingan tidak valid: %s", p.peek().Value)
+	}
+
+	rightTangki := p.consume(TOKEN_IDENTIFIER).Value
+	p.consume(TOKEN_DOT)
+	rightCol := p.consume(TOKEN_IDENTIFIER).Value
+
+	switch {
+	case leftTangki == tangki1 && rightTangki == tangki2:
+		return JoinCondition{Column1: leftCol, Operator: operator, Column2: rightCol}, nil
+	case leftTangki == tangki2 && rightTangki == tangki1:
+		return JoinCondition{Column1: rightCol, Operator: flipOperator(operator), Column2: leftCol}, nil
+	default:
+		return JoinCondition{}, fmt.Errorf("kondisi join harus membandingkan '%s' dan '%s', got '%s' dan '%s'", tangki1, tangki2, leftTangki, rightTangki)
+	}
+}
+
+// parseOperator membaca operator perbandingan dan memajukan token.
+func (p *Parser) parseOperator() (string, bool) {
+	operator := ""
+	switch p.peek().Type {
+	case TOKEN_EQUALS:
+		operator = "="
+	case TOKEN_GT:
+		operator = ">"
+	case TOKEN_LT:
+		operator = "<"
+	case TOKEN_GTE:
+		operator = ">="
+	case TOKEN_LTE:
+		operator = "<="
+	case TOKEN_NEQ:
+		operator = "!="
+	default:
+		return "", false
+	}
+	p.nextToken()
+	return operator, true
+}
+
+func flipOperator(op string) string {
+	switch op {
+	case ">":
+		return "<"
+	case "<":
+		return ">"
+	case ">=":
+		return "<="
+	case "<=":
+		return ">="
+	}
+	return op
+}
+
+// CAMPUR [SEMUA] TANGKI tangki1 + tangki2 MENJADI tangki_baru
+// or SATUKAN|IRISAN|KECUALI [SEMUA] tangki1, tangki2, ... MENJADI tangki_baru
 func (p *Parser) parseUnion() (*Query, error) {
-	isSatukan := p.peek().Type == TOKEN_SATUKAN
+	isCampur := p.peek().Type == TOKEN_CAMPUR
+	op, _ := setOperation(p.peek().Type)
 	p.nextToken()
-	
+
+	all := false
+	if p.peek().Type == TOKEN_SEMUA {
+		p.consume(TOKEN_SEMUA)
+		all = true
+	}
+
 	tangkis := []string{}
-	
-	if isSatukan {
+
+	if !isCampur {
 		for {
 			tangki := p.consume(TOKEN_IDENTIFIER).Value
 			tangkis = append(tangkis, tangki)
-			
+
 			if p.peek().Type != TOKEN_COMMA {
 				break
 			}
@@ -256,18 +749,20 @@ func (p *Parser) parseUnion() (*Query, error) {
 		p.consume(TOKEN_TANGKI)
 		tangki1 := p.consume(TOKEN_IDENTIFIER).Value
 		tangkis = append(tangkis, tangki1)
-		
+
 		p.consume(TOKEN_PLUS)
 		tangki2 := p.consume(TOKEN_IDENTIFIER).Value
 		tangkis = append(tangkis, tangki2)
 	}
-	
+
 	p.consume(TOKEN_MENJADI)
 	newTangki := p.consume(TOKEN_IDENTIFIER).Value
-	
+
 	return &Query{
 		Type: "UNION",
 		UnionInfo: &UnionInfo{
+			Operation: op,
+			All:       all,
 			Tangkis:   tangkis,
 			NewTangki: newTangki,
 		},
@@ -336,24 +831,11 @@ func (p *Parser) parseGroup() (*Query, error) {
 func (p *Parser) parseCondition() *Condition {
 	column := p.consume(TOKEN_IDENTIFIER).Value
 	
-	operator := ""
-	switch p.peek().Type {
-	case TOKEN_EQUALS:
-		operator = "="
-	case TOKEN_GT:
-		operator = ">"
-	case TOKEN_LT:
-		operator = "<"
-	case TOKEN_GTE:
-		operator = ">="
-	case TOKEN_LTE:
-		operator = "<="
-	case TOKEN_NEQ:
-		operator = "!="
-	default:
+	operator, ok := p.parseOperator()
+	if !ok {
 		operator = "="
+		p.nextToken()
 	}
-	p.nextToken()
 	
 	value := p.parseValue()
 	
@@ -389,6 +871,9 @@ func (p *Parser) parseExpression() interface{} {
 
 func (p *Parser) parseValue() interface{} {
 	token := p.current()
+	if token.Type == TOKEN_EOF {
+		panic(parseError{"query berakhir sebelum nilai"})
+	}
 	p.nextToken()
 	
 	switch token.Type {
@@ -419,7 +904,7 @@ func (p *Parser) peek() Token {
 func (p *Parser) consume(expected TokenType) Token {
 	token := p.current()
 	if token.Type != expected {
-		panic(fmt.Sprintf("expected %d, got %d (%s)", expected, token.Type, token.Value))
+		panic(parseError{fmt.Sprintf("expected %d, got %d (%s)", expected, token.Type, token.Value)})
 	}
 	p.nextToken()
 	return token
@@ -433,5 +918,13 @@ func (p *Parser) consumeAny(types ...TokenType) Token {
 			return token
 		}
 	}
-	panic(fmt.Sprintf("unexpected token: %s", token.Value))
-}
\ No newline at end of file
+	panic(parseError{fmt.Sprintf("unexpected token: %s", token.Value)})
+}
+
+// parseError adalah satu-satunya panic yang ditangkap Parse; panic lain
+// berarti bug di parser dan diteruskan.
+type parseError struct {
+	msg string
+}
+
+func (e parseError) Error() string { return e.msg }
\ No newline at end of file
@@ -26,6 +26,33 @@ const (
 	TOKEN_GRUPKAN
 	TOKEN_MENAIK
 	TOKEN_MENURUN
+	TOKEN_KIRI
+	TOKEN_KANAN
+	TOKEN_PENUH
+	TOKEN_SILANG
+	TOKEN_PADA
+	TOKEN_SEBAGAI
+	TOKEN_ATAU
+	TOKEN_DI
+	TOKEN_DALAM
+	TOKEN_ADA
+	TOKEN_TIDAK
+	TOKEN_IRISAN
+	TOKEN_KECUALI
+	TOKEN_SEMUA
+	TOKEN_PANDANGAN
+	TOKEN_TERWUJUD
+	TOKEN_SEGARKAN
+	TOKEN_PARTISI
+	TOKEN_OVER
+	TOKEN_DENGAN
+	TOKEN_REKURSIF
+	TOKEN_JELASKAN
+	TOKEN_ANALISIS
+	TOKEN_BATAS
+	TOKEN_IMPOR
+	TOKEN_EKSPOR
+	TOKEN_HEADER
 	
 	// Data Types
 	TOKEN_INT
@@ -62,6 +89,7 @@ const (
 	TOKEN_RPAREN
 	TOKEN_COMMA
 	TOKEN_DOT
+	TOKEN_SEMICOLON
 	TOKEN_EOF
 	TOKEN_UNKNOWN
 )
@@ -84,6 +112,9 @@ type Query struct {
 	OrderInfo *OrderInfo
 	GroupInfo *GroupInfo
 	UnionInfo *UnionInfo
+	Select    *SelectStmt
+	ViewInfo  *ViewInfo
+	Transfer  *TransferInfo
 }
 
 // Condition represents WHERE clause
@@ -95,19 +126,45 @@ type Condition struct {
 
 // JoinInfo represents JOIN operation
 type JoinInfo struct {
-	Tangki1   string
-	Tangki2   string
-	NewTangki string
-	OnColumn1 string
-	OnColumn2 string
+	Type       string // "INNER", "LEFT", "RIGHT", "FULL", "CROSS"
+	Tangki1    string
+	Tangki2    string
+	NewTangki  string
+	OnColumn1  string
+	OnColumn2  string
+	Conditions []JoinCondition
+}
+
+// JoinCondition represents tangki1.kolom <op> tangki2.kolom
+type JoinCondition struct {
+	Column1  string
+	Operator string
+	Column2  string
 }
 
-// UnionInfo represents UNION operation
+// UnionInfo represents UNION, INTERSECT and EXCEPT operations
 type UnionInfo struct {
+	Operation string // "UNION", "INTERSECT", "EXCEPT"
+	All       bool
 	Tangkis   []string
 	NewTangki string
 }
 
+// ViewInfo represents BUAT PANDANGAN [TERWUJUD] and SEGARKAN PANDANGAN
+type ViewInfo struct {
+	Name         string
+	Materialized bool
+	Definition   string
+	Select       *SelectStmt
+}
+
+// TransferInfo represents IMPOR TANGKI ... DARI and EKSPOR TANGKI ... KE
+type TransferInfo struct {
+	Path   string
+	Format string // "CSV", "NDJSON", "PARQUET", "ARROW" (IPC file), or "ARROW_STREAM"
+	Header bool
+}
+
 // OrderInfo represents ORDER BY
 type OrderInfo struct {
 	Column    string
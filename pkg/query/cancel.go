@@ -0,0 +1,78 @@
+package query
+
+import (
+	"context"
+)
+
+// cancelInterval adalah jumlah iterasi di antara dua pemeriksaan context,
+// agar loop panjang tetap bisa dibatalkan tanpa membayar biaya select di
+// setiap baris.
+const cancelInterval = 1024
+
+// Canceler memeriksa pembatalan context di dalam loop panjang (scan, join,
+// sort, group). Canceler nil tidak pernah dibatalkan.
+type Canceler struct {
+	ctx context.Context
+	n   int
+	err error
+}
+
+// NewCanceler membuat Canceler untuk ctx, atau nil bila ctx tidak pernah
+// dibatalkan.
+func NewCanceler(ctx context.Context) *Canceler {
+	if ctx == nil || ctx.Done() == nil {
+		return nil
+	}
+	return &Canceler{ctx: ctx}
+}
+
+// Canceled mengembalikan true bila context sudah dibatalkan. Context hanya
+// diperiksa setiap cancelInterval panggilan; sekali dibatalkan hasilnya
+// tetap true.
+func (c *Canceler) Canceled() bool {
+	if c == nil {
+		return false
+	}
+	if c.err != nil {
+		return true
+	}
+	c.n++
+	if c.n%cancelInterval != 0 {
+		return false
+	}
+	c.err = c.ctx.Err()
+	return c.err != nil
+}
+
+// Err mengembalikan error context bila loop dihentikan karena pembatalan.
+func (c *Canceler) Err() error {
+	if c == nil {
+		return nil
+	}
+	if c.err == nil {
+		c.err = c.ctx.Err()
+	}
+	return c.err
+}
+
+// canceler mengembalikan Canceler bersama untuk satu eksekusi query.
+func (env *Env) canceler() *Canceler {
+	if env == nil {
+		return nil
+	}
+	if env.cancel == nil {
+		env.cancel = NewCanceler(env.Ctx)
+	}
+	return env.cancel
+}
+
+// checkCanceled mencatat pembatalan sebagai error evaluasi sehingga
+// iterator berhenti seperti saat ekspresi gagal.
+func (env *Env) checkCanceled() bool {
+	c := env.canceler()
+	if c.Canceled() {
+		env.setErr(c.Err())
+		return true
+	}
+	return false
+}
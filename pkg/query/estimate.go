@@ -0,0 +1,249 @@
+package query
+
+import (
+	"github.com/Dziqha/BensinDB/pkg/parser"
+)
+
+// defaultSourceRows dipakai bila jumlah baris sumber belum diketahui,
+// misalnya pandangan biasa yang baru dihitung saat dieksekusi.
+const defaultSourceRows = 1000
+
+// Estimate memperkirakan jumlah baris keluaran sebuah node rencana.
+func Estimate(plan Plan) float64 {
+	switch p := plan.(type) {
+	case *ScanPlan:
+		rows := float64(p.Source.Rows)
+		if p.Source.Rows < 0 {
+			rows = defaultSourceRows
+		}
+		return rows * selectivity(p.Filter, p)
+	case *FilterPlan:
+		return Estimate(p.Input) * selectivity(p.Predicate, p.Input)
+	case *JoinPlan:
+		return estimateJoin(p)
+	case *LimitPlan:
+		return minFloat(Estimate(p.Input), float64(p.Count))
+	case *SetOpPlan:
+		left, right := Estimate(p.Left), Estimate(p.Right)
+		switch p.Op {
+		case SetIntersect:
+			return minFloat(left, right)
+		case SetExcept:
+			return left
+		}
+		return left + right
+	}
+	if children := plan.Children(); len(children) == 1 {
+		return Estimate(children[0])
+	}
+	return 0
+}
+
+// estimateJoin memakai statistik distinct kunci join bila tersedia
+// (|L|*|R| / max(distinct)); tanpa statistik diasumsikan relasi kunci
+// asing sehingga equi-join menghasilkan kira-kira sebanyak sisi yang lebih
+// besar. Join tanpa kunci = menyaring sepertiga pasangan.
+func estimateJoin(p *JoinPlan) float64 {
+	left, right := Estimate(p.Left), Estimate(p.Right)
+
+	var est float64
+	switch {
+	case p.Type == JoinCross || p.On == nil:
+		est = left * right
+	case hasEquiKey(p):
+		est = equiJoinRows(p, left, right) * selectivityWithoutKeys(p)
+	default:
+		est = left * right * selectivity(p.On, p)
+	}
+
+	switch p.Type {
+	case JoinLeft:
+		est = maxFloat(est, left)
+	case JoinRight:
+		est = maxFloat(est, right)
+	case JoinFull:
+		est = maxFloat(est, maxFloat(left, right))
+	}
+	return est
+}
+
+func equiJoinRows(p *JoinPlan, left, right float64) float64 {
+	for _, conj := range SplitConjuncts(p.On) {
+		bin, ok := conj.(*parser.BinaryExpr)
+		if !ok || bin.Operator != "=" {
+			continue
+		}
+		a, aok := bin.Left.(*parser.ColumnRef)
+		b, bok := bin.Right.(*parser.ColumnRef)
+		if !aok || !bok {
+			continue
+		}
+		sa, sb := columnStats(p, a), columnStats(p, b)
+		if sa == nil || sb == nil || sa.Distinct == 0 || sb.Distinct == 0 {
+			continue
+		}
+		return left * right / float64(maxInt(sa.Distinct, sb.Distinct))
+	}
+	return maxFloat(left, right)
+}
+
+func hasEquiKey(p *JoinPlan) bool {
+	left, right := OutputSchema(p.Left), OutputSchema(p.Right)
+	for _, conj := range SplitConjuncts(p.On) {
+		if _, _, ok := equiJoinKey(conj, left, right); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func selectivityWithoutKeys(p *JoinPlan) float64 {
+	left, right := OutputSchema(p.Left), OutputSchema(p.Right)
+	sel := 1.0
+	for _, conj := range SplitConjuncts(p.On) {
+		if _, _, ok := equiJoinKey(conj, left, right); !ok {
+			sel *= selectivity(conj, p)
+		}
+	}
+	return sel
+}
+
+// selectivity adalah perkiraan bagian baris yang lolos predikat. ctx
+// adalah node tempat predikat dievaluasi; statistik kolom dari scan di
+// bawahnya dipakai untuk perbandingan kolom dengan konstanta.
+func selectivity(expr parser.Expr, ctx Plan) float64 {
+	switch e := expr.(type) {
+	case nil:
+		return 1
+	case *parser.BinaryExpr:
+		switch e.Operator {
+		case "DAN":
+			return selectivity(e.Left, ctx) * selectivity(e.Right, ctx)
+		case "ATAU":
+			a, b := selectivity(e.Left, ctx), selectivity(e.Right, ctx)
+			return a + b - a*b
+		case "=", "!=", ">", "<", ">=", "<=":
+			if sel, ok := statsSelectivity(e, ctx); ok {
+				return sel
+			}
+			switch e.Operator {
+			case "=":
+				return 0.1
+			case "!=":
+				return 0.9
+			}
+			return 1.0 / 3
+		}
+	case *parser.InExpr:
+		sel := 0.5
+		if e.Subquery == nil {
+			sel = minFloat(0.1*float64(len(e.List)), 1)
+		}
+		if e.Not {
+			return 1 - sel
+		}
+		return sel
+	}
+	return 0.5
+}
+
+// statsSelectivity menangani kolom <op> konstanta (atau sebaliknya)
+// memakai distinct count dan histogram dari ANALISIS TANGKI.
+func statsSelectivity(e *parser.BinaryExpr, ctx Plan) (float64, bool) {
+	op := e.Operator
+	ref, rok := e.Left.(*parser.ColumnRef)
+	lit, lok := e.Right.(*parser.Literal)
+	if !rok || !lok {
+		ref, rok = e.Right.(*parser.ColumnRef)
+		lit, lok = e.Left.(*parser.Literal)
+		op = flipComparison(op)
+	}
+	if !rok || !lok || ctx == nil {
+		return 0, false
+	}
+	cs := columnStats(ctx, ref)
+	if cs == nil {
+		return 0, false
+	}
+
+	v := lit.Value
+	nonNull := 1 - cs.NullFraction
+	eq := cs.equalSelectivity(v)
+	var sel float64
+	switch op {
+	case "=":
+		sel = eq
+	case "!=":
+		sel = nonNull - eq
+	case "<":
+		sel = cs.lessSelectivity(v)
+	case "<=":
+		sel = cs.lessSelectivity(v) + eq
+	case ">":
+		sel = nonNull - cs.lessSelectivity(v) - eq
+	case ">=":
+		sel = nonNull - cs.lessSelectivity(v)
+	}
+	return clamp01(sel), true
+}
+
+// columnStats mencari statistik kolom ref dari scan di bawah ctx.
+func columnStats(ctx Plan, ref *parser.ColumnRef) *ColumnStats {
+	var found *ColumnStats
+	visitPlan(ctx, func(p Plan) {
+		scan, ok := p.(*ScanPlan)
+		if !ok || found != nil || scan.Source.Stats == nil {
+			return
+		}
+		schema := qualifyFields(scan.Source.Schema, scan.Alias)
+		if idx, err := schema.Resolve(ref.Table, ref.Column); err == nil {
+			found = scan.Source.Stats.Column(schema[idx].Name)
+		}
+	})
+	return found
+}
+
+func flipComparison(op string) string {
+	switch op {
+	case ">":
+		return "<"
+	case "<":
+		return ">"
+	case ">=":
+		return "<="
+	case "<=":
+		return ">="
+	}
+	return op
+}
+
+func clamp01(f float64) float64 {
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
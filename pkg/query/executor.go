@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strconv"
@@ -8,31 +9,11 @@ import (
 	"github.com/Dziqha/BensinDB/pkg/tangki"
 )
 
-func Join(t1, t2 *tangki.Tangki, col1, col2 string) *tangki.Tangki {
-    idx1 := t1.GetColumnIndex(col1)
-    idx2 := t2.GetColumnIndex(col2)
-
-    if idx1 == -1 || idx2 == -1 { return nil }
-
-    allColumns := append(t1.Columns, t2.Columns...)
-    result := tangki.NewTangki("joined", allColumns)
-
-    for _, row1 := range t1.Rows {
-        for _, row2 := range t2.Rows {
-            if compareValues(row1[idx1], "=", row2[idx2]) {
-                
-                joinedRow := make(tangki.Row, len(row1)+len(row2))
-                copy(joinedRow, row1)
-                copy(joinedRow[len(row1):], row2)
-                
-                result.Rows = append(result.Rows, joinedRow)
-            }
-        }
+func compareValues(a interface{}, op string, b interface{}) bool {
+    if a == nil || b == nil {
+        return false
     }
-    return result
-}
 
-func compareValues(a interface{}, op string, b interface{}) bool {
     switch va := a.(type) {
     case int64:
         if vb, ok := b.(int64); ok {
@@ -98,34 +79,39 @@ func toFloatAJAX(val interface{}) float64 {
 
 
 
-func Union(tangkis ...*tangki.Tangki) *tangki.Tangki {
-	if len(tangkis) == 0 {
-		return tangki.NewTangki("union", []tangki.Column{})
-	}
-	
-	result := tangkis[0].Clone("union")
-	
-	for i := 1; i < len(tangkis); i++ {
-		for _, row := range tangkis[i].Rows {
-			if columnsMatch(result.Columns, tangkis[i].Columns) {
-				result.Rows = append(result.Rows, row.Clone())
-			}
-		}
-	}
-	
-	return result
+func OrderBy(t *tangki.Tangki, colName string, asc bool) []tangki.Row {
+    rows, _ := OrderByContext(context.Background(), t, colName, asc)
+    return rows
 }
 
-func OrderBy(t *tangki.Tangki, colName string, asc bool) []tangki.Row {
+// OrderByContext adalah OrderBy yang berhenti dengan error ctx begitu ctx
+// dibatalkan.
+func OrderByContext(ctx context.Context, t *tangki.Tangki, colName string, asc bool) ([]tangki.Row, error) {
     idx := t.GetColumnIndex(colName)
     if idx == -1 {
-        return t.Rows
+        return t.Rows, nil
+    }
+
+    c := NewCanceler(ctx)
+    if cols := t.Columnar(); cols != nil && cols.Vectors[idx] != nil {
+        perm := orderVector(c, cols.Vectors[idx], !asc)
+        if err := c.Err(); err != nil {
+            return nil, err
+        }
+        sortedRows := make([]tangki.Row, len(perm))
+        for i, p := range perm {
+            sortedRows[i] = t.Rows[p]
+        }
+        return sortedRows, nil
     }
 
     sortedRows := make([]tangki.Row, len(t.Rows))
     copy(sortedRows, t.Rows)
 
     sort.Slice(sortedRows, func(i, j int) bool {
+        if c.Canceled() {
+            return false
+        }
         val1 := toFloatAJAX(sortedRows[i][idx])
         val2 := toFloatAJAX(sortedRows[j][idx])
 
@@ -134,11 +120,19 @@ func OrderBy(t *tangki.Tangki, colName string, asc bool) []tangki.Row {
         }
         return val1 > val2
     })
-
-    return sortedRows
+    if err := c.Err(); err != nil {
+        return nil, err
+    }
+    return sortedRows, nil
 }
 
 func GroupBy(t *tangki.Tangki, groupCol, aggFunc, aggCol string) ([]tangki.Row, error) {
+    return GroupByContext(context.Background(), t, groupCol, aggFunc, aggCol)
+}
+
+// GroupByContext adalah GroupBy yang berhenti dengan error ctx begitu ctx
+// dibatalkan.
+func GroupByContext(ctx context.Context, t *tangki.Tangki, groupCol, aggFunc, aggCol string) ([]tangki.Row, error) {
     groupIdx := t.GetColumnIndex(groupCol)
     aggIdx := -1
     if aggCol != "" {
@@ -149,82 +143,111 @@ func GroupBy(t *tangki.Tangki, groupCol, aggFunc, aggCol string) ([]tangki.Row,
         return nil, fmt.Errorf("kolom group '%s' tidak ditemukan", groupCol)
     }
 
-    groups := make(map[string][]tangki.Row)
-    for _, row := range t.Rows {
-        key := fmt.Sprintf("%v", row[groupIdx]) 
-        groups[key] = append(groups[key], row)
+    c := NewCanceler(ctx)
+
+    // Cache kolom: kelompokkan dan agregasi langsung atas vektor bertipe
+    if cols := t.Columnar(); cols != nil && (aggCol == "" || aggIdx != -1) {
+        fn := aggFunc
+        if aggCol == "" {
+            fn = ""
+        }
+        if results, ok, err := groupByVectors(c, cols, groupIdx, fn, aggIdx); ok {
+            if err == nil {
+                err = c.Err()
+            }
+            if err != nil {
+                return nil, err
+            }
+            return results, nil
+        }
+    }
+
+    withAgg := aggFunc != "" && aggCol != ""
+    if withAgg {
+        if aggIdx == -1 {
+            return nil, fmt.Errorf("kolom agregasi '%s' tidak ditemukan", aggCol)
+        }
+        switch aggFunc {
+        case "SUM", "AVG", "MAX", "MIN", "COUNT":
+        default:
+            if t.Len() > 0 {
+                return nil, fmt.Errorf("fungsi agregasi tidak dikenal: %s", aggFunc)
+            }
+        }
+    }
+
+    // Baris dibaca satu per satu dan hanya hasil agregasi per grup yang
+    // disimpan, jadi tangki berhalaman tidak dimuat seluruhnya
+    groups := make(map[string]*groupAcc)
+    err := t.Scan(func(row tangki.Row) bool {
+        if c.Canceled() {
+            return false
+        }
+        key := fmt.Sprintf("%v", row[groupIdx])
+        acc, ok := groups[key]
+        if !ok {
+            acc = &groupAcc{}
+            groups[key] = acc
+        }
+        if withAgg {
+            acc.add(aggFunc, toFloatAJAX(row[aggIdx]))
+        } else {
+            acc.count++
+        }
+        return true
+    })
+    if err == nil {
+        err = c.Err()
+    }
+    if err != nil {
+        return nil, err
     }
 
     results := make([]tangki.Row, 0, len(groups))
 
-    for key, rows := range groups {
+    for key, acc := range groups {
         result := make(tangki.Row, 2) 
         result[0] = key 
 
-        if aggFunc != "" && aggCol != "" {
-            aggValue, err := aggregateByIndex(rows, aggFunc, aggIdx)
-            if err != nil {
-                return nil, err
-            }
-            result[1] = aggValue
+        if withAgg {
+            result[1] = acc.result(aggFunc)
         } else {
-            result[1] = len(rows) 
+            result[1] = acc.count 
         }
         results = append(results, result)
     }
 
     return results, nil
 }
-func aggregateByIndex(rows []tangki.Row, funcName string, colIdx int) (float64, error) {
-    if len(rows) == 0 {
-        return 0, nil
-    }
 
-    var result float64
-    
+// groupAcc menyimpan agregasi berjalan satu grup GroupBy.
+type groupAcc struct {
+    count int
+    value float64
+}
+
+func (a *groupAcc) add(funcName string, val float64) {
+    a.count++
     switch funcName {
     case "SUM", "AVG":
-        for _, row := range rows {
-            result += toFloatAJAX(row[colIdx])
-        }
-        if funcName == "AVG" {
-            result = result / float64(len(rows))
-        }
+        a.value += val
     case "MAX":
-        result = toFloatAJAX(rows[0][colIdx])
-        for _, row := range rows {
-            val := toFloatAJAX(row[colIdx])
-            if val > result {
-                result = val
-            }
+        if a.count == 1 || val > a.value {
+            a.value = val
         }
     case "MIN":
-        result = toFloatAJAX(rows[0][colIdx])
-        for _, row := range rows {
-            val := toFloatAJAX(row[colIdx])
-            if val < result {
-                result = val
-            }
+        if a.count == 1 || val < a.value {
+            a.value = val
         }
-    case "COUNT":
-        return float64(len(rows)), nil
-    default:
-        return 0, fmt.Errorf("fungsi agregasi tidak dikenal: %s", funcName)
     }
-
-    return result, nil
 }
 
-func columnsMatch(cols1, cols2 []tangki.Column) bool {
-	if len(cols1) != len(cols2) {
-		return false
-	}
-	
-	for i := range cols1 {
-		if cols1[i].Name != cols2[i].Name || cols1[i].Type != cols2[i].Type {
-			return false
-		}
-	}
-	
-	return true
-}
\ No newline at end of file
+func (a *groupAcc) result(funcName string) float64 {
+    switch funcName {
+    case "AVG":
+        return a.value / float64(a.count)
+    case "COUNT":
+        return float64(a.count)
+    }
+    return a.value
+}
@@ -0,0 +1,276 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Evaluator menghitung nilai sebuah ekspresi untuk satu baris.
+// Predikat menghasilkan bool, atau nil bila hasilnya tidak diketahui (NULL).
+type Evaluator func(row tangki.Row) interface{}
+
+// SubqueryRunner disediakan oleh engine agar ekspresi bisa menjalankan
+// subquery terhadap tangki yang ada.
+type SubqueryRunner interface {
+	// SourceSchema mengembalikan schema DARI + GABUNG sebelum proyeksi.
+	SourceSchema(stmt *parser.SelectStmt) (Schema, error)
+	// RunSubquery mengeksekusi stmt dengan outer sebagai scope luar.
+	RunSubquery(stmt *parser.SelectStmt, outer *Scope) (*Relation, error)
+}
+
+// Scope adalah baris query luar yang sedang dievaluasi, dipakai oleh
+// subquery berkorelasi untuk membaca kolom luar.
+type Scope struct {
+	Schema Schema
+	Row    tangki.Row
+	Parent *Scope
+}
+
+// Env adalah konteks kompilasi ekspresi untuk satu eksekusi query.
+// Env nil berarti tidak ada scope luar dan subquery tidak didukung.
+// Ctx (boleh nil) menghentikan eksekusi saat dibatalkan.
+type Env struct {
+	Outer   *Scope
+	Runner  SubqueryRunner
+	Ctx     context.Context
+	err     error
+	cancel  *Canceler
+	windows map[*parser.WindowExpr]int
+}
+
+// Err mengembalikan error pertama yang terjadi saat evaluasi.
+func (env *Env) Err() error {
+	if env == nil {
+		return nil
+	}
+	return env.err
+}
+
+func (env *Env) setErr(err error) {
+	if env.err == nil {
+		env.err = err
+	}
+}
+
+func (env *Env) outer() *Scope {
+	if env == nil {
+		return nil
+	}
+	return env.Outer
+}
+
+// CompileExpr mengikat kolom-kolom di expr ke posisi dalam schema.
+func CompileExpr(expr parser.Expr, schema Schema) (Evaluator, error) {
+	return (*Env)(nil).Compile(expr, schema)
+}
+
+// CompilePredicate seperti CompileExpr tetapi hanya menerima baris yang
+// hasilnya benar-benar true. Predikat nil menerima semua baris.
+func CompilePredicate(expr parser.Expr, schema Schema) (func(tangki.Row) bool, error) {
+	return (*Env)(nil).CompilePredicate(expr, schema)
+}
+
+// Compile mengikat kolom-kolom di expr ke posisi dalam schema. Kolom yang
+// tidak ada di schema dicari di scope luar (subquery berkorelasi).
+func (env *Env) Compile(expr parser.Expr, schema Schema) (Evaluator, error) {
+	switch e := expr.(type) {
+	case *parser.Literal:
+		val := e.Value
+		return func(tangki.Row) interface{} { return val }, nil
+
+	case *parser.ColumnRef:
+		return env.compileColumn(e, schema)
+
+	case *parser.BinaryExpr:
+		return env.compileBinary(e, schema)
+
+	case *parser.SubqueryExpr:
+		return env.compileScalarSubquery(e, schema)
+
+	case *parser.InExpr:
+		return env.compileIn(e, schema)
+
+	case *parser.ExistsExpr:
+		return env.compileExists(e, schema)
+
+	case *parser.WindowExpr:
+		return env.compileWindowRef(e)
+	}
+	return nil, fmt.Errorf("ekspresi tidak didukung: %T", expr)
+}
+
+// CompilePredicate adalah versi Compile yang menghasilkan filter baris.
+func (env *Env) CompilePredicate(expr parser.Expr, schema Schema) (func(tangki.Row) bool, error) {
+	if expr == nil {
+		return func(tangki.Row) bool { return true }, nil
+	}
+	eval, err := env.Compile(expr, schema)
+	if err != nil {
+		return nil, err
+	}
+	return func(row tangki.Row) bool {
+		b, _ := eval(row).(bool)
+		return b
+	}, nil
+}
+
+func (env *Env) compileColumn(ref *parser.ColumnRef, schema Schema) (Evaluator, error) {
+	idx, err := schema.Resolve(ref.Table, ref.Column)
+	if err == nil {
+		return func(row tangki.Row) interface{} { return row[idx] }, nil
+	}
+	if isAmbiguous(schema, ref.Table, ref.Column) {
+		return nil, err
+	}
+
+	for scope := env.outer(); scope != nil; scope = scope.Parent {
+		if oidx, oerr := scope.Schema.Resolve(ref.Table, ref.Column); oerr == nil {
+			s := scope
+			return func(tangki.Row) interface{} { return s.Row[oidx] }, nil
+		}
+	}
+	return nil, err
+}
+
+func (env *Env) compileBinary(e *parser.BinaryExpr, schema Schema) (Evaluator, error) {
+	compileOperand := env.Compile
+	if isComparisonOperator(e.Operator) {
+		compileOperand = env.compileComparisonOperand
+	}
+	left, err := compileOperand(e.Left, schema)
+	if err != nil {
+		return nil, err
+	}
+	right, err := compileOperand(e.Right, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Operator {
+	case "DAN":
+		return func(row tangki.Row) interface{} {
+			return logicalAnd(left(row), right(row))
+		}, nil
+	case "ATAU":
+		return func(row tangki.Row) interface{} {
+			return logicalOr(left(row), right(row))
+		}, nil
+	case "=", "!=", ">", "<", ">=", "<=":
+		op := e.Operator
+		return func(row tangki.Row) interface{} {
+			a, b := left(row), right(row)
+			if a == nil || b == nil {
+				return nil
+			}
+			return compareValues(a, op, b)
+		}, nil
+	case "+", "-", "*", "/":
+		op := e.Operator
+		return func(row tangki.Row) interface{} {
+			return arithmetic(left(row), op, right(row))
+		}, nil
+	}
+	return nil, fmt.Errorf("operator tidak didukung: %s", e.Operator)
+}
+
+// compileComparisonOperand mempertahankan perilaku lama DIMANA divisi = IT:
+// identifier tanpa kualifikasi yang bukan nama kolom dibaca sebagai teks.
+func (env *Env) compileComparisonOperand(expr parser.Expr, schema Schema) (Evaluator, error) {
+	eval, err := env.Compile(expr, schema)
+	if err == nil {
+		return eval, nil
+	}
+	if ref, ok := expr.(*parser.ColumnRef); ok && ref.Table == "" && !isAmbiguous(schema, "", ref.Column) {
+		text := ref.Column
+		return func(tangki.Row) interface{} { return text }, nil
+	}
+	return nil, err
+}
+
+func isAmbiguous(schema Schema, table, name string) bool {
+	n := 0
+	for _, f := range schema {
+		if f.matches(table, name) {
+			n++
+		}
+	}
+	return n > 1
+}
+
+func logicalAnd(a, b interface{}) interface{} {
+	ab, aok := a.(bool)
+	bb, bok := b.(bool)
+	if (aok && !ab) || (bok && !bb) {
+		return false
+	}
+	if !aok || !bok {
+		return nil
+	}
+	return true
+}
+
+func logicalOr(a, b interface{}) interface{} {
+	ab, aok := a.(bool)
+	bb, bok := b.(bool)
+	if (aok && ab) || (bok && bb) {
+		return true
+	}
+	if !aok || !bok {
+		return nil
+	}
+	return false
+}
+
+func arithmetic(a interface{}, op string, b interface{}) interface{} {
+	if a == nil || b == nil {
+		return nil
+	}
+
+	if sa, ok := a.(string); ok && op == "+" {
+		if sb, ok := b.(string); ok {
+			return sa + sb
+		}
+	}
+
+	ia, aInt := asInt64(a)
+	ib, bInt := asInt64(b)
+	if aInt && bInt && op != "/" {
+		switch op {
+		case "+":
+			return ia + ib
+		case "-":
+			return ia - ib
+		case "*":
+			return ia * ib
+		}
+	}
+
+	fa, fb := toFloatAJAX(a), toFloatAJAX(b)
+	switch op {
+	case "+":
+		return fa + fb
+	case "-":
+		return fa - fb
+	case "*":
+		return fa * fb
+	case "/":
+		if fb == 0 {
+			return nil
+		}
+		return fa / fb
+	}
+	return nil
+}
+
+func asInt64(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), true
+	case int64:
+		return val, true
+	}
+	return 0, false
+}
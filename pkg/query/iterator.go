@@ -0,0 +1,78 @@
+package query
+
+import (
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Iterator menghasilkan baris keluaran operator satu per satu (model
+// volcano). Next mengembalikan false bila baris habis atau terjadi error;
+// panggil Err untuk membedakan keduanya.
+type Iterator interface {
+	Schema() Schema
+	Next() (tangki.Row, bool)
+	Err() error
+}
+
+// Execute menjalankan op sampai habis dan mengumpulkan seluruh barisnya.
+func Execute(op Operator, env *Env) (*Relation, error) {
+	it, err := op.Open(env)
+	if err != nil {
+		return nil, err
+	}
+	return Collect(it)
+}
+
+// Collect menarik semua baris dari it menjadi relasi.
+func Collect(it Iterator) (*Relation, error) {
+	rows := make([]tangki.Row, 0)
+	for {
+		row, ok := it.Next()
+		if !ok {
+			break
+		}
+		rows = append(rows, row)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return &Relation{Schema: it.Schema(), Rows: rows}, nil
+}
+
+// sliceIter membaca relasi yang sudah dihitung, dipakai oleh operator
+// blocking (join, window, operasi himpunan) yang butuh seluruh inputnya.
+type sliceIter struct {
+	rel *Relation
+	pos int
+}
+
+func newSliceIter(rel *Relation) *sliceIter { return &sliceIter{rel: rel} }
+
+// Iterate membungkus relasi yang sudah dihitung sebagai Iterator.
+func Iterate(rel *Relation) Iterator { return newSliceIter(rel) }
+
+func (it *sliceIter) Schema() Schema { return it.rel.Schema }
+func (it *sliceIter) Err() error     { return nil }
+
+func (it *sliceIter) Next() (tangki.Row, bool) {
+	if it.pos >= len(it.rel.Rows) {
+		return nil, false
+	}
+	row := it.rel.Rows[it.pos]
+	it.pos++
+	return row, true
+}
+
+// countIter menghitung baris yang sudah ditarik dari sebuah operator
+// untuk kolom aktual di JELASKAN.
+type countIter struct {
+	Iterator
+	stats *opStats
+}
+
+func (it *countIter) Next() (tangki.Row, bool) {
+	row, ok := it.Iterator.Next()
+	if ok {
+		it.stats.actual++
+	}
+	return row, ok
+}
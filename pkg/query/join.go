@@ -0,0 +1,548 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Strategi join yang dipilih berdasarkan ukuran dan urutan input.
+const (
+	StrategyNestedLoop = "NESTED_LOOP"
+	StrategyHash       = "HASH"
+	StrategyMerge      = "MERGE"
+)
+
+// Jenis join yang didukung.
+const (
+	JoinInner = "INNER"
+	JoinLeft  = "LEFT"
+	JoinRight = "RIGHT"
+	JoinFull  = "FULL"
+	JoinCross = "CROSS"
+)
+
+// JoinCondition adalah predikat join kiri.kolom <op> kanan.kolom.
+type JoinCondition struct {
+	LeftColumn  string
+	Operator    string
+	RightColumn string
+}
+
+// nestedLoopLimit adalah batas jumlah pasangan baris di mana nested loop
+// masih lebih murah daripada membangun hash table.
+const nestedLoopLimit = 1024
+
+// Join menggabungkan dua tangki dengan equi-join t1.col1 = t2.col2.
+func Join(t1, t2 *tangki.Tangki, col1, col2 string) (*tangki.Tangki, error) {
+	idx1 := t1.GetColumnIndex(col1)
+	if idx1 == -1 {
+		return nil, fmt.Errorf("kolom '%s' tidak ditemukan di tangki '%s'", col1, t1.Name)
+	}
+	idx2 := t2.GetColumnIndex(col2)
+	if idx2 == -1 {
+		return nil, fmt.Errorf("kolom '%s' tidak ditemukan di tangki '%s'", col2, t2.Name)
+	}
+
+	allColumns := QualifyDuplicateColumns(t1.Columns, t2.Columns, t1.Name, t2.Name)
+	result := tangki.NewTangki("joined", allColumns)
+
+	result.Rows = EquiJoinRows(t1.Rows, t2.Rows, []int{idx1}, []int{idx2})
+	return result, nil
+}
+
+// JoinWith menggabungkan dua tangki dengan jenis join dan satu atau lebih
+// predikat. Predikat "=" dipakai sebagai kunci hash, sisanya dievaluasi
+// per pasangan baris. Baris yang tidak punya pasangan pada outer join
+// diisi NULL (nil) di sisi lainnya.
+func JoinWith(t1, t2 *tangki.Tangki, joinType string, conds []JoinCondition) (*tangki.Tangki, error) {
+	if joinType == JoinCross && len(conds) > 0 {
+		return nil, fmt.Errorf("join SILANG tidak menerima kondisi")
+	}
+	if joinType != JoinCross && len(conds) == 0 {
+		return nil, fmt.Errorf("join %s membutuhkan kondisi", joinType)
+	}
+
+	var leftKeys, rightKeys []int
+	var predicates []JoinPredicate
+	for _, c := range conds {
+		li := t1.GetColumnIndex(c.LeftColumn)
+		if li == -1 {
+			return nil, fmt.Errorf("kolom '%s' tidak ditemukan di tangki '%s'", c.LeftColumn, t1.Name)
+		}
+		ri := t2.GetColumnIndex(c.RightColumn)
+		if ri == -1 {
+			return nil, fmt.Errorf("kolom '%s' tidak ditemukan di tangki '%s'", c.RightColumn, t2.Name)
+		}
+		if !isComparisonOperator(c.Operator) {
+			return nil, fmt.Errorf("operator join tidak didukung: %s", c.Operator)
+		}
+
+		if c.Operator == "=" {
+			leftKeys = append(leftKeys, li)
+			rightKeys = append(rightKeys, ri)
+		} else {
+			predicates = append(predicates, JoinPredicate{LeftIndex: li, Operator: c.Operator, RightIndex: ri})
+		}
+	}
+
+	var residual func(l, r tangki.Row) bool
+	if len(predicates) > 0 {
+		residual = func(l, r tangki.Row) bool {
+			for _, p := range predicates {
+				a, b := l[p.LeftIndex], r[p.RightIndex]
+				if a == nil || b == nil || !compareValues(a, p.Operator, b) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	allColumns := QualifyDuplicateColumns(t1.Columns, t2.Columns, t1.Name, t2.Name)
+	result := tangki.NewTangki("joined", allColumns)
+
+	result.Rows = JoinRows(t1.Rows, t2.Rows, len(t1.Columns), len(t2.Columns), joinType, leftKeys, rightKeys, residual)
+	return result, nil
+}
+
+// JoinPredicate adalah predikat non-equi antara kolom kiri dan kanan
+// yang dievaluasi setelah kunci join cocok.
+type JoinPredicate struct {
+	LeftIndex  int
+	Operator   string
+	RightIndex int
+}
+
+// JoinRows menjalankan join dengan jenis apa pun pada dua kumpulan baris.
+// residual (boleh nil) dievaluasi setelah kunci cocok. leftWidth dan
+// rightWidth dipakai untuk mengisi NULL pada outer join.
+func JoinRows(left, right []tangki.Row, leftWidth, rightWidth int, joinType string, leftKeys, rightKeys []int, residual func(l, r tangki.Row) bool) []tangki.Row {
+	return joinRows(nil, left, right, leftWidth, rightWidth, joinType, leftKeys, rightKeys, residual)
+}
+
+// joinRows adalah JoinRows yang berhenti lebih awal bila c dibatalkan;
+// pemanggil harus memeriksa c.Err() karena hasilnya tidak lengkap.
+func joinRows(c *Canceler, left, right []tangki.Row, leftWidth, rightWidth int, joinType string, leftKeys, rightKeys []int, residual func(l, r tangki.Row) bool) []tangki.Row {
+	if joinType == JoinInner && residual == nil && len(leftKeys) > 0 {
+		return equiJoin(c, left, right, leftKeys, rightKeys)
+	}
+
+	match := residual
+	if match == nil {
+		match = func(l, r tangki.Row) bool { return true }
+	}
+
+	keepLeft := joinType == JoinLeft || joinType == JoinFull
+	keepRight := joinType == JoinRight || joinType == JoinFull
+	rightMatched := make([]bool, len(right))
+	results := make([]tangki.Row, 0, len(left))
+
+	emitLeft := func(lrow tangki.Row, candidates []int) {
+		found := false
+		for _, ri := range candidates {
+			if c.Canceled() {
+				return
+			}
+			if match(lrow, right[ri]) {
+				results = append(results, concatRows(lrow, right[ri]))
+				rightMatched[ri] = true
+				found = true
+			}
+		}
+		if !found && keepLeft {
+			results = append(results, concatRows(lrow, make(tangki.Row, rightWidth)))
+		}
+	}
+
+	if len(leftKeys) > 0 {
+		table := make(map[string][]int, len(right))
+		for i, row := range right {
+			key, ok := JoinKey(row, rightKeys)
+			if !ok {
+				continue
+			}
+			table[key] = append(table[key], i)
+		}
+		for _, lrow := range left {
+			if c.Canceled() {
+				return results
+			}
+			key, ok := JoinKey(lrow, leftKeys)
+			if !ok {
+				emitLeft(lrow, nil)
+				continue
+			}
+			emitLeft(lrow, table[key])
+		}
+	} else {
+		all := make([]int, len(right))
+		for i := range all {
+			all[i] = i
+		}
+		for _, lrow := range left {
+			if c.Canceled() {
+				return results
+			}
+			emitLeft(lrow, all)
+		}
+	}
+
+	if keepRight {
+		for i, rrow := range right {
+			if !rightMatched[i] {
+				results = append(results, concatRows(make(tangki.Row, leftWidth), rrow))
+			}
+		}
+	}
+	return results
+}
+
+func isComparisonOperator(op string) bool {
+	switch op {
+	case "=", "!=", ">", "<", ">=", "<=":
+		return true
+	}
+	return false
+}
+
+// ChooseJoinStrategy memilih algoritma equi-join untuk dua input. Merge
+// join dipilih bila kedua input sudah terurut pada satu kolom kunci dengan
+// jenis nilai yang sama; belum ada struktur indeks, jadi urutan diperiksa
+// dari barisnya. Semua strategi memakai aturan kesamaan yang sama (lihat
+// JoinKey), jadi pilihan strategi tidak mengubah hasil.
+func ChooseJoinStrategy(left, right []tangki.Row, leftKeys, rightKeys []int) string {
+	if len(left)*len(right) <= nestedLoopLimit {
+		return StrategyNestedLoop
+	}
+	if len(leftKeys) == 1 {
+		kind := sortedKind(left, leftKeys[0])
+		if kind != "" && kind == sortedKind(right, rightKeys[0]) {
+			return StrategyMerge
+		}
+	}
+	return StrategyHash
+}
+
+// EquiJoinRows menjalankan inner equi-join pada dua kumpulan baris.
+// Baris hasil adalah gabungan baris kiri diikuti baris kanan.
+func EquiJoinRows(left, right []tangki.Row, leftKeys, rightKeys []int) []tangki.Row {
+	return equiJoin(nil, left, right, leftKeys, rightKeys)
+}
+
+func equiJoin(c *Canceler, left, right []tangki.Row, leftKeys, rightKeys []int) []tangki.Row {
+	return equiJoinWith(c, ChooseJoinStrategy(left, right, leftKeys, rightKeys), left, right, leftKeys, rightKeys)
+}
+
+// EquiJoinRowsWith seperti EquiJoinRows dengan strategi tertentu. Merge
+// join hanya benar bila kedua input terurut pada kolom kuncinya.
+func EquiJoinRowsWith(strategy string, left, right []tangki.Row, leftKeys, rightKeys []int) []tangki.Row {
+	return equiJoinWith(nil, strategy, left, right, leftKeys, rightKeys)
+}
+
+func equiJoinWith(c *Canceler, strategy string, left, right []tangki.Row, leftKeys, rightKeys []int) []tangki.Row {
+	switch strategy {
+	case StrategyNestedLoop:
+		return nestedLoopJoin(c, left, right, leftKeys, rightKeys)
+	case StrategyMerge:
+		return mergeJoin(c, left, right, leftKeys[0], rightKeys[0])
+	default:
+		return hashJoin(c, left, right, leftKeys, rightKeys)
+	}
+}
+
+func nestedLoopJoin(c *Canceler, left, right []tangki.Row, leftKeys, rightKeys []int) []tangki.Row {
+	results := make([]tangki.Row, 0)
+	for _, row1 := range left {
+		for _, row2 := range right {
+			if c.Canceled() {
+				return results
+			}
+			if keysEqual(row1, row2, leftKeys, rightKeys) {
+				results = append(results, concatRows(row1, row2))
+			}
+		}
+	}
+	return results
+}
+
+// hashJoin membangun hash table dari sisi yang lebih kecil lalu
+// melakukan probe dari sisi lainnya.
+func hashJoin(c *Canceler, left, right []tangki.Row, leftKeys, rightKeys []int) []tangki.Row {
+	buildLeft := len(left) < len(right)
+
+	build, buildKeys := right, rightKeys
+	probe, probeKeys := left, leftKeys
+	if buildLeft {
+		build, buildKeys = left, leftKeys
+		probe, probeKeys = right, rightKeys
+	}
+
+	table := make(map[string][]int, len(build))
+	for i, row := range build {
+		if c.Canceled() {
+			return nil
+		}
+		key, ok := JoinKey(row, buildKeys)
+		if !ok {
+			continue
+		}
+		table[key] = append(table[key], i)
+	}
+
+	results := make([]tangki.Row, 0, len(probe))
+	for _, prow := range probe {
+		if c.Canceled() {
+			return results
+		}
+		key, ok := JoinKey(prow, probeKeys)
+		if !ok {
+			continue
+		}
+		for _, bi := range table[key] {
+			if buildLeft {
+				results = append(results, concatRows(build[bi], prow))
+			} else {
+				results = append(results, concatRows(prow, build[bi]))
+			}
+		}
+	}
+	return results
+}
+
+// mergeJoin mengasumsikan kedua input sudah terurut menaik pada kolom kunci.
+func mergeJoin(c *Canceler, left, right []tangki.Row, leftKey, rightKey int) []tangki.Row {
+	results := make([]tangki.Row, 0)
+	i, j := 0, 0
+	for i < len(left) && j < len(right) {
+		if c.Canceled() {
+			return results
+		}
+		lv, rv := left[i][leftKey], right[j][rightKey]
+		if lv == nil {
+			i++
+			continue
+		}
+		if rv == nil {
+			j++
+			continue
+		}
+
+		c := compareKeys(lv, rv)
+		if c < 0 {
+			i++
+			continue
+		}
+		if c > 0 {
+			j++
+			continue
+		}
+
+		// Cari rentang nilai yang sama di kedua sisi
+		iEnd := i
+		for iEnd < len(left) && compareKeys(left[iEnd][leftKey], lv) == 0 {
+			iEnd++
+		}
+		jEnd := j
+		for jEnd < len(right) && compareKeys(right[jEnd][rightKey], rv) == 0 {
+			jEnd++
+		}
+		for a := i; a < iEnd; a++ {
+			for b := j; b < jEnd; b++ {
+				results = append(results, concatRows(left[a], right[b]))
+			}
+		}
+		i, j = iEnd, jEnd
+	}
+	return results
+}
+
+// JoinKey menyusun kunci hash dari kolom-kolom kunci sebuah baris, dan
+// sekaligus menjadi aturan kesamaan semua strategi join: dua nilai sama
+// bila kuncinya sama. Teks tidak pernah sama dengan angka ('1' tidak sama
+// dengan 1). INT dibandingkan utuh sebagai int64, dan FLOAT sama dengan
+// INT hanya bila nilainya bulat dan tepat sama. Kunci yang mengandung NULL
+// tidak pernah cocok.
+func JoinKey(row tangki.Row, keys []int) (string, bool) {
+	if len(keys) == 1 {
+		return keyPart(row[keys[0]])
+	}
+
+	var sb strings.Builder
+	for i, k := range keys {
+		part, ok := keyPart(row[k])
+		if !ok {
+			return "", false
+		}
+		if i > 0 {
+			sb.WriteByte(0)
+		}
+		sb.WriteString(part)
+	}
+	return sb.String(), true
+}
+
+func keyPart(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return "s" + val, true
+	case int:
+		return "n" + strconv.FormatInt(int64(val), 10), true
+	case int64:
+		return "n" + strconv.FormatInt(val, 10), true
+	case float64:
+		if n, ok := exactInt(val); ok {
+			return "n" + strconv.FormatInt(n, 10), true
+		}
+		return "f" + strconv.FormatFloat(val, 'g', -1, 64), true
+	default:
+		return fmt.Sprintf("?%v", val), true
+	}
+}
+
+// exactInt mengembalikan f sebagai int64 bila f bulat dan muat tepat.
+func exactInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < -(1<<63) || f >= 1<<63 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func keysEqual(row1, row2 tangki.Row, leftKeys, rightKeys []int) bool {
+	for i := range leftKeys {
+		a, ok := keyPart(row1[leftKeys[i]])
+		if !ok {
+			return false
+		}
+		if b, ok := keyPart(row2[rightKeys[i]]); !ok || a != b {
+			return false
+		}
+	}
+	return true
+}
+
+// compareKeys mengurutkan dua nilai kunci untuk merge join, konsisten
+// dengan JoinKey: angka selalu sebelum teks, dan INT dibandingkan utuh
+// sebagai int64.
+func compareKeys(a, b interface{}) int {
+	sa, aStr := a.(string)
+	sb, bStr := b.(string)
+	switch {
+	case aStr && bStr:
+		return strings.Compare(sa, sb)
+	case aStr:
+		return 1
+	case bStr:
+		return -1
+	}
+	ia, aInt := keyInt(a)
+	ib, bInt := keyInt(b)
+	if aInt && bInt {
+		return cmpInt64(ia, ib)
+	}
+	fa, fb := toFloatAJAX(a), toFloatAJAX(b)
+	switch {
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return 1
+	}
+	return 0
+}
+
+// keyInt mengembalikan nilai bulat v, termasuk FLOAT yang bulat.
+func keyInt(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), true
+	case int64:
+		return val, true
+	case float64:
+		return exactInt(val)
+	}
+	return 0, false
+}
+
+func cmpInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// CompareOrder membandingkan dua nilai untuk keperluan pengurutan.
+// Angka dibandingkan secara numerik, teks secara leksikografis,
+// dan NULL selalu dianggap paling kecil.
+func CompareOrder(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	sa, aStr := a.(string)
+	sb, bStr := b.(string)
+	if aStr && bStr {
+		return strings.Compare(sa, sb)
+	}
+
+	fa, fb := toFloatAJAX(a), toFloatAJAX(b)
+	switch {
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return 1
+	}
+	return 0
+}
+
+// sortedKind mengembalikan jenis nilai ("s" atau "n") kolom idx bila
+// semua baris berjenis sama, tidak NULL, dan terurut menaik; atau "".
+func sortedKind(rows []tangki.Row, idx int) string {
+	kind := ""
+	for i, row := range rows {
+		k := valueKind(row[idx])
+		if k == "" {
+			return ""
+		}
+		if kind == "" {
+			kind = k
+		} else if k != kind {
+			return ""
+		}
+		if i > 0 && compareKeys(rows[i-1][idx], row[idx]) > 0 {
+			return ""
+		}
+	}
+	return kind
+}
+
+func valueKind(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "s"
+	case int, int64, float64:
+		return "n"
+	}
+	return ""
+}
+
+func concatRows(row1, row2 tangki.Row) tangki.Row {
+	joined := make(tangki.Row, len(row1)+len(row2))
+	copy(joined, row1)
+	copy(joined[len(row1):], row2)
+	return joined
+}
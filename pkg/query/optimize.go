@@ -0,0 +1,393 @@
+package query
+
+import (
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+)
+
+// Optimize menulis ulang rencana logis dengan aturan berbasis heuristik:
+//  1. predikat DIMANA dan PADA didorong turun ke scan atau join terdekat,
+//  2. kolom yang tidak dipakai di atas join dipangkas di scan,
+//  3. rangkaian inner join diurutkan ulang mulai dari input terkecil.
+//
+// Semua tangki dibaca dengan full scan karena belum ada struktur indeks;
+// pilihan algoritma join (hash, merge, nested loop) dilakukan saat eksekusi.
+func Optimize(plan Plan) Plan {
+	switch p := plan.(type) {
+	case *SetOpPlan:
+		p.Left = Optimize(p.Left)
+		p.Right = Optimize(p.Right)
+		return p
+	case *LimitPlan:
+		p.Input = Optimize(p.Input)
+		return p
+	case *ProjectPlan:
+		return optimizeBlock(p)
+	}
+	return plan
+}
+
+// optimizeBlock mengoptimasi satu blok Project -> [Window] -> [Filter] -> join.
+func optimizeBlock(project *ProjectPlan) Plan {
+	var filter *FilterPlan
+	parent := Plan(project)
+	input := project.Input
+	if w, ok := input.(*WindowPlan); ok {
+		parent, input = w, w.Input
+	}
+	if f, ok := input.(*FilterPlan); ok {
+		filter, input = f, f.Input
+	}
+
+	tree := input
+	full := OutputSchema(tree)
+
+	// 1. Pushdown predikat
+	pushJoinConditions(tree, full)
+	if filter != nil {
+		var remaining []parser.Expr
+		for _, conj := range SplitConjuncts(filter.Predicate) {
+			if containsSubquery(conj) || !pushPredicate(tree, conj, full) {
+				remaining = append(remaining, conj)
+			}
+		}
+		filter.Predicate = AndAll(remaining)
+	}
+
+	// 2. Pemangkasan kolom hanya berguna bila ada join yang menyalin baris
+	joined := false
+	if _, ok := tree.(*JoinPlan); ok {
+		joined = true
+	}
+	if joined && !blockHasSubquery(project, filter, tree) {
+		pruneColumns(tree, project.Items, filter)
+	}
+
+	// 3. Urutan join
+	tree = reorderJoins(tree, full, hasStar(project.Items))
+
+	var below Plan = tree
+	if filter != nil && filter.Predicate != nil {
+		filter.Input = tree
+		below = filter
+	}
+	switch p := parent.(type) {
+	case *WindowPlan:
+		p.Input = below
+	case *ProjectPlan:
+		p.Input = below
+	}
+	return project
+}
+
+// pushPredicate mencoba menempelkan pred ke scan atau join terdalam yang
+// memuat semua kolomnya. Predikat tidak pernah didorong ke sisi yang bisa
+// diisi NULL oleh outer join karena hasilnya akan berbeda.
+func pushPredicate(plan Plan, pred parser.Expr, full Schema) bool {
+	switch p := plan.(type) {
+	case *ScanPlan:
+		if refsWithin(pred, OutputSchema(p), full) {
+			p.Filter = andExpr(p.Filter, pred)
+			return true
+		}
+	case *JoinPlan:
+		if p.Type != JoinRight && p.Type != JoinFull && pushPredicate(p.Left, pred, full) {
+			return true
+		}
+		if p.Type != JoinLeft && p.Type != JoinFull && pushPredicate(p.Right, pred, full) {
+			return true
+		}
+		if (p.Type == JoinInner || p.Type == JoinCross) && refsWithin(pred, OutputSchema(p), full) {
+			p.On = andExpr(p.On, pred)
+			p.Type = JoinInner
+			return true
+		}
+	}
+	return false
+}
+
+// pushJoinConditions mendorong konjungsi PADA inner join yang hanya
+// membaca satu sisi ke sisi tersebut. Kondisi outer join dibiarkan karena
+// menentukan baris mana yang diisi NULL.
+func pushJoinConditions(plan Plan, full Schema) {
+	join, ok := plan.(*JoinPlan)
+	if !ok {
+		return
+	}
+	pushJoinConditions(join.Left, full)
+	pushJoinConditions(join.Right, full)
+	if join.Type != JoinInner {
+		return
+	}
+
+	var remaining []parser.Expr
+	for _, conj := range SplitConjuncts(join.On) {
+		pushed := false
+		if !containsSubquery(conj) {
+			if refsWithin(conj, OutputSchema(join.Left), full) {
+				pushed = pushPredicate(join.Left, conj, full)
+			} else if refsWithin(conj, OutputSchema(join.Right), full) {
+				pushed = pushPredicate(join.Right, conj, full)
+			}
+		}
+		if !pushed {
+			remaining = append(remaining, conj)
+		}
+	}
+	join.On = AndAll(remaining)
+	if join.On == nil {
+		join.Type = JoinCross
+	}
+}
+
+// refsWithin memeriksa bahwa setiap kolom di expr yang berasal dari blok
+// ini tersedia di sub. Kolom yang tidak dikenal blok (kolom query luar atau
+// teks lama seperti divisi = IT) tidak menghalangi; kolom ambigu selalu
+// menghalangi agar error-nya tetap muncul di tempat yang sama.
+func refsWithin(expr parser.Expr, sub, full Schema) bool {
+	ok := true
+	walkExpr(expr, func(ref *parser.ColumnRef) {
+		n := 0
+		for _, f := range full {
+			if f.matches(ref.Table, ref.Column) {
+				n++
+			}
+		}
+		if n > 1 {
+			ok = false
+			return
+		}
+		if n == 1 && !resolvable(sub, ref) {
+			ok = false
+		}
+	}, func(*parser.SelectStmt) { ok = false })
+	return ok
+}
+
+func containsSubquery(expr parser.Expr) bool {
+	found := false
+	walkExpr(expr, func(*parser.ColumnRef) {}, func(*parser.SelectStmt) { found = true })
+	return found
+}
+
+func blockHasSubquery(project *ProjectPlan, filter *FilterPlan, tree Plan) bool {
+	for _, item := range project.Items {
+		if containsSubquery(item.Expr) {
+			return true
+		}
+	}
+	if filter != nil && containsSubquery(filter.Predicate) {
+		return true
+	}
+	found := false
+	visitPlan(tree, func(p Plan) {
+		switch n := p.(type) {
+		case *JoinPlan:
+			found = found || containsSubquery(n.On)
+		case *ScanPlan:
+			found = found || containsSubquery(n.Filter)
+		}
+	})
+	return found
+}
+
+// pruneColumns menandai kolom yang dibaca di atas scan (daftar PILIH,
+// DIMANA yang tersisa, dan kondisi join). Filter milik scan sendiri
+// dievaluasi sebelum pemangkasan sehingga tidak perlu dihitung.
+func pruneColumns(tree Plan, items []parser.SelectItem, filter *FilterPlan) {
+	var scans []*ScanPlan
+	var exprs []parser.Expr
+	visitPlan(tree, func(p Plan) {
+		switch n := p.(type) {
+		case *ScanPlan:
+			scans = append(scans, n)
+		case *JoinPlan:
+			exprs = append(exprs, n.On)
+		}
+	})
+	if filter != nil {
+		exprs = append(exprs, filter.Predicate)
+	}
+
+	needed := make(map[*ScanPlan]map[int]bool)
+	for _, scan := range scans {
+		needed[scan] = make(map[int]bool)
+	}
+	mark := func(table, name string, star bool) {
+		for _, scan := range scans {
+			for i, f := range qualifyFields(scan.Source.Schema, scan.Alias) {
+				if star && (table == "" || strings.EqualFold(f.Table, table)) || !star && f.matches(table, name) {
+					needed[scan][i] = true
+				}
+			}
+		}
+	}
+
+	for _, item := range items {
+		if item.Star {
+			mark(item.Table, "", true)
+			continue
+		}
+		exprs = append(exprs, item.Expr)
+	}
+	for _, expr := range exprs {
+		walkExpr(expr, func(ref *parser.ColumnRef) { mark(ref.Table, ref.Column, false) }, func(*parser.SelectStmt) {})
+	}
+
+	for _, scan := range scans {
+		if len(needed[scan]) == len(scan.Source.Schema) {
+			continue
+		}
+		cols := make([]int, 0, len(needed[scan]))
+		for i := range scan.Source.Schema {
+			if needed[scan][i] {
+				cols = append(cols, i)
+			}
+		}
+		scan.Columns = cols
+	}
+}
+
+// reorderJoins mengurutkan ulang rangkaian INNER/SILANG join dengan tiga
+// input atau lebih secara greedy: mulai dari input dengan perkiraan baris
+// terkecil, lalu tambahkan input terkecil yang terhubung lewat kondisi =.
+// Bila urutan berubah dan ada PILIH *, ReorderPlan mengembalikan urutan
+// kolom semula.
+func reorderJoins(tree Plan, full Schema, keepOrder bool) Plan {
+	leaves, conds, ok := flattenInnerJoins(tree)
+	if !ok || len(leaves) < 3 {
+		return tree
+	}
+
+	original := OutputSchema(tree)
+	remaining := append([]Plan{}, leaves...)
+	used := make([]bool, len(conds))
+
+	pick := func(i int) Plan {
+		p := remaining[i]
+		remaining = append(remaining[:i], remaining[i+1:]...)
+		return p
+	}
+
+	best := 0
+	for i, leaf := range remaining {
+		if Estimate(leaf) < Estimate(remaining[best]) {
+			best = i
+		}
+	}
+	current := pick(best)
+	order := []Plan{current}
+
+	for len(remaining) > 0 {
+		curSchema := OutputSchema(current)
+		best, bestConnected := -1, false
+		for i, leaf := range remaining {
+			connected := false
+			for j, c := range conds {
+				if !used[j] {
+					if _, _, ok := equiJoinKey(c, curSchema, OutputSchema(leaf)); ok {
+						connected = true
+						break
+					}
+				}
+			}
+			switch {
+			case best == -1,
+				connected && !bestConnected,
+				connected == bestConnected && Estimate(leaf) < Estimate(remaining[best]):
+				best, bestConnected = i, connected
+			}
+		}
+
+		right := pick(best)
+		order = append(order, right)
+		join := &JoinPlan{Left: current, Right: right, Type: JoinInner}
+		joinedSchema := OutputSchema(join)
+		var on []parser.Expr
+		for j, c := range conds {
+			if !used[j] && refsWithin(c, joinedSchema, full) {
+				on = append(on, c)
+				used[j] = true
+			}
+		}
+		if len(on) == 0 {
+			join.Type = JoinCross
+		}
+		join.On = AndAll(on)
+		current = join
+	}
+
+	// Kondisi yang tidak bisa ditempatkan (misalnya berisi subquery)
+	// dievaluasi di join paling atas
+	var rest []parser.Expr
+	for j, c := range conds {
+		if !used[j] {
+			rest = append(rest, c)
+		}
+	}
+	if len(rest) > 0 {
+		top := current.(*JoinPlan)
+		top.On = andExpr(top.On, AndAll(rest))
+		top.Type = JoinInner
+	}
+
+	changed := false
+	for i := range leaves {
+		if leaves[i] != order[i] {
+			changed = true
+		}
+	}
+	if changed && keepOrder {
+		return &ReorderPlan{Input: current, Schema: original}
+	}
+	return current
+}
+
+// flattenInnerJoins mengumpulkan input dan konjungsi PADA dari pohon join
+// yang seluruhnya INNER atau SILANG.
+func flattenInnerJoins(plan Plan) ([]Plan, []parser.Expr, bool) {
+	join, ok := plan.(*JoinPlan)
+	if !ok {
+		return []Plan{plan}, nil, true
+	}
+	if join.Type != JoinInner && join.Type != JoinCross {
+		return nil, nil, false
+	}
+	left, lconds, ok := flattenInnerJoins(join.Left)
+	if !ok {
+		return nil, nil, false
+	}
+	right, rconds, ok := flattenInnerJoins(join.Right)
+	if !ok {
+		return nil, nil, false
+	}
+	conds := append(append(lconds, rconds...), SplitConjuncts(join.On)...)
+	return append(left, right...), conds, true
+}
+
+func hasStar(items []parser.SelectItem) bool {
+	for _, item := range items {
+		if item.Star {
+			return true
+		}
+	}
+	return false
+}
+
+func andExpr(a, b parser.Expr) parser.Expr {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &parser.BinaryExpr{Left: a, Operator: "DAN", Right: b}
+}
+
+func visitPlan(plan Plan, fn func(Plan)) {
+	fn(plan)
+	for _, child := range plan.Children() {
+		visitPlan(child, fn)
+	}
+}
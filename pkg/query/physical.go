@@ -0,0 +1,523 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Operator adalah node rencana fisik. Open menyiapkan node beserta
+// anak-anaknya dan mengembalikan iterator yang menarik baris satu per
+// satu; scan, filter, proyeksi, dan BATAS tidak pernah menampung seluruh
+// hasil, sedangkan join, window, dan operasi himpunan membaca inputnya
+// sampai habis lebih dulu.
+type Operator interface {
+	Open(env *Env) (Iterator, error)
+	Children() []Operator
+	Label() string
+	Estimated() float64
+	// Actual mengembalikan jumlah baris yang sudah dihasilkan, -1 bila
+	// belum dibuka.
+	Actual() int
+}
+
+type opStats struct {
+	est    float64
+	actual int
+}
+
+func (s *opStats) Estimated() float64 { return s.est }
+func (s *opStats) Actual() int        { return s.actual }
+
+// count mereset hitungan baris aktual dan membungkus it agar setiap baris
+// yang ditarik ikut terhitung.
+func (s *opStats) count(it Iterator) (Iterator, error) {
+	s.actual = 0
+	return &countIter{Iterator: it, stats: s}, nil
+}
+
+func newStats(plan Plan) opStats {
+	return opStats{est: Estimate(plan), actual: -1}
+}
+
+// Physical mengubah rencana logis (yang sudah dioptimasi) menjadi pohon
+// operator yang bisa dieksekusi.
+func Physical(plan Plan) Operator {
+	switch p := plan.(type) {
+	case *ScanPlan:
+		return &scanOp{opStats: newStats(p), plan: p}
+	case *FilterPlan:
+		return &filterOp{opStats: newStats(p), input: Physical(p.Input), pred: p.Predicate}
+	case *JoinPlan:
+		return &joinOp{opStats: newStats(p), left: Physical(p.Left), right: Physical(p.Right), joinType: p.Type, on: p.On}
+	case *WindowPlan:
+		return &windowOp{opStats: newStats(p), input: Physical(p.Input), items: p.Items}
+	case *ProjectPlan:
+		return &projectOp{opStats: newStats(p), input: Physical(p.Input), items: p.Items}
+	case *ReorderPlan:
+		return &reorderOp{opStats: newStats(p), input: Physical(p.Input), schema: p.Schema}
+	case *LimitPlan:
+		return &limitOp{opStats: newStats(p), input: Physical(p.Input), limit: p.Count}
+	case *SetOpPlan:
+		return &setOpOp{opStats: newStats(p), left: Physical(p.Left), right: Physical(p.Right), op: p.Op, all: p.All}
+	}
+	panic(fmt.Sprintf("node rencana tidak dikenal: %T", plan))
+}
+
+// Explain menuliskan pohon operator, satu baris per node dengan indentasi
+// sesuai kedalaman.
+func Explain(op Operator) []string {
+	var lines []string
+	var walk func(op Operator, depth int)
+	walk = func(op Operator, depth int) {
+		actual := "-"
+		if op.Actual() >= 0 {
+			actual = fmt.Sprint(op.Actual())
+		}
+		lines = append(lines, fmt.Sprintf("%s%s (perkiraan=%.0f aktual=%s)", strings.Repeat("  ", depth), op.Label(), op.Estimated(), actual))
+		for _, child := range op.Children() {
+			walk(child, depth+1)
+		}
+	}
+	walk(op, 0)
+	return lines
+}
+
+type scanOp struct {
+	opStats
+	plan *ScanPlan
+}
+
+func (o *scanOp) Children() []Operator { return nil }
+
+func (o *scanOp) Label() string {
+	kind := "SeqScan"
+	switch o.plan.Source.Kind {
+	case SourcePandangan:
+		kind = "ViewScan"
+	case SourceCTE:
+		kind = "CTEScan"
+	}
+	label := kind + " " + o.plan.Source.Name
+	if o.plan.Alias != o.plan.Source.Name {
+		label += " " + o.plan.Alias
+	}
+	if o.plan.Filter != nil {
+		label += " DIMANA " + parser.FormatExpr(o.plan.Filter)
+	}
+	if o.plan.Columns != nil {
+		names := make([]string, len(o.plan.Columns))
+		for i, idx := range o.plan.Columns {
+			names[i] = o.plan.Source.Schema[idx].Name
+		}
+		label += " kolom [" + strings.Join(names, ", ") + "]"
+	}
+	return label
+}
+
+func (o *scanOp) Open(env *Env) (Iterator, error) {
+	var it *scanIter
+	if o.plan.Source.Cursor != nil {
+		schema := qualifyFields(o.plan.Source.Schema, o.plan.Alias)
+		it = &scanIter{cursor: o.plan.Source.Cursor(), schema: schema, cols: o.plan.Columns, env: env}
+	} else {
+		src, err := o.plan.Source.Load()
+		if err != nil {
+			return nil, err
+		}
+		schema := qualifyFields(src.Schema, o.plan.Alias)
+		it = &scanIter{rows: src.Rows, schema: schema, cols: o.plan.Columns, env: env}
+		if o.plan.Filter != nil {
+			it.mask = o.vectorMask(src, schema)
+		}
+	}
+
+	schema := it.schema
+	if o.plan.Filter != nil && it.mask == nil {
+		var err error
+		if it.pred, err = env.CompilePredicate(o.plan.Filter, schema); err != nil {
+			return nil, err
+		}
+	}
+	if it.cols != nil {
+		it.schema = make(Schema, len(it.cols))
+		for i, idx := range it.cols {
+			it.schema[i] = schema[idx]
+		}
+	}
+	return o.count(it)
+}
+
+// vectorMask menyaring sumber dengan cache kolom sekaligus per kolom. Hasil
+// nil berarti filter harus dievaluasi per baris.
+func (o *scanOp) vectorMask(src *Relation, schema Schema) []uint64 {
+	if o.plan.Source.Vectors == nil {
+		return nil
+	}
+	cols := o.plan.Source.Vectors()
+	if cols == nil || cols.Rows != len(src.Rows) {
+		return nil
+	}
+	mask, _ := vectorFilter(cols, o.plan.Filter, schema)
+	return mask
+}
+
+type scanIter struct {
+	rows   []tangki.Row
+	pos    int
+	cursor *tangki.Cursor
+	schema Schema
+	pred   func(tangki.Row) bool
+	mask   []uint64
+	cols   []int
+	env    *Env
+}
+
+func (it *scanIter) Schema() Schema { return it.schema }
+
+func (it *scanIter) Err() error {
+	if it.cursor != nil && it.cursor.Err() != nil {
+		return it.cursor.Err()
+	}
+	return it.env.Err()
+}
+
+// next mengembalikan baris sumber berikutnya yang lolos mask.
+func (it *scanIter) next() (tangki.Row, bool) {
+	if it.cursor != nil {
+		return it.cursor.Next()
+	}
+	for it.pos < len(it.rows) {
+		if it.mask != nil && it.mask[it.pos/64]&(1<<(it.pos%64)) == 0 {
+			it.pos++
+			continue
+		}
+		it.pos++
+		return it.rows[it.pos-1], true
+	}
+	return nil, false
+}
+
+func (it *scanIter) Next() (tangki.Row, bool) {
+	for {
+		if it.env.checkCanceled() {
+			return nil, false
+		}
+		row, ok := it.next()
+		if !ok {
+			return nil, false
+		}
+		if it.pred != nil && !it.pred(row) {
+			if it.env.Err() != nil {
+				return nil, false
+			}
+			continue
+		}
+		if it.cols == nil {
+			return row, true
+		}
+		out := make(tangki.Row, len(it.cols))
+		for j, idx := range it.cols {
+			out[j] = row[idx]
+		}
+		return out, true
+	}
+}
+
+type filterOp struct {
+	opStats
+	input Operator
+	pred  parser.Expr
+}
+
+func (o *filterOp) Children() []Operator { return []Operator{o.input} }
+func (o *filterOp) Label() string        { return "Filter " + parser.FormatExpr(o.pred) }
+
+func (o *filterOp) Open(env *Env) (Iterator, error) {
+	input, err := o.input.Open(env)
+	if err != nil {
+		return nil, err
+	}
+	pred, err := env.CompilePredicate(o.pred, input.Schema())
+	if err != nil {
+		return nil, err
+	}
+	return o.count(&filterIter{Iterator: input, pred: pred, env: env})
+}
+
+type filterIter struct {
+	Iterator
+	pred func(tangki.Row) bool
+	env  *Env
+}
+
+func (it *filterIter) Next() (tangki.Row, bool) {
+	for {
+		row, ok := it.Iterator.Next()
+		if !ok || it.env.Err() != nil {
+			return nil, false
+		}
+		if it.pred(row) {
+			return row, true
+		}
+	}
+}
+
+func (it *filterIter) Err() error {
+	if err := it.Iterator.Err(); err != nil {
+		return err
+	}
+	return it.env.Err()
+}
+
+type joinOp struct {
+	opStats
+	left, right Operator
+	joinType    string
+	on          parser.Expr
+	strategy    string
+}
+
+func (o *joinOp) Children() []Operator { return []Operator{o.left, o.right} }
+
+func (o *joinOp) Label() string {
+	name := "Join"
+	switch o.strategy {
+	case StrategyHash:
+		name = "HashJoin"
+	case StrategyMerge:
+		name = "MergeJoin"
+	case StrategyNestedLoop:
+		name = "NestedLoopJoin"
+	}
+	label := name + " " + o.joinType
+	if o.on != nil {
+		label += " PADA " + parser.FormatExpr(o.on)
+	}
+	return label
+}
+
+func (o *joinOp) Open(env *Env) (Iterator, error) {
+	left, err := Execute(o.left, env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := Execute(o.right, env)
+	if err != nil {
+		return nil, err
+	}
+	rel, strategy, err := joinRelation(left, right, o.joinType, o.on, env)
+	o.strategy = strategy
+	if err != nil {
+		return nil, err
+	}
+	return o.count(newSliceIter(rel))
+}
+
+type windowOp struct {
+	opStats
+	input Operator
+	items []parser.SelectItem
+}
+
+func (o *windowOp) Children() []Operator { return []Operator{o.input} }
+
+func (o *windowOp) Label() string {
+	var windows []*parser.WindowExpr
+	for _, item := range o.items {
+		collectWindows(item.Expr, &windows)
+	}
+	names := make([]string, len(windows))
+	for i, w := range windows {
+		names[i] = parser.FormatExpr(w)
+	}
+	return "Window " + strings.Join(names, ", ")
+}
+
+func (o *windowOp) Open(env *Env) (Iterator, error) {
+	input, err := Execute(o.input, env)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := Window(input, o.items, env)
+	if err != nil {
+		return nil, err
+	}
+	return o.count(newSliceIter(rel))
+}
+
+type projectOp struct {
+	opStats
+	input Operator
+	items []parser.SelectItem
+}
+
+func (o *projectOp) Children() []Operator { return []Operator{o.input} }
+
+func (o *projectOp) Label() string {
+	items := make([]string, len(o.items))
+	for i, item := range o.items {
+		items[i] = parser.FormatSelectItem(item)
+	}
+	return "Project " + strings.Join(items, ", ")
+}
+
+func (o *projectOp) Open(env *Env) (Iterator, error) {
+	input, err := o.input.Open(env)
+	if err != nil {
+		return nil, err
+	}
+	schema, evals, err := env.compileProjection(o.items, input.Schema())
+	if err != nil {
+		return nil, err
+	}
+	if evals == nil {
+		return o.count(input)
+	}
+	return o.count(&projectIter{Iterator: input, schema: schema, evals: evals, env: env})
+}
+
+type projectIter struct {
+	Iterator
+	schema Schema
+	evals  []Evaluator
+	env    *Env
+}
+
+func (it *projectIter) Schema() Schema { return it.schema }
+
+func (it *projectIter) Next() (tangki.Row, bool) {
+	row, ok := it.Iterator.Next()
+	if !ok {
+		return nil, false
+	}
+	out := projectRow(row, it.evals)
+	if it.env.Err() != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+func (it *projectIter) Err() error {
+	if err := it.Iterator.Err(); err != nil {
+		return err
+	}
+	return it.env.Err()
+}
+
+type reorderOp struct {
+	opStats
+	input  Operator
+	schema Schema
+}
+
+func (o *reorderOp) Children() []Operator { return []Operator{o.input} }
+func (o *reorderOp) Label() string        { return "Reorder" }
+
+func (o *reorderOp) Open(env *Env) (Iterator, error) {
+	input, err := o.input.Open(env)
+	if err != nil {
+		return nil, err
+	}
+
+	perm := make([]int, len(o.schema))
+	for i, want := range o.schema {
+		perm[i] = -1
+		for j, f := range input.Schema() {
+			if f.Table == want.Table && f.Name == want.Name {
+				perm[i] = j
+				break
+			}
+		}
+		if perm[i] == -1 {
+			return nil, fmt.Errorf("kolom '%s' hilang setelah urutan join diubah", qualifiedName(want.Table, want.Name))
+		}
+	}
+	return o.count(&reorderIter{Iterator: input, schema: o.schema, perm: perm})
+}
+
+type reorderIter struct {
+	Iterator
+	schema Schema
+	perm   []int
+}
+
+func (it *reorderIter) Schema() Schema { return it.schema }
+
+func (it *reorderIter) Next() (tangki.Row, bool) {
+	row, ok := it.Iterator.Next()
+	if !ok {
+		return nil, false
+	}
+	out := make(tangki.Row, len(it.perm))
+	for j, idx := range it.perm {
+		out[j] = row[idx]
+	}
+	return out, true
+}
+
+type limitOp struct {
+	opStats
+	input Operator
+	limit int
+}
+
+func (o *limitOp) Children() []Operator { return []Operator{o.input} }
+func (o *limitOp) Label() string        { return fmt.Sprintf("Batas %d", o.limit) }
+
+func (o *limitOp) Open(env *Env) (Iterator, error) {
+	input, err := o.input.Open(env)
+	if err != nil {
+		return nil, err
+	}
+	return o.count(&limitIter{Iterator: input, left: o.limit})
+}
+
+// limitIter berhenti menarik dari input begitu kuota habis, sehingga
+// operator streaming di bawahnya tidak membaca sisa baris.
+type limitIter struct {
+	Iterator
+	left int
+}
+
+func (it *limitIter) Next() (tangki.Row, bool) {
+	if it.left <= 0 {
+		return nil, false
+	}
+	it.left--
+	return it.Iterator.Next()
+}
+
+type setOpOp struct {
+	opStats
+	left, right Operator
+	op          string
+	all         bool
+}
+
+func (o *setOpOp) Children() []Operator { return []Operator{o.left, o.right} }
+
+func (o *setOpOp) Label() string {
+	if o.all {
+		return "SetOp " + o.op + " SEMUA"
+	}
+	return "SetOp " + o.op
+}
+
+func (o *setOpOp) Open(env *Env) (Iterator, error) {
+	left, err := Execute(o.left, env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := Execute(o.right, env)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := SetOperation(o.op, o.all, left, right)
+	if err != nil {
+		return nil, err
+	}
+	return o.count(newSliceIter(rel))
+}
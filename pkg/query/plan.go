@@ -0,0 +1,167 @@
+package query
+
+import (
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Plan adalah node rencana logis sebuah PILIH. Rencana logis hanya
+// menjelaskan apa yang dihitung; Optimize menulis ulang pohonnya dan
+// Physical mengubahnya menjadi operator yang bisa dieksekusi.
+type Plan interface {
+	Children() []Plan
+}
+
+// Jenis sumber data untuk ScanPlan.
+const (
+	SourceTangki    = "tangki"
+	SourcePandangan = "pandangan"
+	SourceCTE       = "cte"
+)
+
+// Source adalah tangki, pandangan, atau CTE yang dibaca oleh ScanPlan.
+// Load dipanggil saat eksekusi dan mengembalikan relasi tanpa kualifikasi.
+// Stats (boleh nil) berasal dari ANALISIS TANGKI.
+type Source struct {
+	Kind   string
+	Name   string
+	Schema Schema
+	Rows   int // jumlah baris, -1 bila tidak diketahui sebelum dieksekusi
+	Stats  *TableStats
+	Load   func() (*Relation, error)
+	// Vectors (boleh nil) mengembalikan isi sumber per kolom bila tangkinya
+	// punya cache kolom, untuk filter tervektorisasi saat scan.
+	Vectors func() *tangki.Columnar
+	// Cursor (boleh nil) membaca sumber baris demi baris tanpa Load,
+	// dipakai untuk tangki berhalaman agar scan tidak memuat semua baris.
+	Cursor func() *tangki.Cursor
+}
+
+// ScanPlan membaca satu sumber DARI/GABUNG. Filter berisi predikat yang
+// didorong turun oleh optimizer dan Columns berisi posisi kolom yang
+// masih dibutuhkan (nil berarti semua kolom).
+type ScanPlan struct {
+	Source  *Source
+	Alias   string
+	Filter  parser.Expr
+	Columns []int
+}
+
+// FilterPlan menyaring baris dengan predikat DIMANA.
+type FilterPlan struct {
+	Input     Plan
+	Predicate parser.Expr
+}
+
+// JoinPlan menggabungkan dua input dengan kondisi PADA.
+type JoinPlan struct {
+	Left  Plan
+	Right Plan
+	Type  string
+	On    parser.Expr
+}
+
+// WindowPlan menghitung fungsi window di daftar PILIH.
+type WindowPlan struct {
+	Input Plan
+	Items []parser.SelectItem
+}
+
+// ProjectPlan menghitung daftar PILIH.
+type ProjectPlan struct {
+	Input Plan
+	Items []parser.SelectItem
+}
+
+// ReorderPlan mengembalikan urutan kolom setelah join diurutkan ulang,
+// agar PILIH * tetap mengikuti urutan DARI dan GABUNG.
+type ReorderPlan struct {
+	Input  Plan
+	Schema Schema
+}
+
+// LimitPlan berhenti menarik baris dari inputnya setelah Count baris.
+type LimitPlan struct {
+	Input Plan
+	Count int
+}
+
+// SetOpPlan menjalankan SATUKAN, IRISAN, atau KECUALI.
+type SetOpPlan struct {
+	Left  Plan
+	Right Plan
+	Op    string
+	All   bool
+}
+
+func (p *ScanPlan) Children() []Plan    { return nil }
+func (p *FilterPlan) Children() []Plan  { return []Plan{p.Input} }
+func (p *JoinPlan) Children() []Plan    { return []Plan{p.Left, p.Right} }
+func (p *WindowPlan) Children() []Plan  { return []Plan{p.Input} }
+func (p *ProjectPlan) Children() []Plan { return []Plan{p.Input} }
+func (p *ReorderPlan) Children() []Plan { return []Plan{p.Input} }
+func (p *LimitPlan) Children() []Plan   { return []Plan{p.Input} }
+func (p *SetOpPlan) Children() []Plan   { return []Plan{p.Left, p.Right} }
+
+// NewScan membuat ScanPlan untuk source dengan alias (boleh kosong).
+func NewScan(source *Source, alias string) *ScanPlan {
+	if alias == "" {
+		alias = source.Name
+	}
+	return &ScanPlan{Source: source, Alias: alias}
+}
+
+// BuildSelectPlan menyusun rencana logis naif untuk satu blok PILIH
+// (tanpa operasi himpunan): scan dan join sesuai urutan penulisan, lalu
+// DIMANA, fungsi window, dan proyeksi. scans berisi DARI diikuti setiap
+// GABUNG sesuai urutan.
+func BuildSelectPlan(stmt *parser.SelectStmt, scans []*ScanPlan) Plan {
+	var plan Plan = scans[0]
+	for i, join := range stmt.Joins {
+		plan = &JoinPlan{Left: plan, Right: scans[i+1], Type: join.Type, On: join.On}
+	}
+	if stmt.Where != nil {
+		plan = &FilterPlan{Input: plan, Predicate: stmt.Where}
+	}
+	if stmt.HasWindow() {
+		plan = &WindowPlan{Input: plan, Items: stmt.Items}
+	}
+	return &ProjectPlan{Input: plan, Items: stmt.Items}
+}
+
+// OutputSchema mengembalikan schema keluaran node sebelum proyeksi
+// (scan, filter, join, reorder). Node lain mengembalikan schema inputnya.
+func OutputSchema(plan Plan) Schema {
+	switch p := plan.(type) {
+	case *ScanPlan:
+		schema := qualifyFields(p.Source.Schema, p.Alias)
+		if p.Columns == nil {
+			return schema
+		}
+		pruned := make(Schema, len(p.Columns))
+		for i, idx := range p.Columns {
+			pruned[i] = schema[idx]
+		}
+		return pruned
+	case *JoinPlan:
+		left, right := OutputSchema(p.Left), OutputSchema(p.Right)
+		schema := make(Schema, 0, len(left)+len(right))
+		return append(append(schema, left...), right...)
+	case *ReorderPlan:
+		return p.Schema
+	case *SetOpPlan:
+		return OutputSchema(p.Left)
+	}
+	if children := plan.Children(); len(children) == 1 {
+		return OutputSchema(children[0])
+	}
+	return nil
+}
+
+func qualifyFields(schema Schema, table string) Schema {
+	out := make(Schema, len(schema))
+	for i, f := range schema {
+		out[i] = Field{Table: table, Name: f.Name, Type: f.Type}
+	}
+	return out
+}
@@ -0,0 +1,293 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Relation adalah hasil antara sebuah query: schema beserta baris-barisnya.
+// Baris boleh berbagi memori dengan tangki sumber, jadi jangan diubah.
+type Relation struct {
+	Schema Schema
+	Rows   []tangki.Row
+}
+
+// ScanTangki membungkus tangki sebagai relasi dengan kualifikasi alias.
+func ScanTangki(t *tangki.Tangki, alias string) *Relation {
+	if alias == "" {
+		alias = t.Name
+	}
+	return &Relation{Schema: SchemaOf(t, alias), Rows: t.Rows}
+}
+
+// Filter menyaring baris relasi dengan predikat DIMANA.
+func Filter(rel *Relation, where parser.Expr, env *Env) (*Relation, error) {
+	if where == nil {
+		return rel, nil
+	}
+	pred, err := env.CompilePredicate(where, rel.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]tangki.Row, 0)
+	for _, row := range rel.Rows {
+		if pred(row) {
+			rows = append(rows, row)
+		}
+	}
+	if err := env.Err(); err != nil {
+		return nil, err
+	}
+	return &Relation{Schema: rel.Schema, Rows: rows}, nil
+}
+
+// Project menghitung daftar kolom PILIH untuk setiap baris.
+func Project(rel *Relation, items []parser.SelectItem, env *Env) (*Relation, error) {
+	schema, evals, err := env.compileProjection(items, rel.Schema)
+	if err != nil || evals == nil {
+		return rel, err
+	}
+
+	rows := make([]tangki.Row, len(rel.Rows))
+	for i, row := range rel.Rows {
+		rows[i] = projectRow(row, evals)
+	}
+	if err := env.Err(); err != nil {
+		return nil, err
+	}
+	return &Relation{Schema: schema, Rows: rows}, nil
+}
+
+// compileProjection menyusun schema dan evaluator daftar PILIH terhadap
+// schema input. evals nil berarti PILIH * tanpa perubahan baris.
+func (env *Env) compileProjection(items []parser.SelectItem, input Schema) (Schema, []Evaluator, error) {
+	if len(items) == 1 && items[0].Star && items[0].Table == "" {
+		return input, nil, nil
+	}
+
+	var schema Schema
+	var evals []Evaluator
+	for _, item := range items {
+		if item.Star {
+			matched := false
+			for i, f := range input {
+				if f.Hidden || (item.Table != "" && !strings.EqualFold(f.Table, item.Table)) {
+					continue
+				}
+				idx := i
+				schema = append(schema, f)
+				evals = append(evals, func(row tangki.Row) interface{} { return row[idx] })
+				matched = true
+			}
+			if !matched {
+				return nil, nil, fmt.Errorf("tangki '%s' tidak ada di DARI", item.Table)
+			}
+			continue
+		}
+
+		eval, err := env.Compile(item.Expr, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		schema = append(schema, env.fieldOf(item, input))
+		evals = append(evals, eval)
+	}
+	return schema, evals, nil
+}
+
+func projectRow(row tangki.Row, evals []Evaluator) tangki.Row {
+	out := make(tangki.Row, len(evals))
+	for j, eval := range evals {
+		out[j] = eval(row)
+	}
+	return out
+}
+
+func (env *Env) fieldOf(item parser.SelectItem, schema Schema) Field {
+	f := Field{Name: item.Alias, Type: env.ExprType(item.Expr, schema)}
+	if ref, ok := item.Expr.(*parser.ColumnRef); ok {
+		if idx, err := schema.Resolve(ref.Table, ref.Column); err == nil {
+			f.Table = schema[idx].Table
+			if f.Name == "" {
+				f.Name = schema[idx].Name
+			}
+		}
+	}
+	if w, ok := item.Expr.(*parser.WindowExpr); ok && f.Name == "" {
+		f.Name = strings.ToLower(w.Func)
+	}
+	if f.Name == "" {
+		f.Name = "?kolom?"
+	}
+	return f
+}
+
+// ExprType menebak tipe kolom hasil sebuah ekspresi.
+func (env *Env) ExprType(expr parser.Expr, schema Schema) string {
+	switch e := expr.(type) {
+	case *parser.ColumnRef:
+		if idx, err := schema.Resolve(e.Table, e.Column); err == nil {
+			return schema[idx].Type
+		}
+		for scope := env.outer(); scope != nil; scope = scope.Parent {
+			if idx, err := scope.Schema.Resolve(e.Table, e.Column); err == nil {
+				return scope.Schema[idx].Type
+			}
+		}
+		return "TEKS"
+	case *parser.SubqueryExpr:
+		if env == nil || env.Runner == nil || len(e.Select.Items) != 1 || e.Select.Items[0].Star {
+			return "TEKS"
+		}
+		src, err := env.Runner.SourceSchema(e.Select)
+		if err != nil {
+			return "TEKS"
+		}
+		inner := &Env{Outer: &Scope{Schema: schema, Parent: env.outer()}, Runner: env.Runner}
+		return inner.ExprType(e.Select.Items[0].Expr, src)
+	case *parser.Literal:
+		switch e.Value.(type) {
+		case int, int64:
+			return "INT"
+		case float64:
+			return "FLOAT"
+		}
+		return "TEKS"
+	case *parser.WindowExpr:
+		return env.windowType(e, schema)
+	case *parser.BinaryExpr:
+		switch e.Operator {
+		case "+", "-", "*":
+			lt, rt := env.ExprType(e.Left, schema), env.ExprType(e.Right, schema)
+			if lt == "TEKS" && rt == "TEKS" {
+				return "TEKS"
+			}
+			if lt == "INT" && rt == "INT" {
+				return "INT"
+			}
+			return "FLOAT"
+		case "/":
+			return "FLOAT"
+		}
+		return "INT"
+	}
+	return "TEKS"
+}
+
+// JoinRelation menggabungkan dua relasi dengan kondisi PADA. Konjungsi
+// kolom_kiri = kolom_kanan dipakai sebagai kunci hash; sisanya menjadi
+// predikat residual yang dievaluasi per pasangan baris.
+func JoinRelation(left, right *Relation, joinType string, on parser.Expr, env *Env) (*Relation, error) {
+	rel, _, err := joinRelation(left, right, joinType, on, env)
+	return rel, err
+}
+
+// joinRelation juga mengembalikan strategi join yang dipakai untuk JELASKAN.
+func joinRelation(left, right *Relation, joinType string, on parser.Expr, env *Env) (*Relation, string, error) {
+	schema := make(Schema, 0, len(left.Schema)+len(right.Schema))
+	schema = append(schema, left.Schema...)
+	schema = append(schema, right.Schema...)
+
+	var leftKeys, rightKeys []int
+	var rest []parser.Expr
+	for _, conj := range SplitConjuncts(on) {
+		if li, ri, ok := equiJoinKey(conj, left.Schema, right.Schema); ok {
+			leftKeys = append(leftKeys, li)
+			rightKeys = append(rightKeys, ri)
+			continue
+		}
+		rest = append(rest, conj)
+	}
+
+	var residual func(l, r tangki.Row) bool
+	if len(rest) > 0 {
+		pred, err := env.CompilePredicate(AndAll(rest), schema)
+		if err != nil {
+			return nil, "", err
+		}
+		scratch := make(tangki.Row, len(schema))
+		residual = func(l, r tangki.Row) bool {
+			copy(scratch, l)
+			copy(scratch[len(l):], r)
+			return pred(scratch)
+		}
+	}
+
+	if joinType != JoinCross && len(leftKeys) == 0 && residual == nil {
+		return nil, "", fmt.Errorf("join %s membutuhkan kondisi PADA", joinType)
+	}
+
+	strategy := StrategyNestedLoop
+	if len(leftKeys) > 0 {
+		strategy = StrategyHash
+		if joinType == JoinInner && residual == nil {
+			strategy = ChooseJoinStrategy(left.Rows, right.Rows, leftKeys, rightKeys)
+		}
+	}
+
+	rows := joinRows(env.canceler(), left.Rows, right.Rows, len(left.Schema), len(right.Schema), joinType, leftKeys, rightKeys, residual)
+	if err := env.canceler().Err(); err != nil {
+		return nil, "", err
+	}
+	if err := env.Err(); err != nil {
+		return nil, "", err
+	}
+	return &Relation{Schema: schema, Rows: rows}, strategy, nil
+}
+
+// equiJoinKey mengenali kondisi kiri.kolom = kanan.kolom (urutan bebas).
+func equiJoinKey(expr parser.Expr, left, right Schema) (int, int, bool) {
+	bin, ok := expr.(*parser.BinaryExpr)
+	if !ok || bin.Operator != "=" {
+		return 0, 0, false
+	}
+	a, aok := bin.Left.(*parser.ColumnRef)
+	b, bok := bin.Right.(*parser.ColumnRef)
+	if !aok || !bok {
+		return 0, 0, false
+	}
+
+	if li, err := left.Resolve(a.Table, a.Column); err == nil {
+		if ri, err := right.Resolve(b.Table, b.Column); err == nil && !resolvable(left, b) && !resolvable(right, a) {
+			return li, ri, true
+		}
+	}
+	if li, err := left.Resolve(b.Table, b.Column); err == nil {
+		if ri, err := right.Resolve(a.Table, a.Column); err == nil && !resolvable(left, a) && !resolvable(right, b) {
+			return li, ri, true
+		}
+	}
+	return 0, 0, false
+}
+
+func resolvable(schema Schema, ref *parser.ColumnRef) bool {
+	_, err := schema.Resolve(ref.Table, ref.Column)
+	return err == nil
+}
+
+// SplitConjuncts memecah a DAN b DAN c menjadi [a, b, c].
+func SplitConjuncts(expr parser.Expr) []parser.Expr {
+	if expr == nil {
+		return nil
+	}
+	if bin, ok := expr.(*parser.BinaryExpr); ok && bin.Operator == "DAN" {
+		return append(SplitConjuncts(bin.Left), SplitConjuncts(bin.Right)...)
+	}
+	return []parser.Expr{expr}
+}
+
+// AndAll menyusun kembali konjungsi dari beberapa ekspresi.
+func AndAll(exprs []parser.Expr) parser.Expr {
+	if len(exprs) == 0 {
+		return nil
+	}
+	result := exprs[0]
+	for _, e := range exprs[1:] {
+		result = &parser.BinaryExpr{Left: result, Operator: "DAN", Right: e}
+	}
+	return result
+}
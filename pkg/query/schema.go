@@ -0,0 +1,112 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Field adalah satu kolom hasil query beserta tangki (atau alias) asalnya.
+// Field Hidden adalah kolom bantu (misalnya hasil fungsi window) yang tidak
+// bisa dirujuk dengan nama dan tidak ikut di PILIH *.
+type Field struct {
+	Table  string
+	Name   string
+	Type   string
+	Hidden bool
+}
+
+// Schema adalah daftar kolom dari sebuah relasi antara.
+type Schema []Field
+
+// SchemaOf membuat schema dari kolom tangki dengan kualifikasi table.
+func SchemaOf(t *tangki.Tangki, table string) Schema {
+	schema := make(Schema, len(t.Columns))
+	for i, col := range t.Columns {
+		schema[i] = Field{Table: table, Name: col.Name, Type: col.Type}
+	}
+	return schema
+}
+
+// Resolve mencari posisi kolom table.name (table boleh kosong).
+// Kolom tanpa kualifikasi yang cocok dengan lebih dari satu tangki
+// dianggap ambigu.
+func (s Schema) Resolve(table, name string) (int, error) {
+	found := -1
+	for i, f := range s {
+		if !f.matches(table, name) {
+			continue
+		}
+		if found != -1 {
+			return -1, fmt.Errorf("kolom '%s' ambigu", qualifiedName(table, name))
+		}
+		found = i
+	}
+	if found == -1 {
+		return -1, fmt.Errorf("kolom '%s' tidak ditemukan", qualifiedName(table, name))
+	}
+	return found, nil
+}
+
+// matches juga mengenali kolom bernama "tangki.kolom" yang dihasilkan
+// GABUNG ... MENJADI untuk nama kolom yang bentrok.
+func (f Field) matches(table, name string) bool {
+	if f.Hidden {
+		return false
+	}
+	if table == "" {
+		if strings.EqualFold(f.Name, name) {
+			return true
+		}
+		dot := strings.LastIndexByte(f.Name, '.')
+		return dot != -1 && strings.EqualFold(f.Name[dot+1:], name)
+	}
+	if strings.EqualFold(f.Table, table) && strings.EqualFold(f.Name, name) {
+		return true
+	}
+	return strings.EqualFold(f.Name, table+"."+name)
+}
+
+// Columns mengubah schema kembali menjadi definisi kolom tangki.
+func (s Schema) Columns() []tangki.Column {
+	cols := make([]tangki.Column, len(s))
+	for i, f := range s {
+		cols[i] = tangki.Column{Name: f.Name, Type: f.Type}
+	}
+	return cols
+}
+
+func qualifiedName(table, name string) string {
+	if table == "" {
+		return name
+	}
+	return table + "." + name
+}
+
+// QualifyDuplicateColumns memberi awalan nama tangki pada kolom yang
+// namanya muncul di kedua sisi join, misalnya "pegawai.id" dan "divisi.id".
+func QualifyDuplicateColumns(left, right []tangki.Column, leftName, rightName string) []tangki.Column {
+	counts := make(map[string]int)
+	for _, c := range left {
+		counts[strings.ToLower(c.Name)]++
+	}
+	for _, c := range right {
+		counts[strings.ToLower(c.Name)]++
+	}
+
+	cols := make([]tangki.Column, 0, len(left)+len(right))
+	for _, c := range left {
+		if counts[strings.ToLower(c.Name)] > 1 {
+			c.Name = leftName + "." + c.Name
+		}
+		cols = append(cols, c)
+	}
+	for _, c := range right {
+		if counts[strings.ToLower(c.Name)] > 1 {
+			c.Name = rightName + "." + c.Name
+		}
+		cols = append(cols, c)
+	}
+	return cols
+}
@@ -0,0 +1,183 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Operasi himpunan yang didukung.
+const (
+	SetUnion     = "UNION"
+	SetIntersect = "INTERSECT"
+	SetExcept    = "EXCEPT"
+)
+
+// Union menggabungkan beberapa tangki dan membuang baris duplikat.
+func Union(tangkis ...*tangki.Tangki) (*tangki.Tangki, error) {
+	return SetOp(SetUnion, false, tangkis...)
+}
+
+// SetOp menjalankan UNION, INTERSECT atau EXCEPT atas beberapa tangki
+// secara berurutan dari kiri ke kanan. Bila all bernilai true duplikat
+// dipertahankan (semantik multiset).
+func SetOp(op string, all bool, tangkis ...*tangki.Tangki) (*tangki.Tangki, error) {
+	if len(tangkis) == 0 {
+		return tangki.NewTangki("union", []tangki.Column{}), nil
+	}
+
+	result := ScanTangki(tangkis[0], "")
+	for _, t := range tangkis[1:] {
+		var err error
+		result, err = SetOperation(op, all, result, ScanTangki(t, ""))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(tangkis) == 1 && !all {
+		result = distinct(result)
+	}
+
+	out := tangki.NewTangki("union", result.Schema.Columns())
+	out.Rows = make([]tangki.Row, len(result.Rows))
+	for i, row := range result.Rows {
+		out.Rows[i] = row.Clone()
+	}
+	return out, nil
+}
+
+// SetOperation menggabungkan dua relasi. Jumlah kolom harus sama dan
+// tipe tiap kolom harus kompatibel; INT dan FLOAT dikoersi menjadi FLOAT.
+// Nama kolom hasil mengikuti relasi kiri.
+func SetOperation(op string, all bool, left, right *Relation) (*Relation, error) {
+	schema, err := unifySchemas(left.Schema, right.Schema)
+	if err != nil {
+		return nil, err
+	}
+	leftRows := coerceRows(left.Rows, left.Schema, schema)
+	rightRows := coerceRows(right.Rows, right.Schema, schema)
+
+	var rows []tangki.Row
+	switch op {
+	case SetUnion:
+		rows = make([]tangki.Row, 0, len(leftRows)+len(rightRows))
+		rows = append(rows, leftRows...)
+		rows = append(rows, rightRows...)
+		if !all {
+			rows = distinctRows(rows)
+		}
+
+	case SetIntersect, SetExcept:
+		counts := make(map[string]int, len(rightRows))
+		for _, row := range rightRows {
+			counts[RowKey(row)]++
+		}
+		if !all {
+			leftRows = distinctRows(leftRows)
+		}
+		rows = make([]tangki.Row, 0)
+		for _, row := range leftRows {
+			key := RowKey(row)
+			found := counts[key] > 0
+			if found && all {
+				counts[key]--
+			}
+			if found == (op == SetIntersect) {
+				rows = append(rows, row)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("operasi himpunan tidak dikenal: %s", op)
+	}
+
+	return &Relation{Schema: schema, Rows: rows}, nil
+}
+
+func unifySchemas(left, right Schema) (Schema, error) {
+	if len(left) != len(right) {
+		return nil, fmt.Errorf("jumlah kolom tidak sama: %d dan %d", len(left), len(right))
+	}
+
+	schema := make(Schema, len(left))
+	for i := range left {
+		lt, rt := left[i].Type, right[i].Type
+		typ := lt
+		if lt != rt {
+			if !isNumericType(lt) || !isNumericType(rt) {
+				return nil, fmt.Errorf("tipe kolom '%s' (%s) tidak kompatibel dengan '%s' (%s)", left[i].Name, lt, right[i].Name, rt)
+			}
+			typ = "FLOAT"
+		}
+		schema[i] = Field{Name: left[i].Name, Type: typ}
+	}
+	return schema, nil
+}
+
+func isNumericType(t string) bool {
+	return t == "INT" || t == "FLOAT"
+}
+
+func coerceRows(rows []tangki.Row, from, to Schema) []tangki.Row {
+	var floatCols []int
+	for i := range to {
+		if to[i].Type == "FLOAT" && from[i].Type != "FLOAT" {
+			floatCols = append(floatCols, i)
+		}
+	}
+	if len(floatCols) == 0 {
+		return rows
+	}
+
+	out := make([]tangki.Row, len(rows))
+	for i, row := range rows {
+		r := row.Clone()
+		for _, c := range floatCols {
+			if r[c] != nil {
+				r[c] = toFloatAJAX(r[c])
+			}
+		}
+		out[i] = r
+	}
+	return out
+}
+
+func distinct(rel *Relation) *Relation {
+	return &Relation{Schema: rel.Schema, Rows: distinctRows(rel.Rows)}
+}
+
+func distinctRows(rows []tangki.Row) []tangki.Row {
+	seen := make(map[string]struct{}, len(rows))
+	out := make([]tangki.Row, 0, len(rows))
+	for _, row := range rows {
+		key := RowKey(row)
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, row)
+	}
+	return out
+}
+
+// RowKey menyusun kunci untuk seluruh kolom sebuah baris. Berbeda dengan
+// JoinKey, NULL dianggap sama dengan NULL sehingga cocok untuk DISTINCT.
+func RowKey(row tangki.Row) string {
+	var sb strings.Builder
+	for i, v := range row {
+		if i > 0 {
+			sb.WriteByte(0)
+		}
+		part, ok := keyPart(v)
+		if !ok {
+			sb.WriteString("\x01null")
+			continue
+		}
+		sb.WriteString(strconv.Itoa(len(part)))
+		sb.WriteByte(':')
+		sb.WriteString(part)
+	}
+	return sb.String()
+}
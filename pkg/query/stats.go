@@ -0,0 +1,142 @@
+package query
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// HistogramBuckets adalah jumlah bucket histogram equi-depth per kolom.
+const HistogramBuckets = 10
+
+// TableStats adalah statistik sebuah tangki hasil ANALISIS TANGKI.
+type TableStats struct {
+	Rows    int
+	Columns []ColumnStats
+}
+
+// ColumnStats menyimpan statistik satu kolom. Bounds adalah batas bucket
+// histogram equi-depth: Bounds[0] nilai terkecil, Bounds[len-1] nilai
+// terbesar, dan setiap bucket berisi kira-kira jumlah baris yang sama.
+type ColumnStats struct {
+	Name         string
+	Distinct     int
+	NullFraction float64
+	Min          interface{}
+	Max          interface{}
+	Bounds       []interface{}
+}
+
+// Analyze menghitung statistik dari seluruh baris tangki.
+func Analyze(t *tangki.Tangki) (*TableStats, error) {
+	total := t.Len()
+	stats := &TableStats{Rows: total, Columns: make([]ColumnStats, len(t.Columns))}
+
+	// Satu kolom dibaca per putaran agar tangki berhalaman tidak perlu
+	// dimuat seluruhnya
+	values := make([]interface{}, 0, total)
+	for i, col := range t.Columns {
+		values = values[:0]
+		err := t.Scan(func(row tangki.Row) bool {
+			if row[i] != nil {
+				values = append(values, row[i])
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		stats.Columns[i] = analyzeColumn(col.Name, values, total)
+	}
+	return stats, nil
+}
+
+func analyzeColumn(name string, values []interface{}, total int) ColumnStats {
+	cs := ColumnStats{Name: name}
+	if total > 0 {
+		cs.NullFraction = float64(total-len(values)) / float64(total)
+	}
+	if len(values) == 0 {
+		return cs
+	}
+
+	sort.SliceStable(values, func(i, j int) bool { return CompareOrder(values[i], values[j]) < 0 })
+	cs.Min, cs.Max = values[0], values[len(values)-1]
+
+	cs.Distinct = 1
+	for i := 1; i < len(values); i++ {
+		if CompareOrder(values[i-1], values[i]) != 0 {
+			cs.Distinct++
+		}
+	}
+
+	buckets := HistogramBuckets
+	if len(values) < buckets {
+		buckets = len(values)
+	}
+	cs.Bounds = make([]interface{}, buckets+1)
+	for b := 0; b <= buckets; b++ {
+		idx := b * (len(values) - 1) / buckets
+		cs.Bounds[b] = values[idx]
+	}
+	return cs
+}
+
+// Column mencari statistik kolom berdasarkan nama.
+func (s *TableStats) Column(name string) *ColumnStats {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Columns {
+		if strings.EqualFold(s.Columns[i].Name, name) {
+			return &s.Columns[i]
+		}
+	}
+	return nil
+}
+
+// equalSelectivity memperkirakan bagian baris dengan kolom = v.
+func (c *ColumnStats) equalSelectivity(v interface{}) float64 {
+	if c.Distinct == 0 || v == nil {
+		return 0
+	}
+	if CompareOrder(v, c.Min) < 0 || CompareOrder(v, c.Max) > 0 {
+		return 0
+	}
+	return (1 - c.NullFraction) / float64(c.Distinct)
+}
+
+// lessSelectivity memperkirakan bagian baris dengan kolom < v dari
+// histogram. Nilai di dalam bucket numerik diinterpolasi linear.
+func (c *ColumnStats) lessSelectivity(v interface{}) float64 {
+	if len(c.Bounds) < 2 || v == nil {
+		return 0
+	}
+	buckets := len(c.Bounds) - 1
+	if CompareOrder(v, c.Bounds[0]) <= 0 {
+		return 0
+	}
+	if CompareOrder(v, c.Bounds[buckets]) > 0 {
+		return 1 - c.NullFraction
+	}
+
+	b := 0
+	for b < buckets-1 && CompareOrder(v, c.Bounds[b+1]) > 0 {
+		b++
+	}
+	within := 0.5
+	lo, hi := c.Bounds[b], c.Bounds[b+1]
+	if isNumber(lo) && isNumber(hi) && isNumber(v) && CompareOrder(lo, hi) != 0 {
+		within = (toFloatAJAX(v) - toFloatAJAX(lo)) / (toFloatAJAX(hi) - toFloatAJAX(lo))
+	}
+	return (float64(b) + within) / float64(buckets) * (1 - c.NullFraction)
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int64, float64:
+		return true
+	}
+	return false
+}
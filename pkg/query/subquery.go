@@ -0,0 +1,446 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// subqueryPlan adalah hasil analisis sebuah subquery terhadap query luar.
+type subqueryPlan struct {
+	stmt       *parser.SelectStmt
+	correlated bool
+
+	// Bila decorrelated, stmt sudah ditulis ulang tanpa kondisi korelasi
+	// dan kolom innerKeys ditambahkan di akhir daftar PILIH. Nilai outerKeys
+	// dari baris luar dipakai untuk probe hash table hasil subquery.
+	decorrelated bool
+	outerKeys    []Evaluator
+	numKeys      int
+}
+
+func (env *Env) runner() (SubqueryRunner, error) {
+	if env == nil || env.Runner == nil {
+		return nil, fmt.Errorf("subquery tidak didukung di sini")
+	}
+	return env.Runner, nil
+}
+
+// planSubquery menentukan apakah stmt berkorelasi dengan schema luar dan,
+// bila mungkin, mengubah korelasi kesamaan (dalam.kolom = luar.kolom)
+// menjadi hash semi-join sehingga subquery cukup dijalankan sekali.
+func (env *Env) planSubquery(stmt *parser.SelectStmt, schema Schema, keepItems bool) (*subqueryPlan, error) {
+	runner, err := env.runner()
+	if err != nil {
+		return nil, err
+	}
+
+	free, err := freeRefs(runner, stmt, nil)
+	if err != nil {
+		return nil, err
+	}
+	plan := &subqueryPlan{stmt: stmt}
+	for _, ref := range free {
+		if env.resolvesOuter(ref, schema) {
+			plan.correlated = true
+			break
+		}
+	}
+	if !plan.correlated || len(stmt.SetOps) > 0 {
+		return plan, nil
+	}
+
+	src, err := runner.SourceSchema(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var innerKeys []parser.Expr
+	var outerKeys []*parser.ColumnRef
+	var rest []parser.Expr
+	for _, conj := range SplitConjuncts(stmt.Where) {
+		if inner, outer, ok := correlationKey(conj, src, schema); ok {
+			innerKeys = append(innerKeys, inner)
+			outerKeys = append(outerKeys, outer)
+			continue
+		}
+		rest = append(rest, conj)
+	}
+	if len(innerKeys) == 0 {
+		return plan, nil
+	}
+
+	rewritten := *stmt
+	rewritten.Where = AndAll(rest)
+	rewritten.Items = nil
+	if keepItems {
+		rewritten.Items = append(rewritten.Items, stmt.Items...)
+	}
+	for _, k := range innerKeys {
+		rewritten.Items = append(rewritten.Items, parser.SelectItem{Expr: k})
+	}
+
+	remaining, err := freeRefs(runner, &rewritten, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range remaining {
+		if env.resolvesOuter(ref, schema) {
+			return plan, nil
+		}
+	}
+
+	for _, ref := range outerKeys {
+		eval, err := env.Compile(ref, schema)
+		if err != nil {
+			return nil, err
+		}
+		plan.outerKeys = append(plan.outerKeys, eval)
+	}
+	plan.stmt = &rewritten
+	plan.decorrelated = true
+	plan.numKeys = len(innerKeys)
+	return plan, nil
+}
+
+// correlationKey mengenali dalam.kolom = luar.kolom dalam urutan apa pun.
+func correlationKey(expr parser.Expr, inner, outer Schema) (parser.Expr, *parser.ColumnRef, bool) {
+	bin, ok := expr.(*parser.BinaryExpr)
+	if !ok || bin.Operator != "=" {
+		return nil, nil, false
+	}
+	a, aok := bin.Left.(*parser.ColumnRef)
+	b, bok := bin.Right.(*parser.ColumnRef)
+	if !aok || !bok {
+		return nil, nil, false
+	}
+	if resolvable(inner, a) && !resolvable(inner, b) && resolvable(outer, b) {
+		return a, b, true
+	}
+	if resolvable(inner, b) && !resolvable(inner, a) && resolvable(outer, a) {
+		return b, a, true
+	}
+	return nil, nil, false
+}
+
+func (env *Env) resolvesOuter(ref *parser.ColumnRef, schema Schema) bool {
+	if resolvable(schema, ref) {
+		return true
+	}
+	for scope := env.outer(); scope != nil; scope = scope.Parent {
+		if resolvable(scope.Schema, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// freeRefs mengumpulkan referensi kolom di stmt (termasuk subquery di
+// dalamnya) yang tidak bisa diselesaikan oleh DARI/GABUNG milik stmt itu
+// sendiri maupun scope yang mengapitnya di dalam subquery.
+func freeRefs(runner SubqueryRunner, stmt *parser.SelectStmt, enclosing []Schema) ([]*parser.ColumnRef, error) {
+	src, err := runner.SourceSchema(stmt)
+	if err != nil {
+		return nil, err
+	}
+	scopes := append([]Schema{src}, enclosing...)
+
+	var free []*parser.ColumnRef
+	var walkErr error
+	visit := func(expr parser.Expr) {
+		walkExpr(expr, func(ref *parser.ColumnRef) {
+			if ref.Column == "*" {
+				return
+			}
+			for _, s := range scopes {
+				if resolvable(s, ref) {
+					return
+				}
+			}
+			free = append(free, ref)
+		}, func(sub *parser.SelectStmt) {
+			nested, err := freeRefs(runner, sub, scopes)
+			if err != nil && walkErr == nil {
+				walkErr = err
+			}
+			free = append(free, nested...)
+		})
+	}
+
+	for _, item := range stmt.Items {
+		if item.Expr != nil {
+			visit(item.Expr)
+		}
+	}
+	for _, join := range stmt.Joins {
+		visit(join.On)
+	}
+	visit(stmt.Where)
+
+	for _, clause := range stmt.SetOps {
+		nested, err := freeRefs(runner, clause.Select, enclosing)
+		if err != nil {
+			return nil, err
+		}
+		free = append(free, nested...)
+	}
+	return free, walkErr
+}
+
+func walkExpr(expr parser.Expr, onRef func(*parser.ColumnRef), onSub func(*parser.SelectStmt)) {
+	switch e := expr.(type) {
+	case *parser.ColumnRef:
+		onRef(e)
+	case *parser.BinaryExpr:
+		walkExpr(e.Left, onRef, onSub)
+		walkExpr(e.Right, onRef, onSub)
+	case *parser.SubqueryExpr:
+		onSub(e.Select)
+	case *parser.InExpr:
+		walkExpr(e.Expr, onRef, onSub)
+		for _, item := range e.List {
+			walkExpr(item, onRef, onSub)
+		}
+		if e.Subquery != nil {
+			onSub(e.Subquery)
+		}
+	case *parser.ExistsExpr:
+		onSub(e.Subquery)
+	case *parser.WindowExpr:
+		for _, arg := range e.Args {
+			walkExpr(arg, onRef, onSub)
+		}
+		for _, part := range e.PartitionBy {
+			walkExpr(part, onRef, onSub)
+		}
+		for _, item := range e.OrderBy {
+			walkExpr(item.Expr, onRef, onSub)
+		}
+	}
+}
+
+// subqueryIndex adalah hasil subquery decorrelated yang dikelompokkan
+// berdasarkan nilai kunci korelasi.
+type subqueryIndex map[string][]tangki.Row
+
+func buildSubqueryIndex(rel *Relation, numKeys int) subqueryIndex {
+	width := len(rel.Schema)
+	keys := make([]int, numKeys)
+	for i := range keys {
+		keys[i] = width - numKeys + i
+	}
+
+	index := make(subqueryIndex, len(rel.Rows))
+	for _, row := range rel.Rows {
+		key, ok := JoinKey(row, keys)
+		if !ok {
+			continue
+		}
+		index[key] = append(index[key], row)
+	}
+	return index
+}
+
+func (plan *subqueryPlan) probeKey(row tangki.Row) (string, bool) {
+	vals := make(tangki.Row, len(plan.outerKeys))
+	idx := make([]int, len(plan.outerKeys))
+	for i, eval := range plan.outerKeys {
+		vals[i] = eval(row)
+		idx[i] = i
+	}
+	return JoinKey(vals, idx)
+}
+
+// subqueryRows menyiapkan fungsi yang mengembalikan baris subquery untuk
+// sebuah baris luar, beserta jumlah kolom hasilnya. Subquery berkorelasi
+// yang tidak bisa decorrelated dijalankan ulang untuk setiap baris luar.
+func (env *Env) subqueryRows(plan *subqueryPlan, schema Schema) (func(tangki.Row) ([]tangki.Row, bool), int, error) {
+	runner, err := env.runner()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !plan.correlated {
+		rel, err := runner.RunSubquery(plan.stmt, env.outer())
+		if err != nil {
+			return nil, 0, err
+		}
+		return func(tangki.Row) ([]tangki.Row, bool) { return rel.Rows, true }, len(rel.Schema), nil
+	}
+
+	if plan.decorrelated {
+		rel, err := runner.RunSubquery(plan.stmt, env.outer())
+		if err != nil {
+			return nil, 0, err
+		}
+		index := buildSubqueryIndex(rel, plan.numKeys)
+		return func(row tangki.Row) ([]tangki.Row, bool) {
+			key, ok := plan.probeKey(row)
+			if !ok {
+				return nil, true
+			}
+			return index[key], true
+		}, len(rel.Schema) - plan.numKeys, nil
+	}
+
+	scope := &Scope{Schema: schema, Row: make(tangki.Row, len(schema)), Parent: env.outer()}
+	probe, err := runner.RunSubquery(plan.stmt, scope)
+	if err != nil {
+		return nil, 0, err
+	}
+	return func(row tangki.Row) ([]tangki.Row, bool) {
+		scope.Row = row
+		rel, err := runner.RunSubquery(plan.stmt, scope)
+		if err != nil {
+			env.setErr(err)
+			return nil, false
+		}
+		return rel.Rows, true
+	}, len(probe.Schema), nil
+}
+
+func (env *Env) compileScalarSubquery(e *parser.SubqueryExpr, schema Schema) (Evaluator, error) {
+	plan, err := env.planSubquery(e.Select, schema, true)
+	if err != nil {
+		return nil, err
+	}
+	rowsFor, width, err := env.subqueryRows(plan, schema)
+	if err != nil {
+		return nil, err
+	}
+	if width != 1 {
+		return nil, fmt.Errorf("subquery skalar harus mengembalikan tepat satu kolom, got %d", width)
+	}
+	if !plan.correlated {
+		rows, _ := rowsFor(nil)
+		if len(rows) > 1 {
+			return nil, fmt.Errorf("subquery skalar mengembalikan lebih dari satu baris")
+		}
+	}
+
+	return func(row tangki.Row) interface{} {
+		rows, ok := rowsFor(row)
+		if !ok || len(rows) == 0 {
+			return nil
+		}
+		if len(rows) > 1 {
+			env.setErr(fmt.Errorf("subquery skalar mengembalikan lebih dari satu baris"))
+			return nil
+		}
+		return rows[0][0]
+	}, nil
+}
+
+func (env *Env) compileExists(e *parser.ExistsExpr, schema Schema) (Evaluator, error) {
+	plan, err := env.planSubquery(e.Subquery, schema, false)
+	if err != nil {
+		return nil, err
+	}
+	rowsFor, _, err := env.subqueryRows(plan, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	not := e.Not
+	return func(row tangki.Row) interface{} {
+		rows, ok := rowsFor(row)
+		if !ok {
+			return nil
+		}
+		return (len(rows) > 0) != not
+	}, nil
+}
+
+func (env *Env) compileIn(e *parser.InExpr, schema Schema) (Evaluator, error) {
+	value, err := env.Compile(e.Expr, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates func(tangki.Row) ([]tangki.Row, bool)
+	if e.Subquery != nil {
+		plan, err := env.planSubquery(e.Subquery, schema, true)
+		if err != nil {
+			return nil, err
+		}
+		rowsFor, width, err := env.subqueryRows(plan, schema)
+		if err != nil {
+			return nil, err
+		}
+		if width != 1 {
+			return nil, fmt.Errorf("subquery DI DALAM harus mengembalikan tepat satu kolom, got %d", width)
+		}
+		candidates = rowsFor
+
+		if !plan.correlated {
+			rows, _ := rowsFor(nil)
+			set := newValueSet(rows)
+			return func(row tangki.Row) interface{} {
+				return set.contains(value(row), e.Not)
+			}, nil
+		}
+	} else {
+		items := make([]Evaluator, len(e.List))
+		for i, item := range e.List {
+			eval, err := env.Compile(item, schema)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = eval
+		}
+		candidates = func(row tangki.Row) ([]tangki.Row, bool) {
+			rows := make([]tangki.Row, len(items))
+			for i, eval := range items {
+				rows[i] = tangki.Row{eval(row)}
+			}
+			return rows, true
+		}
+	}
+
+	return func(row tangki.Row) interface{} {
+		rows, ok := candidates(row)
+		if !ok {
+			return nil
+		}
+		return newValueSet(rows).contains(value(row), e.Not)
+	}, nil
+}
+
+// valueSet adalah himpunan nilai kolom pertama untuk evaluasi DI DALAM.
+type valueSet struct {
+	values  map[string]bool
+	hasNull bool
+}
+
+func newValueSet(rows []tangki.Row) *valueSet {
+	set := &valueSet{values: make(map[string]bool, len(rows))}
+	for _, row := range rows {
+		key, ok := keyPart(row[0])
+		if !ok {
+			set.hasNull = true
+			continue
+		}
+		set.values[key] = true
+	}
+	return set
+}
+
+// contains mengikuti logika tiga nilai SQL: NULL bila nilai tidak
+// ditemukan tetapi himpunan mengandung NULL.
+func (s *valueSet) contains(v interface{}, not bool) interface{} {
+	key, ok := keyPart(v)
+	if !ok {
+		if len(s.values) == 0 && !s.hasNull {
+			return not
+		}
+		return nil
+	}
+	if s.values[key] {
+		return !not
+	}
+	if s.hasNull {
+		return nil
+	}
+	return not
+}
@@ -0,0 +1,306 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Operasi tervektorisasi atas tangki.Columnar untuk tangki dengan cache kolom.
+// Semuanya mengembalikan ok=false bila kolom yang dibutuhkan tidak punya
+// vektor, dan pemanggil kembali ke jalur per baris. Hasilnya harus sama
+// dengan jalur per baris: NULL dihitung 0 dalam agregasi dan saat
+// diurutkan, dan tidak pernah lolos perbandingan. Seperti jalur per baris,
+// urutan grup dan urutan baris yang setara tidak ditentukan.
+
+// groupByVectors adalah GroupBy untuk cache kolom. aggFunc kosong berarti
+// menghitung jumlah baris per grup. Bila c dibatalkan hasilnya tidak
+// lengkap dan pemanggil harus memeriksa c.Err().
+func groupByVectors(c *Canceler, cols *tangki.Columnar, groupIdx int, aggFunc string, aggIdx int) ([]tangki.Row, bool, error) {
+	group := cols.Vectors[groupIdx]
+	if group == nil {
+		return nil, false, nil
+	}
+	withAgg := aggFunc != ""
+	if withAgg && cols.Vectors[aggIdx] == nil {
+		return nil, false, nil
+	}
+
+	gids, keys := groupVector(c, group)
+	var aggs []float64
+	counts := make([]int, len(keys))
+	for _, g := range gids {
+		counts[g]++
+	}
+	if withAgg {
+		var err error
+		if aggs, err = aggregateVector(cols.Vectors[aggIdx], aggFunc, gids, counts); err != nil {
+			return nil, true, err
+		}
+	}
+
+	results := make([]tangki.Row, len(keys))
+	for g, key := range keys {
+		if withAgg {
+			results[g] = tangki.Row{key, aggs[g]}
+		} else {
+			results[g] = tangki.Row{key, counts[g]}
+		}
+	}
+	return results, true, nil
+}
+
+// groupVector memberi setiap baris nomor grup, urut kemunculan pertama.
+// keys berisi teks kunci tiap grup seperti fmt.Sprintf("%v", nilai).
+func groupVector(c *Canceler, v *tangki.Vector) ([]int32, []string) {
+	n := v.Len()
+	gids := make([]int32, n)
+	var keys []string
+	nullGroup := int32(-1)
+
+	newGroup := func(i int) int32 {
+		keys = append(keys, fmt.Sprintf("%v", v.Value(i)))
+		return int32(len(keys) - 1)
+	}
+
+	ints := make(map[int64]int32)
+	floats := make(map[uint64]int32)
+	var byCode []int32
+	if v.Type == "TEKS" {
+		byCode = make([]int32, len(v.Dict))
+		for i := range byCode {
+			byCode[i] = -1
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if c.Canceled() {
+			break
+		}
+		if v.IsNull(i) {
+			if nullGroup < 0 {
+				nullGroup = newGroup(i)
+			}
+			gids[i] = nullGroup
+			continue
+		}
+
+		switch v.Type {
+		case "INT":
+			g, ok := ints[v.Ints[i]]
+			if !ok {
+				g = newGroup(i)
+				ints[v.Ints[i]] = g
+			}
+			gids[i] = g
+		case "FLOAT":
+			// Semua NaN tercetak "NaN" sehingga masuk satu grup
+			bits := math.Float64bits(v.Floats[i])
+			if math.IsNaN(v.Floats[i]) {
+				bits = math.Float64bits(math.NaN())
+			}
+			g, ok := floats[bits]
+			if !ok {
+				g = newGroup(i)
+				floats[bits] = g
+			}
+			gids[i] = g
+		default:
+			code := v.Codes[i]
+			if byCode[code] < 0 {
+				byCode[code] = newGroup(i)
+			}
+			gids[i] = byCode[code]
+		}
+	}
+	return gids, keys
+}
+
+// aggregateVector adalah agregasi GroupBy untuk semua grup sekaligus
+// dalam satu kali lewat atas v.
+func aggregateVector(v *tangki.Vector, funcName string, gids []int32, counts []int) ([]float64, error) {
+	groups := len(counts)
+	result := make([]float64, groups)
+	switch funcName {
+	case "SUM", "AVG", "MAX", "MIN":
+	case "COUNT":
+		for g, c := range counts {
+			result[g] = float64(c)
+		}
+		return result, nil
+	default:
+		if groups == 0 {
+			return result, nil
+		}
+		return nil, fmt.Errorf("fungsi agregasi tidak dikenal: %s", funcName)
+	}
+
+	value := vectorFloats(v)
+	seen := make([]bool, groups)
+	for i, g := range gids {
+		val := 0.0
+		if !v.IsNull(i) {
+			val = value(i)
+		}
+		switch funcName {
+		case "SUM", "AVG":
+			result[g] += val
+		case "MAX":
+			if !seen[g] || val > result[g] {
+				result[g] = val
+			}
+		case "MIN":
+			if !seen[g] || val < result[g] {
+				result[g] = val
+			}
+		}
+		seen[g] = true
+	}
+	if funcName == "AVG" {
+		for g := range result {
+			result[g] /= float64(counts[g])
+		}
+	}
+	return result, nil
+}
+
+// vectorFloats mengembalikan nilai baris sebagai float64 seperti
+// toFloatAJAX. Teks diparse sekali per entri kamus.
+func vectorFloats(v *tangki.Vector) func(i int) float64 {
+	switch v.Type {
+	case "INT":
+		return func(i int) float64 { return float64(v.Ints[i]) }
+	case "FLOAT":
+		return func(i int) float64 { return v.Floats[i] }
+	}
+	dict := make([]float64, len(v.Dict))
+	for code, s := range v.Dict {
+		dict[code] = toFloatAJAX(s)
+	}
+	return func(i int) float64 { return dict[v.Codes[i]] }
+}
+
+// orderVector mengembalikan urutan baris v seperti OrderBy: nilai
+// dibandingkan sebagai angka lewat toFloatAJAX, jadi NULL dan TEKS yang
+// bukan angka dianggap 0. Urutan tidak terdefinisi bila c dibatalkan.
+func orderVector(c *Canceler, v *tangki.Vector, desc bool) []int {
+	n := v.Len()
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+
+	value := vectorFloats(v)
+	keys := make([]float64, n)
+	for i := range keys {
+		if !v.IsNull(i) {
+			keys[i] = value(i)
+		}
+	}
+	sort.Slice(perm, func(i, j int) bool {
+		if c.Canceled() {
+			return false
+		}
+		a, b := keys[perm[i]], keys[perm[j]]
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+	return perm
+}
+
+// vectorFilter menghitung bitmap baris yang lolos predikat DIMANA yang
+// hanya berisi perbandingan kolom dengan literal, DAN, dan ATAU. Nilai
+// tidak diketahui (NULL) diperlakukan sebagai tidak lolos, sama seperti
+// CompilePredicate.
+func vectorFilter(cols *tangki.Columnar, expr parser.Expr, schema Schema) ([]uint64, bool) {
+	e, ok := expr.(*parser.BinaryExpr)
+	if !ok {
+		return nil, false
+	}
+	switch e.Operator {
+	case "DAN", "ATAU":
+		left, ok := vectorFilter(cols, e.Left, schema)
+		if !ok {
+			return nil, false
+		}
+		right, ok := vectorFilter(cols, e.Right, schema)
+		if !ok {
+			return nil, false
+		}
+		for i := range left {
+			if e.Operator == "DAN" {
+				left[i] &= right[i]
+			} else {
+				left[i] |= right[i]
+			}
+		}
+		return left, true
+	case "=", "!=", ">", "<", ">=", "<=":
+		return compareVector(cols, e, schema)
+	}
+	return nil, false
+}
+
+func compareVector(cols *tangki.Columnar, e *parser.BinaryExpr, schema Schema) ([]uint64, bool) {
+	ref, refOK := e.Left.(*parser.ColumnRef)
+	lit, litOK := e.Right.(*parser.Literal)
+	litLeft := false
+	if !refOK || !litOK {
+		ref, refOK = e.Right.(*parser.ColumnRef)
+		lit, litOK = e.Left.(*parser.Literal)
+		litLeft = true
+	}
+	if !refOK || !litOK {
+		return nil, false
+	}
+	idx, err := schema.Resolve(ref.Table, ref.Column)
+	if err != nil || idx >= len(cols.Vectors) || cols.Vectors[idx] == nil {
+		return nil, false
+	}
+
+	v := cols.Vectors[idx]
+	n := v.Len()
+	mask := make([]uint64, (n+63)/64)
+	if lit.Value == nil {
+		return mask, true
+	}
+
+	op := e.Operator
+	switch v.Type {
+	case "TEKS":
+		match := make([]bool, len(v.Dict))
+		for code, s := range v.Dict {
+			if litLeft {
+				match[code] = compareValues(lit.Value, op, s)
+			} else {
+				match[code] = compareValues(s, op, lit.Value)
+			}
+		}
+		for i, code := range v.Codes {
+			if match[code] {
+				mask[i/64] |= 1 << (i % 64)
+			}
+		}
+	default:
+		if litLeft {
+			op = flipComparison(op)
+		}
+		f := toFloatAJAX(lit.Value)
+		value := vectorFloats(v)
+		for i := 0; i < n; i++ {
+			if evalFloat64(value(i), op, f) {
+				mask[i/64] |= 1 << (i % 64)
+			}
+		}
+	}
+
+	for w := range v.Nulls {
+		mask[w] &^= v.Nulls[w]
+	}
+	return mask, true
+}
@@ -0,0 +1,341 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Dziqha/BensinDB/pkg/parser"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// Window menghitung fungsi window (ROW_NUMBER, RANK, SUM ... OVER (...))
+// yang muncul di daftar PILIH. Hasil setiap fungsi ditambahkan sebagai
+// kolom tersembunyi di akhir relasi; Project kemudian membaca kolom itu
+// saat mengevaluasi WindowExpr.
+func Window(rel *Relation, items []parser.SelectItem, env *Env) (*Relation, error) {
+	var windows []*parser.WindowExpr
+	for _, item := range items {
+		collectWindows(item.Expr, &windows)
+	}
+	if len(windows) == 0 {
+		return rel, nil
+	}
+	if env == nil {
+		return nil, fmt.Errorf("fungsi window tidak didukung di sini")
+	}
+
+	width := len(rel.Schema)
+	schema := make(Schema, width, width+len(windows))
+	copy(schema, rel.Schema)
+	rows := make([]tangki.Row, len(rel.Rows))
+	for i, row := range rel.Rows {
+		out := make(tangki.Row, width, width+len(windows))
+		copy(out, row)
+		rows[i] = out
+	}
+
+	if env.windows == nil {
+		env.windows = make(map[*parser.WindowExpr]int)
+	}
+	for _, w := range windows {
+		values, err := env.evalWindow(w, rel)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			rows[i] = append(rows[i], values[i])
+		}
+		env.windows[w] = len(schema)
+		schema = append(schema, Field{Name: strings.ToLower(w.Func), Type: env.windowType(w, rel.Schema), Hidden: true})
+	}
+	return &Relation{Schema: schema, Rows: rows}, nil
+}
+
+func collectWindows(expr parser.Expr, out *[]*parser.WindowExpr) {
+	switch e := expr.(type) {
+	case *parser.WindowExpr:
+		*out = append(*out, e)
+	case *parser.BinaryExpr:
+		collectWindows(e.Left, out)
+		collectWindows(e.Right, out)
+	}
+}
+
+func (env *Env) compileWindowRef(w *parser.WindowExpr) (Evaluator, error) {
+	idx, ok := env.windowIndex(w)
+	if !ok {
+		return nil, fmt.Errorf("fungsi window %s hanya boleh dipakai di daftar PILIH", w.Func)
+	}
+	return func(row tangki.Row) interface{} { return row[idx] }, nil
+}
+
+func (env *Env) windowIndex(w *parser.WindowExpr) (int, bool) {
+	if env == nil {
+		return 0, false
+	}
+	idx, ok := env.windows[w]
+	return idx, ok
+}
+
+func (env *Env) windowType(w *parser.WindowExpr, schema Schema) string {
+	switch w.Func {
+	case "SUM", "AVG":
+		return "FLOAT"
+	case "MIN", "MAX", "LAG", "LEAD":
+		if len(w.Args) > 0 {
+			return env.ExprType(w.Args[0], schema)
+		}
+	}
+	return "INT"
+}
+
+// windowArity adalah jumlah argumen minimum dan maksimum tiap fungsi.
+var windowArity = map[string][2]int{
+	"ROW_NUMBER": {0, 0},
+	"RANK":       {0, 0},
+	"DENSE_RANK": {0, 0},
+	"SUM":        {1, 1},
+	"AVG":        {1, 1},
+	"MIN":        {1, 1},
+	"MAX":        {1, 1},
+	"COUNT":      {0, 1},
+	"LAG":        {1, 3},
+	"LEAD":       {1, 3},
+}
+
+// evalWindow menghitung satu fungsi window untuk setiap baris rel.
+// Setiap baris diubah menjadi baris kerja [partisi..., urutan..., argumen...,
+// posisi] agar bisa dikelompokkan dengan groupRows dan diurutkan dengan
+// sortRows.
+func (env *Env) evalWindow(w *parser.WindowExpr, rel *Relation) ([]interface{}, error) {
+	arity, ok := windowArity[w.Func]
+	if !ok {
+		return nil, fmt.Errorf("fungsi window tidak dikenal: %s", w.Func)
+	}
+	if len(w.Args) < arity[0] || len(w.Args) > arity[1] {
+		return nil, fmt.Errorf("jumlah argumen %s salah: %d", w.Func, len(w.Args))
+	}
+
+	exprs := append([]parser.Expr{}, w.PartitionBy...)
+	for _, item := range w.OrderBy {
+		exprs = append(exprs, item.Expr)
+	}
+	exprs = append(exprs, w.Args...)
+
+	evals := make([]Evaluator, len(exprs))
+	for i, expr := range exprs {
+		eval, err := env.Compile(expr, rel.Schema)
+		if err != nil {
+			return nil, err
+		}
+		evals[i] = eval
+	}
+
+	posIdx := len(evals)
+	work := make([]tangki.Row, len(rel.Rows))
+	for i, row := range rel.Rows {
+		wr := make(tangki.Row, posIdx+1)
+		for j, eval := range evals {
+			wr[j] = eval(row)
+		}
+		wr[posIdx] = i
+		work[i] = wr
+	}
+	if err := env.Err(); err != nil {
+		return nil, err
+	}
+
+	numPart := len(w.PartitionBy)
+	orderKeys := make([]sortKey, len(w.OrderBy))
+	for i, item := range w.OrderBy {
+		orderKeys[i] = sortKey{Index: numPart + i, Desc: item.Desc}
+	}
+	argIdx := numPart + len(w.OrderBy)
+
+	result := make([]interface{}, len(rel.Rows))
+	keys, groups := groupRows(env.canceler(), work, func(row tangki.Row) string {
+		return RowKey(row[:numPart])
+	})
+	for _, key := range keys {
+		part := groups[key]
+		sortRows(env.canceler(), part, orderKeys)
+
+		values := windowValues(w.Func, part, orderKeys, argIdx)
+		for i, row := range part {
+			result[row[posIdx].(int)] = values[i]
+		}
+	}
+	if err := env.canceler().Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// windowValues menghitung nilai fungsi untuk satu partisi yang sudah
+// terurut. Agregat memakai frame standar SQL: dari awal partisi sampai
+// baris sekarang beserta semua baris yang setara urutannya; tanpa URUTKAN
+// seluruh partisi dianggap setara.
+func windowValues(fn string, part []tangki.Row, orderKeys []sortKey, argIdx int) []interface{} {
+	values := make([]interface{}, len(part))
+
+	switch fn {
+	case "ROW_NUMBER":
+		for i := range part {
+			values[i] = int64(i + 1)
+		}
+		return values
+
+	case "LAG", "LEAD":
+		for i, row := range part {
+			offset := int64(1)
+			if argIdx+1 < len(row)-1 {
+				if n, ok := asInt64(row[argIdx+1]); ok {
+					offset = n
+				}
+			}
+			if fn == "LAG" {
+				offset = -offset
+			}
+			j := int64(i) + offset
+			switch {
+			case j >= 0 && j < int64(len(part)):
+				values[i] = part[j][argIdx]
+			case argIdx+2 < len(row)-1:
+				values[i] = row[argIdx+2]
+			}
+		}
+		return values
+	}
+
+	acc := newWindowAccumulator(fn)
+	rank, dense := int64(0), int64(0)
+	for start := 0; start < len(part); {
+		end := start + 1
+		for end < len(part) && compareByKeys(part[start], part[end], orderKeys) == 0 {
+			end++
+		}
+
+		rank, dense = int64(start+1), dense+1
+		for _, row := range part[start:end] {
+			if argIdx < len(row)-1 {
+				acc.add(row[argIdx])
+			} else {
+				acc.add(true)
+			}
+		}
+
+		for i := start; i < end; i++ {
+			switch fn {
+			case "RANK":
+				values[i] = rank
+			case "DENSE_RANK":
+				values[i] = dense
+			default:
+				values[i] = acc.result()
+			}
+		}
+		start = end
+	}
+	return values
+}
+
+// windowAccumulator menyimpan agregat berjalan untuk SUM/AVG/COUNT/MIN/MAX.
+// NULL diabaikan seperti di SQL.
+type windowAccumulator struct {
+	fn    string
+	sum   float64
+	count int64
+	best  interface{}
+}
+
+func newWindowAccumulator(fn string) *windowAccumulator {
+	return &windowAccumulator{fn: fn}
+}
+
+func (a *windowAccumulator) add(v interface{}) {
+	if v == nil {
+		return
+	}
+	a.count++
+	switch a.fn {
+	case "SUM", "AVG":
+		a.sum += toFloatAJAX(v)
+	case "MIN":
+		if a.best == nil || CompareOrder(v, a.best) < 0 {
+			a.best = v
+		}
+	case "MAX":
+		if a.best == nil || CompareOrder(v, a.best) > 0 {
+			a.best = v
+		}
+	}
+}
+
+func (a *windowAccumulator) result() interface{} {
+	switch a.fn {
+	case "COUNT":
+		return a.count
+	case "SUM":
+		if a.count == 0 {
+			return nil
+		}
+		return a.sum
+	case "AVG":
+		if a.count == 0 {
+			return nil
+		}
+		return a.sum / float64(a.count)
+	}
+	return a.best
+}
+
+// sortKey adalah satu kunci pengurutan window: posisi kolom dan arahnya.
+type sortKey struct {
+	Index int
+	Desc  bool
+}
+
+// sortRows mengurutkan rows di tempat secara stabil berdasarkan keys, dengan
+// NULL paling kecil. Pengurutan berhenti begitu c dibatalkan; urutan rows
+// lalu tidak terdefinisi dan pemanggil harus memeriksa c.Err().
+func sortRows(c *Canceler, rows []tangki.Row, keys []sortKey) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if c.Canceled() {
+			return false
+		}
+		return compareByKeys(rows[i], rows[j], keys) < 0
+	})
+}
+
+func compareByKeys(a, b tangki.Row, keys []sortKey) int {
+	for _, k := range keys {
+		c := CompareOrder(a[k.Index], b[k.Index])
+		if c == 0 {
+			continue
+		}
+		if k.Desc {
+			return -c
+		}
+		return c
+	}
+	return 0
+}
+
+// groupRows mengelompokkan rows berdasarkan kunci, dengan urutan grup
+// sesuai kemunculan pertama.
+func groupRows(c *Canceler, rows []tangki.Row, key func(tangki.Row) string) ([]string, map[string][]tangki.Row) {
+	var order []string
+	groups := make(map[string][]tangki.Row)
+	for _, row := range rows {
+		if c.Canceled() {
+			break
+		}
+		k := key(row)
+		if _, seen := groups[k]; !seen {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], row)
+	}
+	return order, groups
+}
@@ -0,0 +1,251 @@
+package tangki
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Baris ([]Row) selalu menjadi isi utama tangki. Cache kolom (lihat
+// SetColumnCache) adalah salinan per kolom yang dibangun saat pertama
+// dibaca dan dipakai oleh agregasi, pengurutan, dan filter tervektorisasi.
+
+// Vector adalah satu kolom dalam cache kolom. Sesuai Type, hanya salah
+// satu dari Ints, Floats, atau Codes yang terisi. TEKS disimpan sebagai
+// kode ke Dict (dictionary encoding) dengan urutan kemunculan pertama.
+type Vector struct {
+	Type   string
+	Ints   []int64
+	Floats []float64
+	Codes  []uint32
+	Dict   []string
+	// Nulls: bit i menyala bila baris i NULL; nil bila tidak ada NULL.
+	Nulls []uint64
+
+	// codes memetakan teks ke kodenya di Dict, untuk menambah baris
+	codes map[string]uint32
+}
+
+// Len mengembalikan jumlah baris v.
+func (v *Vector) Len() int {
+	switch v.Type {
+	case "INT":
+		return len(v.Ints)
+	case "FLOAT":
+		return len(v.Floats)
+	}
+	return len(v.Codes)
+}
+
+// IsNull berarti baris i bernilai NULL.
+func (v *Vector) IsNull(i int) bool {
+	return v.Nulls != nil && v.Nulls[i/64]&(1<<(i%64)) != 0
+}
+
+// Value mengembalikan nilai baris i sebagai int64, float64, string, atau
+// nil untuk NULL.
+func (v *Vector) Value(i int) interface{} {
+	if v.IsNull(i) {
+		return nil
+	}
+	switch v.Type {
+	case "INT":
+		return v.Ints[i]
+	case "FLOAT":
+		return v.Floats[i]
+	}
+	return v.Dict[v.Codes[i]]
+}
+
+func (v *Vector) setNull(i, n int) {
+	if v.Nulls == nil {
+		v.Nulls = make([]uint64, (n+63)/64)
+	}
+	v.Nulls[i/64] |= 1 << (i % 64)
+}
+
+// Columnar adalah isi tangki per kolom. Vectors[i] nil bila kolom i berisi
+// nilai yang tidak sesuai tipenya (misalnya FLOAT di kolom INT setelah ATUR
+// dengan ekspresi); pemakainya lalu kembali ke jalur per baris.
+type Columnar struct {
+	Rows    int
+	Vectors []*Vector
+
+	// extended menyala setelah satu versi berikutnya menambahkan baris ke
+	// array milik c; versi lain harus membangun salinannya sendiri.
+	extended atomic.Bool
+}
+
+// BuildColumnar menyalin rows per kolom.
+func BuildColumnar(columns []Column, rows []Row) *Columnar {
+	c := &Columnar{Rows: len(rows), Vectors: make([]*Vector, len(columns))}
+	for i, col := range columns {
+		c.Vectors[i] = buildVector(col.Type, rows, i)
+	}
+	return c
+}
+
+func buildVector(typ string, rows []Row, idx int) *Vector {
+	n := len(rows)
+	v := &Vector{Type: typ}
+	switch typ {
+	case "INT":
+		v.Ints = make([]int64, 0, n)
+	case "FLOAT":
+		v.Floats = make([]float64, 0, n)
+	case "TEKS":
+		v.Codes = make([]uint32, 0, n)
+		v.codes = make(map[string]uint32)
+	default:
+		return nil
+	}
+	if !v.append(rows, idx, 0) {
+		return nil
+	}
+	return v
+}
+
+// append menambahkan kolom idx dari rows setelah baris ke-start v. Hasilnya
+// false bila ada nilai yang tidak sesuai tipe v.
+func (v *Vector) append(rows []Row, idx, start int) bool {
+	n := start + len(rows)
+	for i, row := range rows {
+		val := row[idx]
+		if val == nil {
+			v.setNull(start+i, n)
+		}
+		switch x := val.(type) {
+		case nil:
+			switch v.Type {
+			case "INT":
+				v.Ints = append(v.Ints, 0)
+			case "FLOAT":
+				v.Floats = append(v.Floats, 0)
+			default:
+				v.Codes = append(v.Codes, 0)
+			}
+		case int:
+			if v.Type != "INT" {
+				return false
+			}
+			v.Ints = append(v.Ints, int64(x))
+		case int64:
+			if v.Type != "INT" {
+				return false
+			}
+			v.Ints = append(v.Ints, x)
+		case float64:
+			if v.Type != "FLOAT" {
+				return false
+			}
+			v.Floats = append(v.Floats, x)
+		case string:
+			if v.Type != "TEKS" {
+				return false
+			}
+			code, ok := v.codes[x]
+			if !ok {
+				code = uint32(len(v.Dict))
+				v.codes[x] = code
+				v.Dict = append(v.Dict, x)
+			}
+			v.Codes = append(v.Codes, code)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// extend mengembalikan c ditambah rows. Array c dipakai bersama: c tetap
+// membaca sampai panjangnya sendiri, sedangkan hasilnya menulis setelah
+// itu. Hanya boleh dipanggil sekali per c (lihat extended).
+func (c *Columnar) extend(columns []Column, rows []Row) *Columnar {
+	next := &Columnar{Rows: c.Rows + len(rows), Vectors: make([]*Vector, len(c.Vectors))}
+	for i, v := range c.Vectors {
+		if v == nil {
+			continue
+		}
+		nv := *v
+		// Bitmap NULL disalin karena kata terakhirnya juga dibaca oleh c
+		if v.Nulls != nil {
+			nv.Nulls = make([]uint64, (next.Rows+63)/64)
+			copy(nv.Nulls, v.Nulls)
+		}
+		if nv.append(rows, i, c.Rows) {
+			next.Vectors[i] = &nv
+		}
+	}
+	return next
+}
+
+// columnarCache menyimpan cache kolom untuk satu versi tangki. base, bila
+// ada, adalah cache versi sebelumnya yang barisnya merupakan awalan baris
+// versi ini, sehingga hanya baris baru yang perlu ditambahkan.
+type columnarCache struct {
+	once sync.Once
+	data atomic.Pointer[Columnar]
+	base *Columnar
+}
+
+func (c *columnarCache) build(columns []Column, rows []Row) *Columnar {
+	if b := c.base; b != nil && b.Rows <= len(rows) {
+		if b.Rows == len(rows) {
+			return b
+		}
+		if b.extended.CompareAndSwap(false, true) {
+			return b.extend(columns, rows[b.Rows:])
+		}
+	}
+	return BuildColumnar(columns, rows)
+}
+
+// ColumnCache berarti t menyimpan cache kolom (lihat SetColumnCache).
+func (t *Tangki) ColumnCache() bool {
+	return t.columnCache
+}
+
+// SetColumnCache menyalakan atau mematikan cache kolom t. Selama menyala,
+// Columnar mengembalikan salinan isi t per kolom. Baris tetap menjadi isi
+// utama t, jadi cache menambah memori sebesar satu salinan lagi.
+func (t *Tangki) SetColumnCache(on bool) {
+	t.columnCache = on
+	t.resetColumnar()
+}
+
+// Columnar mengembalikan cache kolom t, atau nil bila cache mati atau t
+// berhalaman. Cache dibangun sekali per versi tangki lalu disimpan,
+// sehingga aman dipanggil dari beberapa pembaca bersamaan. Versi yang
+// hanya menambah baris (ISI) memakai ulang cache versi sebelumnya.
+func (t *Tangki) Columnar() *Columnar {
+	if !t.columnCache || t.paged != nil {
+		return nil
+	}
+	if t.vectors == nil {
+		return BuildColumnar(t.Columns, t.Rows)
+	}
+	t.vectors.once.Do(func() {
+		t.vectors.data.Store(t.vectors.build(t.Columns, t.Rows))
+	})
+	return t.vectors.data.Load()
+}
+
+// resetColumnar membuang cache kolom setelah baris t berubah.
+func (t *Tangki) resetColumnar() {
+	t.vectors = nil
+	if t.columnCache {
+		t.vectors = &columnarCache{}
+	}
+}
+
+// keepColumnar menyiapkan cache kolom untuk versi t berikutnya yang hanya
+// menambah baris, dengan cache yang sudah dibangun sebagai dasarnya.
+func (t *Tangki) keepColumnar() {
+	if t.vectors == nil {
+		return
+	}
+	base := t.vectors.base
+	if data := t.vectors.data.Load(); data != nil {
+		base = data
+	}
+	t.vectors = &columnarCache{base: base}
+}
@@ -0,0 +1,52 @@
+package tangki
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ToInt64, ToFloat64, dan ToText mengubah nilai baris ke tipe kolom untuk
+// format ekspor bertipe (Parquet, Arrow), sama seperti AddRow: FLOAT di
+// kolom INT dipotong dan nilai lain di kolom TEKS ditulis sebagai teks.
+
+func ToInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("tipe data tidak sesuai untuk INT")
+}
+
+func ToFloat64(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("tipe data tidak sesuai untuk FLOAT")
+}
+
+func ToText(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(val)
+}
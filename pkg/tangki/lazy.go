@@ -0,0 +1,38 @@
+package tangki
+
+import "sync"
+
+// lazyLoad menunda pembacaan isi tangki sampai Resolve pertama.
+type lazyLoad struct {
+	once   sync.Once
+	load   func() (*Tangki, error)
+	loaded *Tangki
+	err    error
+}
+
+// NewLazyTangki membuat tangki pengganti yang baru memanggil load saat
+// Resolve pertama kali dipanggil. rows adalah jumlah baris yang sudah
+// diketahui, untuk Len sebelum tangki dimuat.
+func NewLazyTangki(name string, columns []Column, rows int, load func() (*Tangki, error)) *Tangki {
+	return &Tangki{Name: name, Columns: columns, lazyRows: rows, lazy: &lazyLoad{load: load}}
+}
+
+// Lazy berarti isi t belum dimuat; panggil Resolve sebelum membaca baris.
+func (t *Tangki) Lazy() bool {
+	return t.lazy != nil
+}
+
+// Resolve mengembalikan tangki yang isinya sudah dimuat. Untuk tangki biasa
+// hasilnya t sendiri. Untuk tangki dari NewLazyTangki, load dipanggil
+// sekali dan hasil (atau error-nya) dipakai bersama oleh semua pemanggil.
+func (t *Tangki) Resolve() (*Tangki, error) {
+	if t.lazy == nil {
+		return t, nil
+	}
+	l := t.lazy
+	l.once.Do(func() {
+		l.loaded, l.err = l.load()
+		l.load = nil
+	})
+	return l.loaded, l.err
+}
@@ -0,0 +1,479 @@
+package tangki
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"runtime"
+
+	"github.com/Dziqha/BensinDB/pkg/pager"
+)
+
+// PageRef menunjuk satu halaman berisi baris tangki: Rows baris pertama di
+// halaman itu, yang berakhir di byte End. Isi halaman setelah End belum
+// menjadi bagian dari versi tangki ini.
+type PageRef struct {
+	ID   pager.PageID
+	Rows int
+	End  int
+}
+
+// Tag nilai di dalam halaman. Setiap nilai diberi tag sendiri agar nilai
+// yang tipenya tidak sesuai kolom (misalnya FLOAT di kolom INT setelah ATUR
+// dengan ekspresi) tetap utuh.
+const (
+	pageNull  = 0
+	pageInt   = 1
+	pageFloat = 2
+	pageTeks  = 3
+)
+
+// pagedRows menyimpan baris tangki di halaman-halaman buffer pool. Baris
+// hanya ditambahkan di akhir: halaman penuh (full) tidak pernah berubah,
+// sedangkan baris baru ditulis ke halaman ekor (tail) setelah End-nya.
+// Versi lama hasil Fork tetap membaca sampai End miliknya sendiri.
+//
+// full boleh dipakai bersama antarversi. Append hanya boleh dipanggil pada
+// fork dari versi terbaru, sehingga elemen setelah len(full) di array yang
+// sama tidak pernah dibaca oleh versi lain.
+//
+// Setiap versi menahan halamannya lewat lease sampai versi itu tidak
+// terjangkau lagi (termasuk oleh snapshot lama dan QueryIter terbuka);
+// sesudahnya halaman yang tidak ditahan versi lain boleh dipakai ulang.
+type pagedRows struct {
+	pool    *pager.BufferPool
+	lease   *pager.Lease
+	full    []PageRef
+	tail    PageRef
+	hasTail bool
+	count   int
+}
+
+func newPagedRows(pool *pager.BufferPool, lease *pager.Lease) *pagedRows {
+	p := &pagedRows{pool: pool, lease: lease}
+	runtime.AddCleanup(p, (*pager.Lease).Release, lease)
+	return p
+}
+
+// fork menyalin p dengan lease sendiri atas halaman yang sama.
+func (p *pagedRows) fork() *pagedRows {
+	f := newPagedRows(p.pool, p.lease.Clone())
+	f.full, f.tail, f.hasTail, f.count = p.full, p.tail, p.hasTail, p.count
+	return f
+}
+
+func (p *pagedRows) refs() []PageRef {
+	refs := append([]PageRef(nil), p.full...)
+	if p.hasTail {
+		refs = append(refs, p.tail)
+	}
+	return refs
+}
+
+func (p *pagedRows) append(row Row) error {
+	enc := encodePageRow(nil, row)
+	if len(enc) > pager.PageSize {
+		return fmt.Errorf("baris %d byte terlalu besar untuk satu halaman (%d byte)", len(enc), pager.PageSize)
+	}
+
+	if p.hasTail && p.tail.End+len(enc) <= pager.PageSize {
+		pg, err := p.pool.Fetch(p.tail.ID)
+		if err != nil {
+			return err
+		}
+		pg.Lock()
+		copy(pg.Data[p.tail.End:], enc)
+		pg.Unlock()
+		p.pool.Unpin(pg, true)
+		p.tail.Rows++
+		p.tail.End += len(enc)
+		p.count++
+		return nil
+	}
+
+	pg, err := p.pool.New()
+	if err != nil {
+		return err
+	}
+	p.lease.Add(pg.ID)
+	pg.Lock()
+	copy(pg.Data, enc)
+	pg.Unlock()
+	p.pool.Unpin(pg, true)
+	if p.hasTail {
+		p.full = append(p.full, p.tail)
+	}
+	p.tail = PageRef{ID: pg.ID, Rows: 1, End: len(enc)}
+	p.hasTail = true
+	p.count++
+	return nil
+}
+
+// readPage mendekode semua baris ref. Halaman hanya di-pin selama
+// didekode, jadi pemanggil boleh memegang hasilnya selama apa pun.
+func (p *pagedRows) readPage(ref PageRef, rows []Row) ([]Row, error) {
+	pg, err := p.pool.Fetch(ref.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer p.pool.Unpin(pg, false)
+	pg.RLock()
+	defer pg.RUnlock()
+
+	data := pg.Data[:ref.End]
+	for i := 0; i < ref.Rows; i++ {
+		row, n, err := decodePageRow(data)
+		if err != nil {
+			return nil, fmt.Errorf("halaman %d rusak: %v", ref.ID, err)
+		}
+		rows = append(rows, row)
+		data = data[n:]
+	}
+	return rows, nil
+}
+
+func encodePageRow(b []byte, row Row) []byte {
+	b = binary.AppendUvarint(b, uint64(len(row)))
+	for _, val := range row {
+		switch v := val.(type) {
+		case nil:
+			b = append(b, pageNull)
+		case int:
+			b = append(b, pageInt)
+			b = binary.AppendVarint(b, int64(v))
+		case int64:
+			b = append(b, pageInt)
+			b = binary.AppendVarint(b, v)
+		case float64:
+			b = append(b, pageFloat)
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+		default:
+			s := fmt.Sprint(v)
+			b = append(b, pageTeks)
+			b = binary.AppendUvarint(b, uint64(len(s)))
+			b = append(b, s...)
+		}
+	}
+	return b
+}
+
+func decodePageRow(data []byte) (Row, int, error) {
+	pos := 0
+	uvarint := func() (uint64, bool) {
+		v, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return 0, false
+		}
+		pos += n
+		return v, true
+	}
+
+	cols, ok := uvarint()
+	if !ok || cols > uint64(len(data)) {
+		return nil, 0, fmt.Errorf("jumlah kolom tidak valid")
+	}
+	row := make(Row, cols)
+	for i := range row {
+		if pos >= len(data) {
+			return nil, 0, fmt.Errorf("baris terpotong")
+		}
+		tag := data[pos]
+		pos++
+		switch tag {
+		case pageNull:
+		case pageInt:
+			v, n := binary.Varint(data[pos:])
+			if n <= 0 {
+				return nil, 0, fmt.Errorf("INT tidak valid")
+			}
+			pos += n
+			row[i] = v
+		case pageFloat:
+			if pos+8 > len(data) {
+				return nil, 0, fmt.Errorf("FLOAT terpotong")
+			}
+			row[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[pos:]))
+			pos += 8
+		case pageTeks:
+			l, ok := uvarint()
+			if !ok || l > uint64(len(data)-pos) {
+				return nil, 0, fmt.Errorf("TEKS terpotong")
+			}
+			row[i] = string(data[pos : pos+int(l)])
+			pos += int(l)
+		default:
+			return nil, 0, fmt.Errorf("tag nilai %d tidak dikenal", tag)
+		}
+	}
+	return row, pos, nil
+}
+
+// NewPagedTangki membuat tangki yang barisnya tersimpan di halaman pool,
+// misalnya saat dimuat dari file. refs adalah hasil Pages dari versi yang
+// disimpan; halaman terakhir menjadi ekor untuk baris berikutnya.
+func NewPagedTangki(name string, columns []Column, pool *pager.BufferPool, refs []PageRef) *Tangki {
+	ids := make([]pager.PageID, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref.ID
+	}
+	p := newPagedRows(pool, pool.Pager().Lease(ids))
+	for i, ref := range refs {
+		p.count += ref.Rows
+		if i == len(refs)-1 {
+			p.tail, p.hasTail = ref, true
+		} else {
+			p.full = append(p.full, ref)
+		}
+	}
+	return &Tangki{Name: name, Columns: columns, paged: p}
+}
+
+// Paged berarti baris t tersimpan di halaman, bukan di t.Rows. Tangki
+// seperti ini dibaca dengan Scan atau Cursor; Materialize memuat semua
+// barisnya ke memori.
+func (t *Tangki) Paged() bool {
+	return t.paged != nil
+}
+
+// Pages mengembalikan halaman-halaman t, atau nil bila t tidak berhalaman.
+func (t *Tangki) Pages() []PageRef {
+	if t.paged == nil {
+		return nil
+	}
+	return t.paged.refs()
+}
+
+// Len mengembalikan jumlah baris t.
+func (t *Tangki) Len() int {
+	if t.paged != nil {
+		return t.paged.count
+	}
+	if t.lazy != nil {
+		return t.lazyRows
+	}
+	return len(t.Rows)
+}
+
+// WritePages memindahkan t.Rows ke halaman baru di pool. Setelahnya t
+// menjadi tangki berhalaman dan t.Rows kosong.
+func (t *Tangki) WritePages(pool *pager.BufferPool) error {
+	if t.paged != nil {
+		return nil
+	}
+	p := newPagedRows(pool, pool.Pager().Lease(nil))
+	for _, row := range t.Rows {
+		if err := p.append(row); err != nil {
+			return err
+		}
+	}
+	t.paged = p
+	t.Rows = nil
+	t.pool = nil
+	t.resetColumnar()
+	return nil
+}
+
+// AppendRow menambahkan row apa adanya tanpa validasi tipe seperti AddRow,
+// untuk baris yang sudah berasal dari tangki lain.
+func (t *Tangki) AppendRow(row Row) error {
+	if t.paged != nil {
+		return t.paged.append(row)
+	}
+	t.Rows = append(t.Rows, row)
+	t.keepColumnar()
+	return nil
+}
+
+// RewriteRows mengganti setiap baris t dengan hasil fn; keep=false
+// membuang baris itu. Tangki berhalaman ditulis ke halaman baru sambil
+// dibaca per halaman, jadi tidak pernah dimuat seluruhnya ke memori.
+// Hanya untuk versi yang boleh diubah (hasil Fork).
+func (t *Tangki) RewriteRows(fn func(Row) (Row, bool)) error {
+	next, err := t.rewriteRows(fn)
+	if err != nil {
+		return err
+	}
+	next()
+	return nil
+}
+
+// rewriteRows membaca semua baris melalui fn tanpa mengubah t dan
+// mengembalikan fungsi yang memasang hasilnya, agar pemanggil bisa
+// membatalkan perubahan (misalnya bila tidak ada baris yang cocok).
+func (t *Tangki) rewriteRows(fn func(Row) (Row, bool)) (func(), error) {
+	if t.paged == nil {
+		rows := make([]Row, 0, len(t.Rows))
+		for _, row := range t.Rows {
+			if row, keep := fn(row); keep {
+				rows = append(rows, row)
+			}
+		}
+		return func() {
+			t.Rows = rows
+			t.resetColumnar()
+		}, nil
+	}
+
+	p := newPagedRows(t.paged.pool, t.paged.pool.Pager().Lease(nil))
+	var err error
+	scanErr := t.Scan(func(row Row) bool {
+		if row, keep := fn(row); keep {
+			err = p.append(row)
+		}
+		return err == nil
+	})
+	if err == nil {
+		err = scanErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		t.paged = p
+		t.resetColumnar()
+	}, nil
+}
+
+// LoadRows memuat baris tangki berhalaman ke t.Rows sehingga t menjadi
+// tangki biasa. Hanya untuk versi yang boleh diubah (hasil Fork).
+func (t *Tangki) LoadRows() error {
+	if t.paged == nil {
+		return nil
+	}
+	rows, err := t.readAll()
+	if err != nil {
+		return err
+	}
+	t.Rows = rows
+	t.paged = nil
+	t.resetColumnar()
+	return nil
+}
+
+// Materialize mengembalikan t bila barisnya sudah di memori, atau salinan
+// t dengan semua baris dimuat dari halaman. t sendiri tidak berubah.
+func (t *Tangki) Materialize() (*Tangki, error) {
+	if t.paged == nil {
+		return t, nil
+	}
+	m := t.Fork()
+	if err := m.LoadRows(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Copy seperti Materialize, tetapi selalu mengembalikan salinan yang
+// kolom dan barisnya boleh diubah pemanggil tanpa memengaruhi t. Cache
+// kolom t tetap dipakai bersama seperti pada Fork.
+func (t *Tangki) Copy() (*Tangki, error) {
+	c, err := t.Materialize()
+	if err != nil {
+		return nil, err
+	}
+	if c == t {
+		c = t.Fork()
+		c.Rows = make([]Row, len(t.Rows))
+		for i, row := range t.Rows {
+			c.Rows[i] = row.Clone()
+		}
+	}
+	c.Columns = append([]Column(nil), t.Columns...)
+	return c, nil
+}
+
+func (t *Tangki) readAll() ([]Row, error) {
+	if t.paged == nil {
+		return t.Rows, nil
+	}
+	rows := make([]Row, 0, t.paged.count)
+	for _, ref := range t.paged.refs() {
+		var err error
+		if rows, err = t.paged.readPage(ref, rows); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// RowRange mengembalikan baris ke-from sampai sebelum to.
+func (t *Tangki) RowRange(from, to int) ([]Row, error) {
+	if t.paged == nil {
+		return t.Rows[from:to], nil
+	}
+	var rows []Row
+	start := 0
+	for _, ref := range t.paged.refs() {
+		end := start + ref.Rows
+		if end > from && start < to {
+			page, err := t.paged.readPage(ref, nil)
+			if err != nil {
+				return nil, err
+			}
+			lo, hi := max(from-start, 0), min(to-start, ref.Rows)
+			rows = append(rows, page[lo:hi]...)
+		}
+		start = end
+	}
+	return rows, nil
+}
+
+// Scan memanggil fn untuk setiap baris t sampai fn mengembalikan false.
+func (t *Tangki) Scan(fn func(Row) bool) error {
+	c := t.Cursor()
+	for {
+		row, ok := c.Next()
+		if !ok {
+			return c.Err()
+		}
+		if !fn(row) {
+			return nil
+		}
+	}
+}
+
+// Cursor membaca baris t satu per satu. Untuk tangki berhalaman hanya
+// satu halaman yang didekode pada satu waktu.
+func (t *Tangki) Cursor() *Cursor {
+	c := &Cursor{rows: t.Rows}
+	if t.paged != nil {
+		c.paged = t.paged
+		c.refs = t.paged.refs()
+		c.rows = nil
+	}
+	return c
+}
+
+// Cursor adalah hasil Tangki.Cursor.
+type Cursor struct {
+	rows  []Row
+	pos   int
+	paged *pagedRows
+	refs  []PageRef
+	err   error
+}
+
+// Next mengembalikan baris berikutnya, atau false bila baris habis atau
+// halaman gagal dibaca (lihat Err).
+func (c *Cursor) Next() (Row, bool) {
+	for c.pos >= len(c.rows) {
+		if c.paged == nil || len(c.refs) == 0 || c.err != nil {
+			return nil, false
+		}
+		rows, err := c.paged.readPage(c.refs[0], c.rows[:0])
+		if err != nil {
+			c.err = err
+			return nil, false
+		}
+		c.refs = c.refs[1:]
+		c.rows, c.pos = rows, 0
+	}
+	row := c.rows[c.pos]
+	c.pos++
+	return row, true
+}
+
+// Err mengembalikan error pembacaan halaman, bila ada.
+func (c *Cursor) Err() error {
+	return c.err
+}
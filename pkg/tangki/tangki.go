@@ -17,6 +17,13 @@ type Tangki struct {
 	Columns []Column
 	Rows    []Row
 	pool    []interface{} 
+	columnCache bool
+	vectors     *columnarCache
+	// paged tidak nil bila baris disimpan di halaman (lihat paged.go)
+	paged *pagedRows
+	// lazy tidak nil bila isi tangki belum dimuat (lihat lazy.go)
+	lazy     *lazyLoad
+	lazyRows int
 }
 
 func NewTangki(name string, columns []Column) *Tangki {
@@ -60,7 +67,14 @@ func (t *Tangki) AddRow(values ...interface{}) error {
 		}
 	}
 
+	if t.paged != nil {
+		row := Row(t.pool[start : start+numCols])
+		t.pool = t.pool[:start]
+		return t.paged.append(row)
+	}
+
 	t.Rows = append(t.Rows, Row(t.pool[start:start+numCols]))
+	t.keepColumnar()
 	return nil
 }
 
@@ -81,38 +95,47 @@ func (t *Tangki) UpdateRows(columnName string, value interface{}, condition func
         return err
     }
     
+    // Baris yang cocok disalin, bukan diubah di tempat, agar versi
+    // lama hasil Fork tetap utuh
     updated := 0
-    for i := range t.Rows {
-        if condition(t.Rows[i]) {
-            t.Rows[i][colIndex] = val
+    next, err := t.rewriteRows(func(row Row) (Row, bool) {
+        if condition(row) {
+            row = row.Clone()
+            row[colIndex] = val
             updated++
         }
+        return row, true
+    })
+    if err != nil {
+        return err
     }
     
     if updated == 0 {
         return fmt.Errorf("tidak ada baris yang di-update")
     }
     
+    next()
     return nil
 }
 
 func (t *Tangki) DeleteRows(condition func(Row) bool) error {
-	newRows := make([]Row, 0)
 	deleted := 0
-	
-	for _, row := range t.Rows {
-		if !condition(row) {
-			newRows = append(newRows, row)
-		} else {
+	next, err := t.rewriteRows(func(row Row) (Row, bool) {
+		if condition(row) {
 			deleted++
+			return nil, false
 		}
+		return row, true
+	})
+	if err != nil {
+		return err
 	}
 	
 	if deleted == 0 {
 		return fmt.Errorf("tidak ada baris yang dihapus")
 	}
 	
-	t.Rows = newRows
+	next()
 	return nil
 }
 
@@ -130,16 +153,13 @@ func (t *Tangki) SelectRows(columnNames []string, condition func(Row) bool) ([]R
         }
     }
 
-    count := 0
-    for _, row := range t.Rows {
-        if condition == nil || condition(row) {
-            count++
-        }
+    rows, err := t.readAll()
+    if err != nil {
+        return nil, err
     }
+    results := make([]Row, 0)
 
-    results := make([]Row, 0, count)
-
-    for _, row := range t.Rows {
+    for _, row := range rows {
         if condition == nil || condition(row) {
             if isSelectAll {
                 results = append(results, row)
@@ -157,27 +177,48 @@ func (t *Tangki) SelectRows(columnNames []string, condition func(Row) bool) ([]R
 }
 
 
+// Fork mengembalikan versi baru t yang berbagi baris dengan t tanpa
+// menyalinnya. Setelah di-Fork, t tidak boleh diubah lagi: AddRow,
+// UpdateRows, dan DeleteRows pada versi baru tidak terlihat dari t.
+func (t *Tangki) Fork() *Tangki {
+	f := *t
+	if t.paged != nil {
+		f.paged = t.paged.fork()
+	}
+	f.keepColumnar()
+	return &f
+}
+
+// GetAllRows mengembalikan semua baris; tangki berhalaman dimuat dulu dan
+// menghasilkan nil bila halamannya gagal dibaca.
 func (t *Tangki) GetAllRows() []Row {
-	return t.Rows
+	rows, _ := t.readAll()
+	return rows
 }
 
 func (t *Tangki) Clone(newName string) *Tangki {
+	rows := t.GetAllRows()
 	newTangki := &Tangki{
 		Name:    newName,
 		Columns: make([]Column, len(t.Columns)),
-		Rows:    make([]Row, len(t.Rows)),
+		Rows:    make([]Row, len(rows)),
 	}
 	
 	copy(newTangki.Columns, t.Columns)
-	for i, row := range t.Rows {
+	for i, row := range rows {
 		newTangki.Rows[i] = row.Clone()
 	}
+	newTangki.SetColumnCache(t.columnCache)
 	
 	return newTangki
 }
 
 
 func (t *Tangki) validateAndConvert(colType string, value interface{}) (interface{}, error) {
+    // NULL (misalnya kolom kosong dari IMPOR) boleh di kolom apa pun
+    if value == nil {
+        return nil, nil
+    }
     switch colType {
     case "INT":
         switch v := value.(type) {
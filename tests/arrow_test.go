@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fbTable membaca tabel flatbuffer secukupnya untuk memeriksa keluaran
+// Arrow tanpa pustaka arrow.
+type fbTable struct {
+	buf []byte
+	pos int
+}
+
+func fbRoot(buf []byte) fbTable {
+	return fbTable{buf, int(binary.LittleEndian.Uint32(buf))}
+}
+
+func (t fbTable) field(id int) int {
+	vt := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if 4+2*id >= int(binary.LittleEndian.Uint16(t.buf[vt:])) {
+		return 0
+	}
+	off := int(binary.LittleEndian.Uint16(t.buf[vt+4+2*id:]))
+	if off == 0 {
+		return 0
+	}
+	return t.pos + off
+}
+
+func (t fbTable) u8(id int) int {
+	if p := t.field(id); p != 0 {
+		return int(t.buf[p])
+	}
+	return 0
+}
+
+func (t fbTable) i64(id int) int64 {
+	if p := t.field(id); p != 0 {
+		return int64(binary.LittleEndian.Uint64(t.buf[p:]))
+	}
+	return 0
+}
+
+func (t fbTable) deref(id int) int {
+	p := t.field(id)
+	return p + int(binary.LittleEndian.Uint32(t.buf[p:]))
+}
+
+func (t fbTable) table(id int) fbTable {
+	return fbTable{t.buf, t.deref(id)}
+}
+
+func (t fbTable) str(id int) string {
+	p := t.deref(id)
+	n := int(binary.LittleEndian.Uint32(t.buf[p:]))
+	return string(t.buf[p+4 : p+4+n])
+}
+
+// vector mengembalikan posisi elemen pertama dan jumlah elemen.
+func (t fbTable) vector(id int) (int, int) {
+	p := t.deref(id)
+	return p + 4, int(binary.LittleEndian.Uint32(t.buf[p:]))
+}
+
+type arrowMessage struct {
+	header    int
+	meta      fbTable
+	bodyBytes []byte
+}
+
+// readArrowStream membaca pesan-pesan stream Arrow IPC sampai penanda EOS.
+func readArrowStream(t *testing.T, data []byte) []arrowMessage {
+	var msgs []arrowMessage
+	for pos := 0; ; {
+		if binary.LittleEndian.Uint32(data[pos:]) != 0xffffffff {
+			t.Fatalf("Expected continuation marker at %d", pos)
+		}
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		pos += 8
+		if size == 0 {
+			return msgs
+		}
+		if size%8 != 0 {
+			t.Fatalf("Metadata size %d is not 8-byte aligned", size)
+		}
+		msg := fbRoot(data[pos : pos+size])
+		pos += size
+		bodyLen := int(msg.i64(3))
+		m := arrowMessage{header: msg.u8(1), meta: msg.table(2), bodyBytes: data[pos : pos+bodyLen]}
+		pos += bodyLen
+		msgs = append(msgs, m)
+	}
+}
+
+func TestArrowStreamExport(t *testing.T) {
+	db, _ := openBarang(t)
+	var buf bytes.Buffer
+	if err := db.ExportArrow(context.Background(), "barang", &buf); err != nil {
+		t.Fatalf("ExportArrow: %v", err)
+	}
+	msgs := readArrowStream(t, buf.Bytes())
+	if len(msgs) != 2 || msgs[0].header != 1 || msgs[1].header != 3 {
+		t.Fatalf("Expected schema and one record batch, got %d messages", len(msgs))
+	}
+
+	schema := msgs[0].meta
+	start, n := schema.vector(1)
+	names := []string{"id", "nama", "harga"}
+	types := []int{2, 5, 3} // Int, Utf8, FloatingPoint
+	if n != 3 {
+		t.Fatalf("Expected 3 fields, got %d", n)
+	}
+	for i := 0; i < n; i++ {
+		p := start + 4*i
+		f := fbTable{schema.buf, p + int(binary.LittleEndian.Uint32(schema.buf[p:]))}
+		if f.str(0) != names[i] || f.u8(2) != types[i] || f.u8(1) != 1 {
+			t.Fatalf("Field %d: got %s type %d", i, f.str(0), f.u8(2))
+		}
+	}
+
+	batch := msgs[1].meta
+	body := msgs[1].bodyBytes
+	if batch.i64(0) != 3000 {
+		t.Fatalf("Expected 3000 rows, got %d", batch.i64(0))
+	}
+	nodes, _ := batch.vector(1)
+	if nulls := binary.LittleEndian.Uint64(batch.buf[nodes+2*16+8:]); nulls != 300 {
+		t.Fatalf("Expected 300 NULL harga, got %d", nulls)
+	}
+	bufs, count := batch.vector(2)
+	if count != 7 {
+		t.Fatalf("Expected 7 buffers, got %d", count)
+	}
+	buffer := func(i int) []byte {
+		off := binary.LittleEndian.Uint64(batch.buf[bufs+16*i:])
+		size := binary.LittleEndian.Uint64(batch.buf[bufs+16*i+8:])
+		if off%8 != 0 {
+			t.Fatalf("Buffer %d is not 8-byte aligned", i)
+		}
+		return body[off : off+size]
+	}
+
+	ids := buffer(1)
+	if binary.LittleEndian.Uint64(ids[8*42:]) != 42 {
+		t.Fatalf("Unexpected id at row 42")
+	}
+	offsets, text := buffer(3), buffer(4)
+	from, to := binary.LittleEndian.Uint32(offsets[4*3:]), binary.LittleEndian.Uint32(offsets[4*4:])
+	if string(text[from:to]) != "barang3" {
+		t.Fatalf("Expected barang3 at row 3, got %q", text[from:to])
+	}
+	if math.Float64frombits(binary.LittleEndian.Uint64(buffer(6)[8*3:])) != 3.5 {
+		t.Fatalf("Unexpected harga at row 3")
+	}
+	if valid := buffer(5); valid[0]&1 != 0 || valid[0]&2 == 0 {
+		t.Fatalf("Expected harga NULL at row 0 and valid at row 1, bitmap %08b", valid[0])
+	}
+}
+
+func TestArrowFileExport(t *testing.T) {
+	db, dir := openBarang(t)
+	path := filepath.Join(dir, "barang.arrow")
+	if err := db.Jalankan("EKSPOR TANGKI barang KE '" + path + "'"); err != nil {
+		t.Fatalf("EKSPOR: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data[:8]) != "ARROW1\x00\x00" || string(data[len(data)-6:]) != "ARROW1" {
+		t.Fatalf("Missing Arrow file magic")
+	}
+	msgs := readArrowStream(t, data[8:])
+	if len(msgs) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(msgs))
+	}
+
+	size := int(binary.LittleEndian.Uint32(data[len(data)-10:]))
+	footer := fbRoot(data[len(data)-10-size : len(data)-10])
+	blocks, n := footer.vector(3)
+	if n != 1 {
+		t.Fatalf("Expected 1 record batch block, got %d", n)
+	}
+	offset := int(binary.LittleEndian.Uint64(footer.buf[blocks:]))
+	if binary.LittleEndian.Uint32(data[offset:]) != 0xffffffff {
+		t.Fatalf("Block offset %d does not point at a message", offset)
+	}
+	if footer.table(1).field(1) == 0 {
+		t.Fatal("Expected schema in footer")
+	}
+
+	// Hasil GRUPKAN tidak punya tipe; tipenya ditebak dari baris pertama
+	rows, err := db.QueryIter(context.Background(), "GRUPKAN TANGKI barang BERDASARKAN nama")
+	if err != nil {
+		t.Fatalf("QueryIter: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := rows.WriteArrow(&buf); err != nil {
+		t.Fatalf("WriteArrow: %v", err)
+	}
+	if msgs := readArrowStream(t, buf.Bytes()); len(msgs) != 2 {
+		t.Fatalf("Expected schema and one batch, got %d messages", len(msgs))
+	}
+}
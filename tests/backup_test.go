@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func TestBackupWhileWriting(t *testing.T) {
+	dir := t.TempDir()
+	db, err := engine.OpenTangkiWithOptions(filepath.Join(dir, "toko.bensin"), engine.Options{PageCache: 8})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	db.Jalankan("BUAT TANGKI penjualan (id INT, barang TEKS)")
+	for i := 0; i < 500; i++ {
+		db.Jalankan(fmt.Sprintf("ISI TANGKI penjualan NILAI (%d, 'awal')", i))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 500; i < 1500; i++ {
+			if err := db.Jalankan(fmt.Sprintf("ISI TANGKI penjualan NILAI (%d, 'baru')", i)); err != nil {
+				t.Errorf("Insert %d: %v", i, err)
+				return
+			}
+		}
+	}()
+
+	var buf bytes.Buffer
+	if err := db.Backup(context.Background(), &buf); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	wg.Wait()
+
+	path := filepath.Join(dir, "pulih.bensin")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Open backup: %v", err)
+	}
+	defer restored.Close()
+
+	// Backup adalah satu titik waktu: id-nya harus berurutan tanpa celah
+	rows, err := restored.Query("PILIH id DARI penjualan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) < 500 || len(rows) > 1500 {
+		t.Fatalf("Expected between 500 and 1500 rows, got %d", len(rows))
+	}
+	for i, row := range rows {
+		if row[0] != int64(i) {
+			t.Fatalf("Row %d has id %v; backup is not a consistent snapshot", i, row[0])
+		}
+	}
+	if _, err := os.Stat(path + ".pages"); !os.IsNotExist(err) {
+		t.Fatalf("Backup should not need a page file, got %v", err)
+	}
+}
+
+func TestBackupCancelled(t *testing.T) {
+	db, err := engine.OpenTangki(filepath.Join(t.TempDir(), "toko.bensin"))
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	db.Jalankan("BUAT TANGKI penjualan (id INT, barang TEKS)")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var buf bytes.Buffer
+	if err := db.Backup(ctx, &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRestoreToPointInTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "toko.bensin")
+	archive := filepath.Join(dir, "toko.arsip")
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{Durability: engine.DurabilityFsync, WALArchive: archive})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	db.Jalankan("BUAT TANGKI penjualan (id INT, barang TEKS)")
+	db.Jalankan("ISI TANGKI penjualan NILAI (1, 'awal')")
+
+	backup := filepath.Join(dir, "toko.backup")
+	var buf bytes.Buffer
+	if err := db.Backup(context.Background(), &buf); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	os.WriteFile(backup, buf.Bytes(), 0644)
+
+	// id 2 dipindah ke arsip oleh checkpoint, id 3 masih di WAL, id 4
+	// dicommit sesudah titik pemulihan
+	db.Jalankan("ISI TANGKI penjualan NILAI (2, 'arsip')")
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	db.Jalankan("ISI TANGKI penjualan NILAI (3, 'wal')")
+	time.Sleep(10 * time.Millisecond)
+	until := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	db.Jalankan("ISI TANGKI penjualan NILAI (4, 'nanti')")
+
+	for _, tc := range []struct {
+		until time.Time
+		want  int
+	}{{until, 3}, {time.Time{}, 4}} {
+		restored := filepath.Join(t.TempDir(), "pulih.bensin")
+		if err := engine.RestoreTo(restored, backup, tc.until, archive, path+".wal"); err != nil {
+			t.Fatalf("RestoreTo: %v", err)
+		}
+		other, err := engine.OpenTangki(restored)
+		if err != nil {
+			t.Fatalf("Open restored: %v", err)
+		}
+		rows, _ := other.Query("PILIH id DARI penjualan")
+		other.Close()
+		if len(rows) != tc.want || rows[len(rows)-1][0] != int64(tc.want) {
+			t.Fatalf("Restore to %v: expected ids 1..%d, got %v", tc.until, tc.want, rows)
+		}
+	}
+
+	// Tanpa arsip ada celah antara backup dan WAL
+	if err := engine.RestoreTo(filepath.Join(dir, "celah.bensin"), backup, time.Time{}, path+".wal"); err == nil {
+		t.Fatal("Expected RestoreTo to detect the missing archive")
+	}
+	if err := engine.RestoreTo(path, backup, time.Time{}); err == nil {
+		t.Fatal("Expected RestoreTo to refuse overwriting a database")
+	}
+}
+
+func TestBackupCancelledMidway(t *testing.T) {
+	db, err := engine.OpenTangki(filepath.Join(t.TempDir(), "toko.bensin"))
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	teks := strings.Repeat("x", 8000)
+	for i := 0; i < 20; i++ {
+		db.Jalankan(fmt.Sprintf("BUAT TANGKI t%d (id INT, isian TEKS)", i))
+		db.Jalankan(fmt.Sprintf("ISI TANGKI t%d NILAI (1, '%s')", i, teks))
+	}
+
+	// ctx dibatalkan saat tulisan pertama sampai di writer; tangki
+	// berikutnya tidak ditulis lagi
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &cancelWriter{cancel: cancel}
+	if err := db.Backup(ctx, w); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if w.written > 4*8000 {
+		t.Fatalf("Expected Backup to stop early, wrote %d bytes", w.written)
+	}
+}
+
+type cancelWriter struct {
+	cancel  context.CancelFunc
+	written int
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	w.written += len(p)
+	return len(p), nil
+}
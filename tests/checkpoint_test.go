@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func countRowsInFile(t *testing.T, path, name string) int {
+	db, err := openFileCopy(t, path)
+	if err != nil {
+		return -1
+	}
+	defer db.Close()
+	results, err := db.Query("PILIH * DARI " + name)
+	if err != nil {
+		return -1
+	}
+	return len(results)
+}
+
+func TestManualCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp.bensin")
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	db.Jalankan("BUAT TANGKI log (id INT, pesan TEKS)")
+	db.Jalankan("ISI TANGKI log NILAI (1, 'mulai')")
+	if status := db.CheckpointStatus(); status.PendingWrites != 2 || status.Checkpoints != 0 {
+		t.Fatalf("Unexpected status before checkpoint %+v", status)
+	}
+
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if n := countRowsInFile(t, path, "log"); n != 1 {
+		t.Fatalf("Expected 1 row on disk after checkpoint, got %d", n)
+	}
+	status := db.CheckpointStatus()
+	if status.PendingWrites != 0 || status.Checkpoints != 1 || status.LastCheckpoint.IsZero() || status.Running {
+		t.Fatalf("Unexpected status after checkpoint %+v", status)
+	}
+
+	db.Jalankan("ISI TANGKI log NILAI (2, 'lanjut')")
+	if status := db.CheckpointStatus(); status.PendingWrites != 1 {
+		t.Fatalf("Expected 1 pending write, got %+v", status)
+	}
+}
+
+func TestBackgroundCheckpointAfterWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp.bensin")
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{AutosaveWrites: 5})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	db.Jalankan("BUAT TANGKI log (id INT, pesan TEKS)")
+	for i := 1; i <= 4; i++ {
+		db.Jalankan(fmt.Sprintf("ISI TANGKI log NILAI (%d, 'baris')", i))
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for countRowsInFile(t, path, "log") != 4 {
+		if time.Now().After(deadline) {
+			t.Fatal("Background checkpoint did not run after 5 writes")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status := db.CheckpointStatus(); !status.Running || status.Checkpoints < 1 {
+		t.Fatalf("Unexpected status %+v", status)
+	}
+
+	db.Close()
+	if status := db.CheckpointStatus(); status.Running {
+		t.Fatal("Expected checkpointer to stop on Close")
+	}
+}
+
+func TestCheckpointWithConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp.bensin")
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{AutosaveInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI log (id INT, pesan TEKS)")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			db.Jalankan(fmt.Sprintf("ISI TANGKI log NILAI (%d, 'baris')", i))
+			db.Jalankan(fmt.Sprintf("ATUR TANGKI log SET pesan = 'ubah' DIMANA id = %d", i))
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if err := db.Checkpoint(); err != nil {
+			t.Errorf("Checkpoint failed: %v", err)
+		}
+	}
+	wg.Wait()
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if n := countRowsInFile(t, path, "log"); n != 200 {
+		t.Fatalf("Expected final state on disk, got %d rows", n)
+	}
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+func setupJual(t testing.TB, columnar bool, n int) *engine.Engine {
+	db, err := engine.OpenTangkiWithOptions(engine.MemoryPath, engine.Options{ColumnCache: columnar})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	produk := []string{"Laptop", "Mouse", "Keyboard", "Monitor", "10"}
+	db.Jalankan("BUAT TANGKI jual (id INT, produk TEKS, qty INT, harga FLOAT, gudang INT)")
+	db.Jalankan("BUAT TANGKI gudang (id INT, kota TEKS)")
+	db.Jalankan("ISI TANGKI gudang NILAI (1, 'Jakarta')")
+	db.Jalankan("ISI TANGKI gudang NILAI (2, 'Bandung')")
+	for i := 0; i < n; i++ {
+		db.Jalankan(fmt.Sprintf("ISI TANGKI jual NILAI (%d, '%s', %d, %d.5, %d)", i, produk[i%len(produk)], i%7, i%13*100, i%4))
+	}
+	// GABUNG KIRI menghasilkan NULL untuk gudang 0 dan 3
+	db.Jalankan("GABUNG KIRI jual DAN gudang MENJADI lokasi DIMANA jual.gudang = gudang.id")
+	return db
+}
+
+func TestColumnarMatchesRowLayout(t *testing.T) {
+	rowDB := setupJual(t, false, 200)
+	defer rowDB.Close()
+	colDB := setupJual(t, true, 200)
+	defer colDB.Close()
+
+	jual, _ := colDB.GetTangki("jual")
+	cols := jual.Columnar()
+	if !jual.ColumnCache() || cols == nil {
+		t.Fatal("Expected jual to have a column cache")
+	}
+	if v := cols.Vectors[1]; v.Type != "TEKS" || len(v.Dict) != 5 || len(v.Codes) != 200 {
+		t.Fatalf("Expected dictionary-encoded produk, got %+v", v)
+	}
+
+	queries := []string{
+		"GRUPKAN TANGKI jual BERDASARKAN produk SUM(harga)",
+		"GRUPKAN TANGKI jual BERDASARKAN produk AVG(qty)",
+		"GRUPKAN TANGKI jual BERDASARKAN qty MAX(harga)",
+		"GRUPKAN TANGKI jual BERDASARKAN harga MIN(produk)",
+		"GRUPKAN TANGKI jual BERDASARKAN produk COUNT(id)",
+		"GRUPKAN TANGKI lokasi BERDASARKAN kota SUM(qty)",
+		"GRUPKAN TANGKI lokasi BERDASARKAN qty MIN(kota)",
+		"URUTKAN TANGKI jual BERDASARKAN harga MENURUN",
+		"URUTKAN TANGKI jual BERDASARKAN produk MENAIK",
+		"URUTKAN TANGKI lokasi BERDASARKAN kota MENAIK",
+		"PILIH id DARI jual DIMANA qty > 3 DAN produk = 'Mouse'",
+		"PILIH id DARI jual DIMANA harga <= 300.5 ATAU produk != 'Laptop'",
+		"PILIH id DARI jual DIMANA 5 < qty",
+		"PILIH id DARI jual DIMANA produk = 10",
+		"PILIH produk, kota DARI lokasi DIMANA kota = 'Bandung' ATAU qty = 0",
+	}
+	// Urutan grup GRUPKAN dan urutan baris yang setara pada URUTKAN tidak
+	// ditentukan, jadi hasilnya dibandingkan sebagai kumpulan baris dan
+	// urutan URUTKAN diperiksa lewat nilai kuncinya
+	orderKey := map[string]string{
+		"URUTKAN TANGKI jual BERDASARKAN harga MENURUN": "harga",
+		"URUTKAN TANGKI jual BERDASARKAN produk MENAIK": "produk",
+		"URUTKAN TANGKI lokasi BERDASARKAN kota MENAIK": "kota",
+	}
+	check := func() {
+		for _, q := range queries {
+			want, err := rowDB.Query(q)
+			if err != nil {
+				t.Fatalf("%s: %v", q, err)
+			}
+			got, err := colDB.Query(q)
+			if err != nil {
+				t.Fatalf("%s (columnar): %v", q, err)
+			}
+			if strings.HasPrefix(q, "PILIH") {
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Fatalf("%s: columnar result differs\n got: %v\nwant: %v", q, got, want)
+				}
+				continue
+			}
+			if fmt.Sprint(sortedRows(got)) != fmt.Sprint(sortedRows(want)) {
+				t.Fatalf("%s: columnar result differs\n got: %v\nwant: %v", q, got, want)
+			}
+			if col, ok := orderKey[q]; ok {
+				tk, _ := colDB.GetTangki(strings.Fields(q)[2])
+				idx := tk.GetColumnIndex(col)
+				for i := range got {
+					if numeric(got[i][idx]) != numeric(want[i][idx]) {
+						t.Fatalf("%s: columnar order differs at row %d\n got: %v\nwant: %v", q, i, got, want)
+					}
+				}
+			}
+		}
+	}
+	check()
+
+	// Setelah perubahan vektor dibangun ulang; ATUR dengan ekspresi
+	// menaruh FLOAT di kolom INT sehingga kolom itu kembali ke jalur baris
+	for _, db := range []*engine.Engine{rowDB, colDB} {
+		db.Jalankan("ISI TANGKI jual NILAI (999, 'Mouse', 6, 1.5, 1)")
+		db.Jalankan("ATUR TANGKI jual SET qty = qty * 2 DIMANA produk = 'Mouse'")
+		db.Jalankan("BAKAR TANGKI jual DIMANA id < 10")
+	}
+	check()
+}
+
+// sortedRows mengurutkan salinan rows menurut teksnya, untuk hasil yang
+// urutannya tidak ditentukan.
+func sortedRows(rows []tangki.Row) []string {
+	out := make([]string, len(rows))
+	for i, row := range rows {
+		out[i] = fmt.Sprint(row)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// numeric mengubah v menjadi angka seperti URUTKAN membandingkannya.
+func numeric(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	case int:
+		return float64(n)
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// BenchmarkGroupByLayout membandingkan GRUPKAN pada layout baris dan kolom.
+func BenchmarkGroupByLayout(b *testing.B) {
+	for _, columnar := range []bool{false, true} {
+		name := "row"
+		if columnar {
+			name = "columnar"
+		}
+		b.Run(name, func(b *testing.B) {
+			db := setupJual(b, columnar, 20000)
+			defer db.Close()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := db.Query("GRUPKAN TANGKI jual BERDASARKAN produk SUM(harga)"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestColumnCacheAppendsInsertedRows(t *testing.T) {
+	users := tangki.NewTangki("users", []tangki.Column{{Name: "id", Type: "INT"}, {Name: "nama", Type: "TEKS"}})
+	users.SetColumnCache(true)
+	users.AddRow(1, "Andi")
+	users.AddRow(2, nil)
+	users.AddRow(3, "Andi")
+	before := users.Columnar()
+
+	next := users.Fork()
+	next.AddRow(4, "Budi")
+	after := next.Columnar()
+	names := after.Vectors[1]
+	if after.Rows != 4 || len(names.Dict) != 2 || names.Value(2) != "Andi" || names.Value(3) != "Budi" || !names.IsNull(1) {
+		t.Fatalf("Unexpected cache after ISI %+v %+v", after, names)
+	}
+	if before.Rows != 3 || before.Vectors[0].Len() != 3 || len(before.Vectors[1].Dict) != 1 {
+		t.Fatalf("Old version changed: %+v", before)
+	}
+
+	// Versi berikutnya menambah ke vektor versi sebelumnya, bukan
+	// membangun ulang
+	last := next.Fork()
+	last.AddRow(5, "Citra")
+	if cols := last.Columnar(); cols.Rows != 5 || &cols.Vectors[0].Ints[0] != &after.Vectors[0].Ints[0] {
+		t.Fatalf("Expected inserted rows to be appended to the existing vectors, got %+v", cols)
+	}
+
+	// Versi lain dari dasar yang sama membangun cache sendiri
+	other := next.Fork()
+	other.AddRow(6, "Dedi")
+	if cols := other.Columnar(); cols.Vectors[0].Value(4) != int64(6) || cols.Vectors[1].Value(4) != "Dedi" {
+		t.Fatalf("Unexpected sibling version %+v", cols)
+	}
+	if cols := last.Columnar(); cols.Vectors[0].Value(4) != int64(5) || cols.Vectors[1].Value(4) != "Citra" {
+		t.Fatal("Sibling version overwrote the appended rows")
+	}
+
+	if err := last.DeleteRows(func(row tangki.Row) bool { return row[0] == 1 }); err != nil {
+		t.Fatal(err)
+	}
+	if cols := last.Columnar(); cols.Rows != 4 || cols.Vectors[0].Value(0) != int64(2) {
+		t.Fatalf("Expected the cache to be rebuilt after BAKAR, got %+v", cols)
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func setupCrossJoin(t *testing.T, opts engine.Options) *engine.Engine {
+	db, err := engine.OpenTangkiWithOptions("", opts)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	db.Jalankan("BUAT TANGKI alfa (id INT)")
+	db.Jalankan("BUAT TANGKI beta (id INT)")
+	for i := 0; i < 3000; i++ {
+		db.Jalankan(fmt.Sprintf("ISI TANGKI alfa NILAI (%d)", i))
+		db.Jalankan(fmt.Sprintf("ISI TANGKI beta NILAI (%d)", i))
+	}
+	return db
+}
+
+func TestQueryContextCancelsCrossJoin(t *testing.T) {
+	db := setupCrossJoin(t, engine.Options{})
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := db.QueryContext(ctx, "PILIH * DARI alfa GABUNG SILANG beta")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Cancellation took too long: %v", elapsed)
+	}
+
+	// Lock sudah dilepas sehingga penulis berikutnya tidak menunggu
+	if err := db.Jalankan("ISI TANGKI alfa NILAI (5000)"); err != nil {
+		t.Fatalf("Insert after cancelled query failed: %v", err)
+	}
+}
+
+func TestDefaultQueryTimeout(t *testing.T) {
+	db := setupCrossJoin(t, engine.Options{Timeouts: engine.Timeouts{Query: 20 * time.Millisecond}})
+	defer db.Close()
+
+	if _, err := db.Query("PILIH * DARI alfa GABUNG SILANG beta DIMANA alfa.id = beta.id + 1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected default timeout to stop the query, got %v", err)
+	}
+	results, err := db.Query("PILIH id DARI alfa DIMANA id < 3")
+	if err != nil || len(results) != 3 {
+		t.Fatalf("Expected quick query to finish within the timeout, got %v (%v)", results, err)
+	}
+}
+
+func TestJalankanContextCancelled(t *testing.T) {
+	db := setupCrossJoin(t, engine.Options{})
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := db.JalankanContext(ctx, "BAKAR TANGKI alfa DIMANA id > 10"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	results, _ := db.Query("PILIH id DARI alfa")
+	if len(results) != 3000 {
+		t.Fatalf("Expected cancelled delete to change nothing, got %d rows", len(results))
+	}
+}
+
+func TestQueryContextCancelsOrderAndGroup(t *testing.T) {
+	for _, opts := range []engine.Options{{}, {ColumnCache: true}} {
+		db := setupCrossJoin(t, opts)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for _, fql := range []string{
+			"URUTKAN TANGKI alfa BERDASARKAN id MENURUN",
+			"GRUPKAN TANGKI alfa BERDASARKAN id COUNT(id)",
+		} {
+			if _, err := db.QueryContext(ctx, fql); !errors.Is(err, context.Canceled) {
+				t.Errorf("%s (column cache %v): expected context.Canceled, got %v", fql, opts.ColumnCache, err)
+			}
+			results, err := db.Query(fql)
+			if err != nil || len(results) != 3000 {
+				t.Errorf("%s (column cache %v): expected 3000 rows without cancellation, got %d (%v)", fql, opts.ColumnCache, len(results), err)
+			}
+		}
+		db.Close()
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func TestImportExportCSVFQL(t *testing.T) {
+	dir := t.TempDir()
+	db, err := engine.OpenTangki(filepath.Join(dir, "gudang.bensin"))
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	src := filepath.Join(dir, "barang.csv")
+	data := "id,nama,harga\n1,Obeng,15000.5\n2,\"Palu, besar\",40000\n3,Tang,\n"
+	if err := os.WriteFile(src, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Jalankan("IMPOR TANGKI barang DARI '" + src + "' DENGAN HEADER"); err != nil {
+		t.Fatalf("IMPOR: %v", err)
+	}
+
+	barang, ok := db.GetTangki("barang")
+	if !ok {
+		t.Fatal("Expected IMPOR to create barang")
+	}
+	types := []string{"INT", "TEKS", "FLOAT"}
+	for i, col := range barang.Columns {
+		if col.Type != types[i] {
+			t.Fatalf("Column %s: expected type %s, got %s", col.Name, types[i], col.Type)
+		}
+	}
+	rows, err := db.Query("PILIH nama DARI barang DIMANA harga > 20000")
+	if err != nil || len(rows) != 1 || rows[0][0] != "Palu, besar" {
+		t.Fatalf("Expected quoted value, got %v (%v)", rows, err)
+	}
+	rows, _ = db.Query("PILIH * DARI barang DIMANA id = 3")
+	if len(rows) != 1 || rows[0][2] != nil {
+		t.Fatalf("Expected empty FLOAT to be NULL, got %v", rows)
+	}
+
+	// Tanpa header, baris ditambahkan ke tangki yang sudah ada menurut urutan
+	more := filepath.Join(dir, "tambahan.csv")
+	os.WriteFile(more, []byte("4,Gergaji,55000\n"), 0644)
+	if err := db.Jalankan("IMPOR TANGKI barang DARI '" + more + "'"); err != nil {
+		t.Fatalf("IMPOR into existing: %v", err)
+	}
+
+	out := filepath.Join(dir, "ekspor.csv")
+	if err := db.Jalankan("EKSPOR TANGKI barang KE '" + out + "' DENGAN HEADER"); err != nil {
+		t.Fatalf("EKSPOR: %v", err)
+	}
+	got, _ := os.ReadFile(out)
+	want := "id,nama,harga\n1,Obeng,15000.5\n2,\"Palu, besar\",40000\n3,Tang,\n4,Gergaji,55000\n"
+	if string(got) != want {
+		t.Fatalf("Unexpected export:\n%s", got)
+	}
+}
+
+func TestImportCSVRowErrors(t *testing.T) {
+	db, err := engine.OpenTangki(filepath.Join(t.TempDir(), "gudang.bensin"))
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	db.Jalankan("BUAT TANGKI stok (jumlah INT, lokasi TEKS)")
+	db.Jalankan("ISI TANGKI stok NILAI (1, 'A')")
+
+	data := "lokasi;jumlah\nB;2\nC;banyak\nD\nE;5\n"
+	opts := engine.CSVOptions{Delimiter: ';', Header: true}
+
+	// Bawaan: baris salah pertama membatalkan impor
+	_, err = db.ImportCSV(context.Background(), "stok", strings.NewReader(data), opts)
+	var rowErr *engine.RowError
+	if !errors.As(err, &rowErr) || rowErr.Line != 3 {
+		t.Fatalf("Expected error on line 3, got %v", err)
+	}
+	if rows, _ := db.Query("PILIH * DARI stok"); len(rows) != 1 {
+		t.Fatalf("Failed import should not change stok, got %d rows", len(rows))
+	}
+
+	opts.MaxErrors = -1
+	res, err := db.ImportCSV(context.Background(), "stok", strings.NewReader(data), opts)
+	if err != nil {
+		t.Fatalf("ImportCSV: %v", err)
+	}
+	if res.Rows != 2 || len(res.Errors) != 2 || res.Errors[0].Line != 3 || res.Errors[1].Line != 4 {
+		t.Fatalf("Expected 2 rows and errors on lines 3 and 4, got %+v", res)
+	}
+	rows, err := db.Query("PILIH lokasi DARI stok DIMANA jumlah >= 2")
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("Expected 2 imported rows, got %v (%v)", rows, err)
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportCSV(context.Background(), "stok", &buf, engine.CSVOptions{Delimiter: '\t'}); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	if buf.String() != "1\tA\n2\tB\n5\tE\n" {
+		t.Fatalf("Unexpected export: %q", buf.String())
+	}
+}
+
+func TestCSVNullMarkerRoundTrip(t *testing.T) {
+	db, err := engine.OpenTangki(filepath.Join(t.TempDir(), "gudang.bensin"))
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	opts := engine.CSVOptions{Header: true, Null: `\N`}
+	data := "id,catatan,harga\n1,,10\n2,\\N,\\N\n3,Rusak,2.5\n"
+	if _, err := db.ImportCSV(context.Background(), "retur", strings.NewReader(data), opts); err != nil {
+		t.Fatalf("ImportCSV: %v", err)
+	}
+	retur, _ := db.GetTangki("retur")
+	if retur.Columns[1].Type != "TEKS" || retur.Columns[2].Type != "FLOAT" {
+		t.Fatalf("Unexpected column types %v", retur.Columns)
+	}
+	if retur.Rows[0][1] != "" || retur.Rows[1][1] != nil || retur.Rows[1][2] != nil {
+		t.Fatalf("Expected empty text and NULLs to stay distinct, got %v", retur.Rows)
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportCSV(context.Background(), "retur", &buf, opts); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	if buf.String() != data {
+		t.Fatalf("Unexpected export:\n%s", buf.String())
+	}
+
+	if _, err := db.ImportCSV(context.Background(), "salinan", &buf, opts); err != nil {
+		t.Fatalf("Reimport: %v", err)
+	}
+	salinan, _ := db.GetTangki("salinan")
+	for i, row := range salinan.Rows {
+		for j, val := range row {
+			if val != retur.Rows[i][j] {
+				t.Fatalf("Row %d column %d: expected %#v, got %#v", i, j, retur.Rows[i][j], val)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func setupOrgChart(t *testing.T) *engine.Engine {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	db.Jalankan("BUAT TANGKI pegawai (id INT, nama TEKS, atasan_id INT, gaji INT)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (1, 'Direktur', 0, 900)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (2, 'Manajer IT', 1, 700)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (3, 'Manajer HR', 1, 650)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (4, 'Programmer', 2, 500)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (5, 'Magang', 4, 100)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (6, 'Rekruter', 3, 400)")
+	return db
+}
+
+func TestCTE(t *testing.T) {
+	db := setupOrgChart(t)
+	defer db.Close()
+
+	results, err := db.Query("DENGAN senior SEBAGAI (PILIH id, nama, gaji DARI pegawai DIMANA gaji >= 500), mahal (kode, orang) SEBAGAI (PILIH id, nama DARI senior DIMANA gaji > 650) PILIH orang DARI mahal")
+	if err != nil {
+		t.Fatalf("CTE query failed: %v", err)
+	}
+	if len(results) != 2 || results[0][0] != "Direktur" || results[1][0] != "Manajer IT" {
+		t.Fatalf("Expected [Direktur, Manajer IT], got %v", results)
+	}
+
+	// CTE terlihat dari subquery di query utama
+	results, err = db.Query("DENGAN atasan SEBAGAI (PILIH atasan_id DARI pegawai) PILIH nama DARI pegawai DIMANA id TIDAK DI DALAM (PILIH atasan_id DARI atasan DIMANA atasan_id != 0)")
+	if err != nil {
+		t.Fatalf("CTE subquery failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 leaf employees, got %v", results)
+	}
+
+	if names := db.ListTangki(); len(names) != 1 {
+		t.Fatalf("CTE tidak boleh membuat tangki, got %v", names)
+	}
+}
+
+func TestRecursiveCTEOrgChart(t *testing.T) {
+	db := setupOrgChart(t)
+	defer db.Close()
+
+	results, err := db.Query("DENGAN REKURSIF bawahan (id, nama, level) SEBAGAI (PILIH id, nama, 0 DARI pegawai DIMANA id = 2 SATUKAN SEMUA PILIH p.id, p.nama, b.level + 1 DARI pegawai p GABUNG bawahan b PADA p.atasan_id = b.id) PILIH nama, level DARI bawahan")
+	if err != nil {
+		t.Fatalf("Recursive CTE failed: %v", err)
+	}
+
+	expected := []struct {
+		nama  string
+		level int64
+	}{{"Manajer IT", 0}, {"Programmer", 1}, {"Magang", 2}}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d rows, got %v", len(expected), results)
+	}
+	for i, want := range expected {
+		if results[i][0] != want.nama || toInt64(results[i][1]) != want.level {
+			t.Errorf("Row %d: expected %v, got %v", i, want, results[i])
+		}
+	}
+
+	// Siklus berhenti karena SATUKAN membuang baris yang sudah ada
+	db.Jalankan("ATUR TANGKI pegawai SET atasan_id = 5 DIMANA id = 2")
+	results, err = db.Query("DENGAN REKURSIF rantai SEBAGAI (PILIH id DARI pegawai DIMANA id = 2 SATUKAN PILIH p.id DARI pegawai p GABUNG rantai r PADA p.atasan_id = r.id) PILIH * DARI rantai")
+	if err != nil {
+		t.Fatalf("Cyclic recursive CTE failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 rows in cycle, got %v", results)
+	}
+
+	if _, err := db.Query("DENGAN REKURSIF x SEBAGAI (PILIH id DARI x) PILIH * DARI x"); err == nil {
+		t.Fatal("Expected error for self-referencing anchor")
+	}
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int64:
+		return n
+	}
+	return -1
+}
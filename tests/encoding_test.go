@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func TestColumnEncodingsRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compress=%v", compress), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "enc.bensin")
+			db, err := engine.OpenTangkiWithOptions(path, engine.Options{Compress: compress})
+			if err != nil {
+				t.Fatalf("Failed to open engine: %v", err)
+			}
+			db.Jalankan("BUAT TANGKI pegawai (id INT, divisi TEKS, skor FLOAT, level INT, nama TEKS)")
+			db.Jalankan("BUAT TANGKI divisi (kode TEKS, lantai INT)")
+			db.Jalankan("ISI TANGKI divisi NILAI ('IT', 3)")
+			for i := 0; i < 500; i++ {
+				div := "IT"
+				if i >= 300 {
+					div = "HR"
+				}
+				if i%50 == 0 {
+					div = "Keuangan"
+				}
+				db.Jalankan(fmt.Sprintf("ISI TANGKI pegawai NILAI (%d, '%s', %d.25, %d, 'Pegawai %d')", i*3, div, i%9, i/100, i))
+			}
+			// Level negatif dan NULL dari GABUNG KIRI
+			db.Jalankan("ATUR TANGKI pegawai SET level = level - 3 DIMANA id < 30")
+			db.Jalankan("GABUNG KIRI pegawai DAN divisi MENJADI lantai DIMANA pegawai.divisi = divisi.kode")
+
+			queries := []string{"PILIH * DARI pegawai", "PILIH * DARI lantai", "PILIH * DARI divisi"}
+			want := make([]string, len(queries))
+			for i, q := range queries {
+				rows, err := db.Query(q)
+				if err != nil {
+					t.Fatalf("%s: %v", q, err)
+				}
+				want[i] = fmt.Sprint(rows)
+			}
+			if err := db.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			db, err = engine.OpenTangki(path)
+			if err != nil {
+				t.Fatalf("Reopen failed: %v", err)
+			}
+			defer db.Close()
+			for i, q := range queries {
+				rows, err := db.Query(q)
+				if err != nil {
+					t.Fatalf("%s after reload: %v", q, err)
+				}
+				if got := fmt.Sprint(rows); got != want[i] {
+					t.Fatalf("%s changed after reload\n got: %.300s\nwant: %.300s", q, got, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLowCardinalityTextIsCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "divisi.bensin")
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI pegawai (id INT, divisi TEKS)")
+	for i := 0; i < 10000; i++ {
+		div := []string{"IT", "HR"}[i%2]
+		db.Jalankan(fmt.Sprintf("ISI TANGKI pegawai NILAI (%d, '%s')", i, div))
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Format lama: 1 byte bitmap + 8 byte INT + 4 byte teks per baris
+	if info.Size() > 40000 {
+		t.Fatalf("Expected compact file, got %d bytes", info.Size())
+	}
+}
+
+func TestLoadsRowOrientedFormat(t *testing.T) {
+	// File minor 3: baris demi baris dengan bitmap NULL
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	str := func(s string) {
+		binary.Write(&buf, le, uint16(len(s)))
+		buf.WriteString(s)
+	}
+	binary.Write(&buf, le, uint16(1))
+	binary.Write(&buf, le, uint16(3))
+	binary.Write(&buf, le, uint16(1))
+	str("produk")
+	binary.Write(&buf, le, uint16(2))
+	str("id")
+	buf.WriteByte(engine.TypeInt)
+	str("nama")
+	buf.WriteByte(engine.TypeTeks)
+	binary.Write(&buf, le, uint32(2))
+	buf.WriteByte(0)
+	binary.Write(&buf, le, int64(1))
+	str("Laptop")
+	buf.WriteByte(2) // nama NULL
+	binary.Write(&buf, le, int64(2))
+	binary.Write(&buf, le, uint16(0)) // pandangan
+	binary.Write(&buf, le, uint16(0)) // statistik
+
+	path := filepath.Join(t.TempDir(), "lama.bensin")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open old file: %v", err)
+	}
+	defer db.Close()
+	rows, err := db.Query("PILIH * DARI produk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(rows); got != "[[1 Laptop] [2 <nil>]]" {
+		t.Fatalf("Unexpected rows from old format: %s", got)
+	}
+}
@@ -261,6 +261,11 @@ func TestFQLParser(t *testing.T) {
 			input:   "PILIH * DARI tangki_a GABUNG tangki_b PADA tangki_a.id = tangki_b.id",
 			wantErr: false,
 		},
+		{
+			name:    "Test Isi Data Terpotong",
+			input:   "ISI TANGKI pengguna NILAI (1, ",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+func explainLines(t *testing.T, rows []tangki.Row) []string {
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = fmt.Sprint(row[0])
+	}
+	t.Logf("\n%s", strings.Join(lines, "\n"))
+	return lines
+}
+
+func TestExplainPushesPredicatesDown(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	rows, err := db.Query("JELASKAN PILIH p.nama, d.lokasi DARI pegawai p GABUNG divisi d PADA p.divisi_id = d.id DIMANA d.lokasi = 'Jakarta' DAN p.gaji > 5000000")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	lines := explainLines(t, rows)
+
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 plan lines, got %d", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "Project p.nama, d.lokasi") || !strings.Contains(lines[0], "aktual=1") {
+		t.Errorf("Unexpected project line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "Join INNER PADA p.divisi_id = d.id") {
+		t.Errorf("Expected join directly below project, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "SeqScan pegawai p DIMANA p.gaji > 5000000") || !strings.Contains(lines[2], "aktual=3") {
+		t.Errorf("Expected gaji filter pushed into pegawai scan, got %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "SeqScan divisi d DIMANA d.lokasi = 'Jakarta'") || !strings.Contains(lines[3], "perkiraan=0 aktual=1") {
+		t.Errorf("Expected lokasi filter pushed into divisi scan, got %q", lines[3])
+	}
+}
+
+func TestPlannerKeepsOuterJoinSemantics(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	// Predikat pada sisi kanan LEFT join tidak boleh didorong ke scan divisi
+	results, err := db.Query("PILIH p.nama DARI pegawai p GABUNG KIRI divisi d PADA p.divisi_id = d.id DIMANA d.nama = 'IT' ATAU p.gaji > 5400000")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 rows, got %v", results)
+	}
+
+	results, err = db.Query("PILIH p.nama DARI pegawai p GABUNG KIRI divisi d PADA p.divisi_id = d.id DIMANA d.nama = 'IT'")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 rows, got %v", results)
+	}
+}
+
+func TestPlannerReordersJoins(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	db.Jalankan("BUAT TANGKI kota (nama TEKS, pulau TEKS)")
+	db.Jalankan("ISI TANGKI kota NILAI ('Jakarta', 'Jawa')")
+
+	query := "PILIH * DARI pegawai p GABUNG divisi d PADA p.divisi_id = d.id GABUNG kota k PADA d.lokasi = k.nama"
+	results, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 rows, got %v", results)
+	}
+	// Urutan kolom PILIH * tetap mengikuti DARI dan GABUNG
+	if len(results[0]) != 9 || results[0][1] != "Andi" || results[0][8] != "Jawa" {
+		t.Fatalf("Unexpected column order %v", results[0])
+	}
+
+	rows, err := db.Query("JELASKAN " + query)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	lines := explainLines(t, rows)
+	if !strings.HasPrefix(lines[1], "  Reorder") || !strings.Contains(lines[len(lines)-1], "SeqScan pegawai") {
+		t.Fatalf("Expected join order starting from the smallest tangki, got %v", lines)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func setupAngka(t testing.TB, n int) *engine.Engine {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	db.Jalankan("BUAT TANGKI angka (id INT, genap INT)")
+	for i := 1; i <= n; i++ {
+		db.Jalankan(fmt.Sprintf("ISI TANGKI angka NILAI (%d, %d)", i, 1-i%2))
+	}
+	return db
+}
+
+func TestLimit(t *testing.T) {
+	db := setupAngka(t, 100)
+	defer db.Close()
+
+	results, err := db.Query("PILIH id DARI angka DIMANA genap = 1 BATAS 3")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 3 || toInt64(results[0][0]) != 2 || toInt64(results[2][0]) != 6 {
+		t.Fatalf("Expected first 3 even ids, got %v", results)
+	}
+
+	if _, err := db.Query("PILIH id DARI angka BATAS -1"); err == nil {
+		t.Error("Expected error for negative BATAS")
+	}
+
+	// BATAS menghentikan scan setelah cukup baris
+	rows, err := db.Query("JELASKAN PILIH id DARI angka DIMANA genap = 1 BATAS 5")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	lines := explainLines(t, rows)
+	if !strings.HasPrefix(lines[0], "Batas 5") || !strings.Contains(lines[len(lines)-1], "aktual=5") {
+		t.Fatalf("Expected scan to stop after 5 rows, got %v", lines)
+	}
+}
+
+func TestQueryIterStreams(t *testing.T) {
+	db := setupAngka(t, 1000)
+	defer db.Close()
+
+	rows, err := db.QueryIter(context.Background(), "PILIH id, genap DARI angka DIMANA id > 10")
+	if err != nil {
+		t.Fatalf("QueryIter failed: %v", err)
+	}
+	if cols := rows.Columns(); len(cols) != 2 || cols[0] != "id" {
+		t.Errorf("Unexpected columns %v", cols)
+	}
+	for i := 0; i < 3; i++ {
+		row, ok := rows.Next()
+		if !ok || toInt64(row[0]) != int64(11+i) {
+			t.Fatalf("Unexpected row %v", row)
+		}
+	}
+	rows.Close()
+	if _, ok := rows.Next(); ok {
+		t.Error("Expected no rows after Close")
+	}
+
+	// Lock sudah dilepas sehingga penulisan tidak macet
+	if err := db.Jalankan("ISI TANGKI angka NILAI (1001, 0)"); err != nil {
+		t.Fatalf("Insert after Close failed: %v", err)
+	}
+
+	rows, err = db.QueryIter(context.Background(), "PILIH * DARI angka")
+	if err != nil {
+		t.Fatalf("QueryIter failed: %v", err)
+	}
+	count := 0
+	for {
+		if _, ok := rows.Next(); !ok {
+			break
+		}
+		count++
+	}
+	if rows.Err() != nil || count != 1001 {
+		t.Fatalf("Expected 1001 rows, got %d (%v)", count, rows.Err())
+	}
+}
+
+func TestQueryIterCancel(t *testing.T) {
+	db := setupAngka(t, 100)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, err := db.QueryIter(ctx, "PILIH id DARI angka")
+	if err != nil {
+		t.Fatalf("QueryIter failed: %v", err)
+	}
+	defer rows.Close()
+
+	if _, ok := rows.Next(); !ok {
+		t.Fatal("Expected a first row")
+	}
+	cancel()
+	if _, ok := rows.Next(); ok {
+		t.Fatal("Expected iteration to stop after cancel")
+	}
+	if !errors.Is(rows.Err(), context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", rows.Err())
+	}
+}
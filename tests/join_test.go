@@ -0,0 +1,279 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+	"github.com/Dziqha/BensinDB/pkg/query"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+func buildJoinTangkis(n int, sorted bool) (*tangki.Tangki, *tangki.Tangki) {
+	users := tangki.NewTangki("users", []tangki.Column{{Name: "id", Type: "INT"}, {Name: "nama", Type: "TEKS"}})
+	orders := tangki.NewTangki("orders", []tangki.Column{{Name: "id", Type: "INT"}, {Name: "user_id", Type: "INT"}})
+
+	for i := 0; i < n; i++ {
+		users.AddRow(i, fmt.Sprintf("User%d", i))
+	}
+	for i := 0; i < n*2; i++ {
+		userID := i / 2
+		if !sorted {
+			userID = (i * 7919) % n
+		}
+		orders.AddRow(i, userID)
+	}
+	return users, orders
+}
+
+func TestJoinStrategies(t *testing.T) {
+	small1, small2 := buildJoinTangkis(10, true)
+	if s := query.ChooseJoinStrategy(small1.Rows, small2.Rows, []int{0}, []int{1}); s != query.StrategyNestedLoop {
+		t.Fatalf("Expected nested loop for small inputs, got %s", s)
+	}
+
+	sorted1, sorted2 := buildJoinTangkis(1000, true)
+	if s := query.ChooseJoinStrategy(sorted1.Rows, sorted2.Rows, []int{0}, []int{1}); s != query.StrategyMerge {
+		t.Fatalf("Expected merge join for sorted inputs, got %s", s)
+	}
+
+	hash1, hash2 := buildJoinTangkis(1000, false)
+	if s := query.ChooseJoinStrategy(hash1.Rows, hash2.Rows, []int{0}, []int{1}); s != query.StrategyHash {
+		t.Fatalf("Expected hash join for unsorted inputs, got %s", s)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		t1, t2 *tangki.Tangki
+	}{
+		{"merge", sorted1, sorted2},
+		{"hash", hash1, hash2},
+	} {
+		result, err := query.Join(tc.t1, tc.t2, "id", "user_id")
+		if err != nil {
+			t.Fatalf("%s join failed: %v", tc.name, err)
+		}
+		if len(result.Rows) != 2000 {
+			t.Fatalf("%s join: expected 2000 rows, got %d", tc.name, len(result.Rows))
+		}
+		for _, row := range result.Rows {
+			if fmt.Sprint(row[0]) != fmt.Sprint(row[3]) {
+				t.Fatalf("%s join produced mismatched row %v", tc.name, row)
+			}
+		}
+	}
+}
+
+func TestJoinMixedNumericTypes(t *testing.T) {
+	users, orders := buildJoinTangkis(100, false)
+	for i := range orders.Rows {
+		orders.Rows[i][1] = int64(orders.Rows[i][1].(int))
+	}
+
+	result, err := query.Join(users, orders, "id", "user_id")
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	if len(result.Rows) != 200 {
+		t.Fatalf("Expected 200 rows, got %d", len(result.Rows))
+	}
+}
+
+func TestJoinUnknownColumn(t *testing.T) {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	db.Jalankan("BUAT TANGKI users (id INT, nama TEKS)")
+	db.Jalankan("BUAT TANGKI orders (id INT, user_id INT)")
+
+	err = db.Jalankan("GABUNG users DAN orders MENJADI user_orders DIMANA users.id = orders.pembeli")
+	if err == nil {
+		t.Fatal("Expected error for unknown join column")
+	}
+
+	if _, exists := db.GetTangki("user_orders"); exists {
+		t.Fatal("Tangki hasil tidak boleh dibuat saat join gagal")
+	}
+}
+
+func BenchmarkHashJoin(b *testing.B) {
+	users, orders := buildJoinTangkis(200000, false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := query.Join(users, orders, "id", "user_id"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestOuterJoins(t *testing.T) {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	db.Jalankan("BUAT TANGKI pegawai (id INT, nama TEKS, divisi_id INT)")
+	db.Jalankan("BUAT TANGKI divisi (id INT, lokasi TEKS)")
+
+	db.Jalankan("ISI TANGKI pegawai NILAI (1, 'Andi', 101)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (2, 'Budi', 102)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (3, 'Citra', 999)")
+
+	db.Jalankan("ISI TANGKI divisi NILAI (101, 'Jakarta')")
+	db.Jalankan("ISI TANGKI divisi NILAI (102, 'Bandung')")
+	db.Jalankan("ISI TANGKI divisi NILAI (103, 'Surabaya')")
+
+	tests := []struct {
+		fql      string
+		expected int
+		nulls    int
+	}{
+		{"GABUNG pegawai DAN divisi MENJADI hasil DIMANA pegawai.divisi_id = divisi.id", 2, 0},
+		{"GABUNG KIRI pegawai DAN divisi MENJADI hasil DIMANA pegawai.divisi_id = divisi.id", 3, 1},
+		{"GABUNG KANAN pegawai DAN divisi MENJADI hasil DIMANA pegawai.divisi_id = divisi.id", 3, 1},
+		{"GABUNG PENUH pegawai DAN divisi MENJADI hasil DIMANA pegawai.divisi_id = divisi.id", 4, 2},
+		{"GABUNG SILANG pegawai DAN divisi MENJADI hasil", 9, 0},
+		{"GABUNG KIRI pegawai DAN divisi MENJADI hasil DIMANA divisi.id = pegawai.divisi_id", 3, 1},
+	}
+
+	for _, tt := range tests {
+		db.DropTangki("hasil")
+		if err := db.Jalankan(tt.fql); err != nil {
+			t.Fatalf("%s: %v", tt.fql, err)
+		}
+
+		hasil, _ := db.GetTangki("hasil")
+		if len(hasil.Rows) != tt.expected {
+			t.Fatalf("%s: expected %d rows, got %d", tt.fql, tt.expected, len(hasil.Rows))
+		}
+
+		nulls := 0
+		for _, row := range hasil.Rows {
+			if row[0] == nil || row[3] == nil {
+				nulls++
+			}
+		}
+		if nulls != tt.nulls {
+			t.Fatalf("%s: expected %d null-padded rows, got %d", tt.fql, tt.nulls, nulls)
+		}
+	}
+}
+
+func TestJoinMultiColumnAndNonEquality(t *testing.T) {
+	db, _ := engine.OpenTangki("")
+	defer db.Close()
+
+	db.Jalankan("BUAT TANGKI stok (gudang TEKS, produk TEKS, jumlah INT)")
+	db.Jalankan("BUAT TANGKI pesanan (gudang TEKS, produk TEKS, minta INT)")
+
+	db.Jalankan("ISI TANGKI stok NILAI ('A', 'Laptop', 10)")
+	db.Jalankan("ISI TANGKI stok NILAI ('A', 'Mouse', 1)")
+	db.Jalankan("ISI TANGKI stok NILAI ('B', 'Laptop', 5)")
+
+	db.Jalankan("ISI TANGKI pesanan NILAI ('A', 'Laptop', 3)")
+	db.Jalankan("ISI TANGKI pesanan NILAI ('A', 'Mouse', 4)")
+	db.Jalankan("ISI TANGKI pesanan NILAI ('B', 'Mouse', 1)")
+
+	err := db.Jalankan("GABUNG stok DAN pesanan MENJADI cukup DIMANA stok.gudang = pesanan.gudang DAN stok.produk = pesanan.produk DAN stok.jumlah >= pesanan.minta")
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+
+	cukup, _ := db.GetTangki("cukup")
+	if len(cukup.Rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(cukup.Rows))
+	}
+	if cukup.Rows[0][1] != "Laptop" {
+		t.Fatalf("Expected 'Laptop', got %v", cukup.Rows[0][1])
+	}
+
+	err = db.Jalankan("GABUNG stok DAN pesanan MENJADI lebih DIMANA stok.jumlah > pesanan.minta")
+	if err != nil {
+		t.Fatalf("Non-equi join failed: %v", err)
+	}
+	lebih, _ := db.GetTangki("lebih")
+	if len(lebih.Rows) != 6 {
+		t.Fatalf("Expected 6 rows, got %d", len(lebih.Rows))
+	}
+}
+
+func TestOuterJoinNullsPersist(t *testing.T) {
+	path := t.TempDir() + "/outer.bensin"
+
+	db, _ := engine.OpenTangki(path)
+	db.Jalankan("BUAT TANGKI pegawai (id INT, nama TEKS, divisi_id INT)")
+	db.Jalankan("BUAT TANGKI divisi (id INT, lokasi TEKS)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (1, 'Andi', 101)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (2, 'Budi', 999)")
+	db.Jalankan("ISI TANGKI divisi NILAI (101, 'Jakarta')")
+
+	if err := db.Jalankan("GABUNG KIRI pegawai DAN divisi MENJADI hasil DIMANA pegawai.divisi_id = divisi.id"); err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	hasil, _ := db.GetTangki("hasil")
+	if len(hasil.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(hasil.Rows))
+	}
+	for _, row := range hasil.Rows {
+		if row[1] == "Budi" && (row[3] != nil || row[4] != nil) {
+			t.Fatalf("Expected NULL divisi for Budi, got %v", row)
+		}
+	}
+}
+
+func TestJoinStrategiesAgreeOnMixedTypes(t *testing.T) {
+	big := int64(1 << 53)
+	// Terurut menurut aturan merge join: angka sebelum teks.
+	left := []tangki.Row{{int64(1)}, {2.0}, {2.5}, {big}, {"1"}}
+	right := []tangki.Row{{int64(1)}, {int64(2)}, {2.5}, {big + 1}, {"1"}, {"2"}}
+
+	want := map[string]bool{"1=1": true, "2=2": true, "2.5=2.5": true, "1=1 (teks)": true}
+	for _, strategy := range []string{query.StrategyNestedLoop, query.StrategyHash, query.StrategyMerge} {
+		result := query.EquiJoinRowsWith(strategy, left, right, []int{0}, []int{0})
+		got := map[string]bool{}
+		for _, row := range result {
+			key := fmt.Sprintf("%v=%v", row[0], row[1])
+			if _, ok := row[0].(string); ok {
+				key += " (teks)"
+			}
+			got[key] = true
+		}
+		if len(result) != len(want) || len(got) != len(want) {
+			t.Fatalf("%s: expected %d matches, got %v", strategy, len(want), result)
+		}
+		for key := range want {
+			if !got[key] {
+				t.Fatalf("%s: missing match %s, got %v", strategy, key, result)
+			}
+		}
+	}
+
+	// Kolom dengan jenis berbeda tidak memicu merge join walau terurut.
+	nums := make([]tangki.Row, 100)
+	texts := make([]tangki.Row, 100)
+	for i := range nums {
+		nums[i] = tangki.Row{i}
+		texts[i] = tangki.Row{fmt.Sprintf("%03d", i)}
+	}
+	if s := query.ChooseJoinStrategy(nums, texts, []int{0}, []int{0}); s != query.StrategyHash {
+		t.Fatalf("Expected hash join for mixed key kinds, got %s", s)
+	}
+	if n := len(query.EquiJoinRows(nums, texts, []int{0}, []int{0})); n != 0 {
+		t.Fatalf("Expected no TEKS/INT matches, got %d", n)
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func TestNDJSONImportExport(t *testing.T) {
+	dir := t.TempDir()
+	db, err := engine.OpenTangki(filepath.Join(dir, "api.bensin"))
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	src := filepath.Join(dir, "pesanan.ndjson")
+	data := `{"id": 1, "kode": "007", "total": 12.5}
+{"id": 2, "kode": "A1", "total": 3}
+
+{"id": 3, "total": null}
+`
+	os.WriteFile(src, []byte(data), 0644)
+	if err := db.Jalankan("IMPOR TANGKI pesanan DARI '" + src + "'"); err != nil {
+		t.Fatalf("IMPOR: %v", err)
+	}
+	pesanan, _ := db.GetTangki("pesanan")
+	types := []string{"INT", "TEKS", "FLOAT"}
+	for i, col := range pesanan.Columns {
+		if col.Type != types[i] {
+			t.Fatalf("Column %s: expected type %s, got %s", col.Name, types[i], col.Type)
+		}
+	}
+
+	res, err := db.ImportNDJSON(context.Background(), "pesanan",
+		strings.NewReader("{\"id\": 4, \"kode\": \"B\"}\n{\"id\": \"x\"}\n[1]\n{\"id\": 5, \"diskon\": 1}\n"),
+		engine.NDJSONOptions{MaxErrors: 3})
+	if err != nil {
+		t.Fatalf("ImportNDJSON: %v", err)
+	}
+	if res.Rows != 1 || len(res.Errors) != 3 || res.Errors[2].Line != 4 {
+		t.Fatalf("Expected 1 row and 3 errors, got %+v", res)
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportNDJSON(context.Background(), "pesanan", &buf); err != nil {
+		t.Fatalf("ExportNDJSON: %v", err)
+	}
+	want := `{"id":1,"kode":"007","total":12.5}
+{"id":2,"kode":"A1","total":3}
+{"id":3,"kode":null,"total":null}
+{"id":4,"kode":"B","total":null}
+`
+	if buf.String() != want {
+		t.Fatalf("Unexpected export:\n%s", buf.String())
+	}
+
+	out := filepath.Join(dir, "salinan.jsonl")
+	if err := db.Jalankan("EKSPOR TANGKI pesanan KE '" + out + "'"); err != nil {
+		t.Fatalf("EKSPOR: %v", err)
+	}
+	if got, _ := os.ReadFile(out); string(got) != want {
+		t.Fatalf("Unexpected EKSPOR file:\n%s", got)
+	}
+	if err := db.Jalankan("EKSPOR TANGKI pesanan KE '" + out + "' DENGAN HEADER"); err == nil {
+		t.Fatal("Expected DENGAN HEADER to be rejected for NDJSON")
+	}
+}
+
+func TestQueryResultJSON(t *testing.T) {
+	db, err := engine.OpenTangki(filepath.Join(t.TempDir(), "api.bensin"))
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	db.Jalankan("BUAT TANGKI pengguna (id INT, nama TEKS)")
+	db.Jalankan("BUAT TANGKI akun (id INT, saldo FLOAT)")
+	db.Jalankan("ISI TANGKI pengguna NILAI (1, 'Budi \"B\"')")
+	db.Jalankan("ISI TANGKI akun NILAI (1, 2.5)")
+
+	res, err := db.QueryResult(context.Background(), "PILIH * DARI pengguna p GABUNG akun a PADA p.id = a.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"p.id":1,"nama":"Budi \"B\"","a.id":1,"saldo":2.5}]`
+	if string(got) != want {
+		t.Fatalf("Expected %s, got %s", want, got)
+	}
+
+	// Result yang disimpan sebagai nilai tetap memakai MarshalJSON
+	got, err = json.Marshal(struct{ Data engine.Result }{*res})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"Data":`+want+`}` {
+		t.Fatalf("Expected Result value to marshal as rows, got %s", got)
+	}
+
+	rows, err := db.QueryIter(context.Background(), "GRUPKAN TANGKI akun BERDASARKAN id SUM(saldo)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := rows.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0]["SUM(saldo)"] != 2.5 {
+		t.Fatalf("Unexpected WriteJSON output %s (%v)", buf.String(), err)
+	}
+
+	rows, _ = db.QueryIter(context.Background(), "PILIH id DARI pengguna DIMANA id > 5")
+	buf.Reset()
+	if err := rows.WriteJSON(&buf); err != nil || buf.String() != "[]" {
+		t.Fatalf("Expected empty array, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestSchemaJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.bensin")
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI pengguna (id INT, nama TEKS)")
+	db.Jalankan("ISI TANGKI pengguna NILAI (1, 'Budi')")
+	db.Jalankan("BUAT PANDANGAN TERWUJUD nama SEBAGAI PILIH nama DARI pengguna")
+	db.Close()
+
+	db, err = engine.OpenTangkiWithOptions(path, engine.Options{ReadOnly: true, LazyLoad: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	got, err := json.Marshal(db.Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"format":"1.7","tangkis":[{"name":"pengguna","columns":[{"name":"id","type":"INT"},{"name":"nama","type":"TEKS"}],"rows":1}],` +
+		`"views":[{"name":"nama","materialized":true,"definition":"PILIH nama DARI pengguna","sources":["pengguna"],"columns":[{"name":"nama","type":"TEKS"}],"rows":1}]}`
+	if string(got) != want {
+		t.Fatalf("Unexpected schema:\n%s", got)
+	}
+
+	if _, err := db.ImportNDJSON(context.Background(), "pengguna", strings.NewReader(""), engine.NDJSONOptions{}); err == nil {
+		t.Fatal("Expected import into read-only database to fail")
+	}
+}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func createGudangFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "gudang.bensin")
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI barang (id INT, nama TEKS, harga FLOAT)")
+	db.Jalankan("ISI TANGKI barang NILAI (1, 'Obeng', 15000.5)")
+	db.Jalankan("ISI TANGKI barang NILAI (2, 'Palu', 40000)")
+	db.Jalankan("BUAT TANGKI catatan (id INT, pesan TEKS)")
+	db.Jalankan("ISI TANGKI catatan NILAI (1, 'ZZZZZZZZ')")
+	db.Jalankan("BUAT PANDANGAN murah SEBAGAI PILIH nama DARI barang DIMANA harga < 20000")
+	db.Jalankan("ANALISIS TANGKI barang")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+func TestTruncatedFileIsCorrupt(t *testing.T) {
+	data, err := os.ReadFile(createGudangFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for cut := 0; cut < len(data); cut++ {
+		path := filepath.Join(dir, "potong.bensin")
+		os.Remove(path + ".lock")
+		if err := os.WriteFile(path, data[:cut], 0644); err != nil {
+			t.Fatal(err)
+		}
+		db, err := engine.OpenTangki(path)
+		if err == nil {
+			db.Close()
+			t.Fatalf("Expected error for file cut at %d of %d bytes", cut, len(data))
+		}
+		var ce *engine.CorruptError
+		if !errors.Is(err, engine.ErrCorrupt) || !errors.As(err, &ce) {
+			t.Fatalf("Cut at %d: expected ErrCorrupt, got %v", cut, err)
+		}
+		if ce.Offset > int64(cut) {
+			t.Fatalf("Cut at %d: offset %d is past the end of the file", cut, ce.Offset)
+		}
+	}
+}
+
+func TestLazyLoadDefersTangki(t *testing.T) {
+	path := createGudangFile(t)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Panjang teks 'ZZZZZZZZ' dibuat melewati ujung blok kolomnya
+	i := bytes.Index(data, []byte("ZZZZZZZZ"))
+	if i < 1 || data[i-1] != 8 {
+		t.Fatalf("Could not find catatan text in file")
+	}
+	data[i-1] = 0x7f
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := engine.OpenTangki(path); !errors.Is(err, engine.ErrCorrupt) {
+		t.Fatalf("Expected eager open to fail with ErrCorrupt, got %v", err)
+	}
+
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{LazyLoad: true})
+	if err != nil {
+		t.Fatalf("Lazy open should not read catatan: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("PILIH nama DARI murah")
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Expected 1 row from murah, got %v (%v)", rows, err)
+	}
+	if err := db.Jalankan("ISI TANGKI barang NILAI (3, 'Tang', 25000)"); err != nil {
+		t.Fatalf("Insert into loaded tangki: %v", err)
+	}
+	if _, err := db.Query("PILIH * DARI catatan"); !errors.Is(err, engine.ErrCorrupt) {
+		t.Fatalf("Expected ErrCorrupt when catatan is first read, got %v", err)
+	}
+	if err := db.Jalankan("ISI TANGKI catatan NILAI (2, 'lagi')"); !errors.Is(err, engine.ErrCorrupt) {
+		t.Fatalf("Expected ErrCorrupt when writing to catatan, got %v", err)
+	}
+}
+
+func TestLazyLoadMatchesEager(t *testing.T) {
+	path := createGudangFile(t)
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{LazyLoad: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Jalankan("ISI TANGKI catatan NILAI (2, 'lagi')"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("PILIH * DARI catatan")
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %v (%v)", rows, err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err = engine.OpenTangki(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for q, want := range map[string]int{"PILIH * DARI catatan": 2, "PILIH * DARI barang": 2} {
+		rows, err := db.Query(q)
+		if err != nil || len(rows) != want {
+			t.Fatalf("%s: expected %d rows, got %v (%v)", q, want, rows, err)
+		}
+	}
+}
+
+func TestCorruptRowCount(t *testing.T) {
+	path := createGudangFile(t)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Jumlah baris catatan ada sesudah nama kolom terakhir, tipe, dan
+	// jenis penyimpanannya
+	i := bytes.Index(data, []byte("pesan"))
+	if i < 0 {
+		t.Fatalf("Could not find catatan columns in file")
+	}
+	count := data[i+len("pesan")+2:]
+	if count[0] != 1 {
+		t.Fatalf("Expected row count 1, got %d", count[0])
+	}
+	copy(count, []byte{0xf0, 0xff, 0xff, 0xff})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var ce *engine.CorruptError
+	if _, err := engine.OpenTangki(path); !errors.As(err, &ce) || !errors.Is(err, engine.ErrCorrupt) {
+		t.Fatalf("Expected ErrCorrupt, got %v", err)
+	}
+	if ce.Offset <= int64(i) {
+		t.Fatalf("Expected offset past the row count, got %d", ce.Offset)
+	}
+
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{LazyLoad: true})
+	if err != nil {
+		t.Fatalf("Lazy open should not read catatan: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Query("PILIH * DARI catatan"); !errors.Is(err, engine.ErrCorrupt) {
+		t.Fatalf("Expected ErrCorrupt when catatan is first read, got %v", err)
+	}
+}
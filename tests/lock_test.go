@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func TestSecondWriterIsLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kunci.bensin")
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI produk (id INT, nama TEKS)")
+
+	if _, err := engine.OpenTangki(path); !errors.Is(err, engine.ErrLocked) {
+		t.Fatalf("Expected ErrLocked for second writer, got %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Setelah Close lock dilepas dan perubahan tidak tertimpa
+	db, err = engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db.Close()
+	if _, ok := db.GetTangki("produk"); !ok {
+		t.Fatal("Expected tangki to persist")
+	}
+}
+
+func TestSharedReadOnlyLock(t *testing.T) {
+	path := createProdukFile(t)
+
+	r1, err := engine.OpenTangkiWithOptions(path, engine.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to open first reader: %v", err)
+	}
+	r2, err := engine.OpenTangkiWithOptions(path, engine.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Expected readers to share the lock: %v", err)
+	}
+
+	if _, err := engine.OpenTangki(path); !errors.Is(err, engine.ErrLocked) {
+		t.Fatalf("Expected writer to be locked out by readers, got %v", err)
+	}
+
+	r1.Close()
+	r2.Close()
+
+	w, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Expected writer to open after readers closed: %v", err)
+	}
+	defer w.Close()
+	if _, err := engine.OpenTangkiWithOptions(path, engine.Options{ReadOnly: true}); !errors.Is(err, engine.ErrLocked) {
+		t.Fatalf("Expected reader to be locked out by writer, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func TestParallelWritesToDifferentTangkis(t *testing.T) {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	const tangkis, rows = 30, 50
+	for i := 0; i < tangkis; i++ {
+		db.Jalankan(fmt.Sprintf("BUAT TANGKI t%d (id INT, nama TEKS)", i))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, tangkis*rows)
+	for i := 0; i < tangkis; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for r := 0; r < rows; r++ {
+				if err := db.Jalankan(fmt.Sprintf("ISI TANGKI t%d NILAI (%d, 'x')", i, r)); err != nil {
+					errs <- err
+				}
+			}
+			if err := db.Jalankan(fmt.Sprintf("ATUR TANGKI t%d SET nama = 'y' DIMANA id < 10", i)); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for i := 0; i < tangkis; i++ {
+		results, _ := db.Query(fmt.Sprintf("PILIH id DARI t%d DIMANA nama = 'y'", i))
+		all, _ := db.Query(fmt.Sprintf("PILIH id DARI t%d", i))
+		if len(all) != rows || len(results) != 10 {
+			t.Fatalf("t%d: expected %d rows and 10 updated, got %d and %d", i, rows, len(all), len(results))
+		}
+	}
+}
+
+func TestMultiTangkiLockOrder(t *testing.T) {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	db.Jalankan("BUAT TANGKI alfa (id INT, nama TEKS)")
+	db.Jalankan("BUAT TANGKI beta (id INT, nama TEKS)")
+	db.Jalankan("ISI TANGKI alfa NILAI (1, 'a')")
+	db.Jalankan("ISI TANGKI beta NILAI (1, 'b')")
+
+	// Urutan tangki yang berlawanan tidak boleh saling mengunci
+	statements := []string{
+		"GABUNG alfa DAN beta MENJADI ab DIMANA alfa.id = beta.id",
+		"GABUNG beta DAN alfa MENJADI ba DIMANA beta.id = alfa.id",
+		"SATUKAN alfa, beta MENJADI gabungan",
+		"ISI TANGKI alfa NILAI (2, 'a')",
+		"ISI TANGKI beta NILAI (2, 'b')",
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for _, stmt := range statements {
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(stmt string) {
+					defer wg.Done()
+					if err := db.Jalankan(stmt); err != nil {
+						t.Errorf("%s: %v", stmt, err)
+					}
+				}(stmt)
+			}
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Multi-tangki statements deadlocked")
+	}
+	if results, _ := db.Query("PILIH id DARI alfa"); len(results) != 21 {
+		t.Fatalf("Expected 21 rows in alfa, got %d", len(results))
+	}
+}
+
+// BenchmarkParallelInsertTangkis mengisi satu tangki per goroutine.
+func BenchmarkParallelInsertTangkis(b *testing.B) {
+	db, _ := engine.OpenTangki("")
+	defer db.Close()
+
+	const tangkis = 30
+	for i := 0; i < tangkis; i++ {
+		db.Jalankan(fmt.Sprintf("BUAT TANGKI t%d (id INT, nama TEKS)", i))
+	}
+
+	var mu sync.Mutex
+	next := 0
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		mu.Lock()
+		name := fmt.Sprintf("t%d", next%tangkis)
+		next++
+		mu.Unlock()
+		for n := 0; pb.Next(); n++ {
+			if err := db.Jalankan(fmt.Sprintf("ISI TANGKI %s NILAI (%d, 'x')", name, n)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQueryIterSeesSnapshot(t *testing.T) {
+	db := setupAngka(t, 100)
+	defer db.Close()
+
+	rows, err := db.QueryIter(context.Background(), "PILIH id, genap DARI angka")
+	if err != nil {
+		t.Fatalf("QueryIter failed: %v", err)
+	}
+	defer rows.Close()
+	if _, ok := rows.Next(); !ok {
+		t.Fatal("Expected first row")
+	}
+
+	// Rows yang masih terbuka tidak menahan penulis
+	if err := db.Jalankan("ISI TANGKI angka NILAI (101, 0)"); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if err := db.Jalankan("ATUR TANGKI angka SET genap = 9 DIMANA id > 0"); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := db.Jalankan("BAKAR TANGKI angka DIMANA id < 50"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	count := 1
+	for {
+		row, ok := rows.Next()
+		if !ok {
+			break
+		}
+		if toInt64(row[1]) == 9 {
+			t.Fatalf("Snapshot saw a later update: %v", row)
+		}
+		count++
+	}
+	if count != 100 {
+		t.Fatalf("Expected 100 rows from the snapshot, got %d", count)
+	}
+
+	results, _ := db.Query("PILIH * DARI angka")
+	if len(results) != 52 {
+		t.Fatalf("Expected 52 rows after writes, got %d", len(results))
+	}
+}
+
+func TestGetTangkiVersionIsStable(t *testing.T) {
+	db := setupAngka(t, 10)
+	defer db.Close()
+
+	old, _ := db.GetTangki("angka")
+	db.Jalankan("ATUR TANGKI angka SET genap = 5 DIMANA id = 1")
+	db.Jalankan("ISI TANGKI angka NILAI (11, 0)")
+
+	if len(old.Rows) != 10 || toInt64(old.Rows[0][1]) != 0 {
+		t.Fatalf("Old version changed: %d rows, first %v", len(old.Rows), old.Rows[0])
+	}
+	fresh, _ := db.GetTangki("angka")
+	if len(fresh.Rows) != 11 || toInt64(fresh.Rows[0][1]) != 5 {
+		t.Fatalf("Expected new version, got %d rows, first %v", len(fresh.Rows), fresh.Rows[0])
+	}
+}
+
+func TestGetTangkiReturnsCopy(t *testing.T) {
+	db := setupAngka(t, 10)
+	defer db.Close()
+
+	// Mengubah hasil GetTangki tidak mengubah snapshot yang dibaca Query
+	tk, _ := db.GetTangki("angka")
+	tk.Rows[0][1] = int64(7)
+	tk.Rows = tk.Rows[:5]
+	tk.Columns[0].Name = "nomor"
+	tk.AddRow([]interface{}{int64(99), int64(0)})
+
+	results, err := db.Query("PILIH id, genap DARI angka")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 10 || toInt64(results[0][1]) != 0 {
+		t.Fatalf("Expected the database to be unchanged, got %v", results)
+	}
+	again, _ := db.GetTangki("angka")
+	if len(again.Rows) != 10 || again.Columns[0].Name != "id" || toInt64(again.Rows[0][1]) != 0 {
+		t.Fatalf("Expected a fresh copy, got %v %v", again.Columns, again.Rows)
+	}
+}
+
+func TestConcurrentReadersAndWriters(t *testing.T) {
+	db := setupAngka(t, 100)
+	defer db.Close()
+
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if err := db.Jalankan(fmt.Sprintf("ISI TANGKI angka NILAI (%d, 0)", 1000+w*100+i)); err != nil {
+					failed.Add(1)
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := 0
+			for i := 0; i < 50; i++ {
+				results, err := db.Query("PILIH id DARI angka")
+				if err != nil || len(results) < last {
+					failed.Add(1)
+				}
+				last = len(results)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed.Load() != 0 {
+		t.Fatalf("%d operations failed or went back in time", failed.Load())
+	}
+	if results, _ := db.Query("PILIH id DARI angka"); len(results) != 300 {
+		t.Fatalf("Expected 300 rows, got %d", len(results))
+	}
+}
+
+// BenchmarkMixedReadWrite menjalankan 1 ISI untuk setiap 9 PILIH secara
+// paralel pada satu tangki.
+func BenchmarkMixedReadWrite(b *testing.B) {
+	db := setupAngka(b, 1000)
+	defer db.Close()
+
+	var seq atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := seq.Add(1)
+			if n%10 == 0 {
+				if err := db.Jalankan(fmt.Sprintf("ISI TANGKI angka NILAI (%d, 0)", n)); err != nil {
+					b.Fatal(err)
+				}
+				continue
+			}
+			if _, err := db.Query("PILIH id DARI angka DIMANA genap = 1 BATAS 20"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,360 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+type recordingMetrics struct {
+	mu    sync.Mutex
+	kinds []string
+}
+
+func (m *recordingMetrics) ObserveStatement(kind string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kinds = append(m.kinds, kind)
+}
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func createProdukFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "produk.bensin")
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI produk (id INT, nama TEKS)")
+	db.Jalankan("ISI TANGKI produk NILAI (1, 'Laptop')")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+// openFileCopy membuka salinan file di path (beserta WAL-nya, bila ada)
+// secara hanya-baca, untuk memeriksa isi file selagi engine penulis masih
+// memegang lock-nya.
+func openFileCopy(t *testing.T, path string) (*engine.Engine, error) {
+	cp := filepath.Join(t.TempDir(), "salinan.bensin")
+	for _, suffix := range []string{"", ".wal"} {
+		data, err := os.ReadFile(path + suffix)
+		if os.IsNotExist(err) && suffix != "" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(cp+suffix, data, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return engine.OpenTangkiWithOptions(cp, engine.Options{ReadOnly: true})
+}
+
+func TestOpenMustExistAndMemory(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "hilang.bensin")
+	if _, err := engine.OpenTangkiWithOptions(missing, engine.Options{MustExist: true}); err == nil {
+		t.Fatal("Expected error opening a missing file with MustExist")
+	}
+
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	db, err := engine.OpenTangkiWithOptions(engine.MemoryPath, engine.Options{})
+	if err != nil {
+		t.Fatalf("Failed to open in-memory engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI produk (id INT, nama TEKS)")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("Expected in-memory engine to write nothing, found %v", entries)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	path := createProdukFile(t)
+	before, _ := os.ReadFile(path)
+
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to open read-only: %v", err)
+	}
+	if err := db.Jalankan("ISI TANGKI produk NILAI (2, 'Mouse')"); err == nil || !strings.Contains(err.Error(), "hanya-baca") {
+		t.Fatalf("Expected read-only error, got %v", err)
+	}
+	if err := db.DropTangki("produk"); err == nil {
+		t.Fatal("Expected DropTangki to fail in read-only mode")
+	}
+	results, err := db.Query("PILIH nama DARI produk")
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected reads to work, got %v (%v)", results, err)
+	}
+	db.Close()
+
+	after, _ := os.ReadFile(path)
+	if string(before) != string(after) {
+		t.Fatal("Read-only engine modified the file")
+	}
+}
+
+func TestDurabilityLevels(t *testing.T) {
+	path := createProdukFile(t)
+
+	db, _ := engine.OpenTangkiWithOptions(path, engine.Options{Durability: engine.DurabilityNone})
+	db.Jalankan("ISI TANGKI produk NILAI (2, 'Mouse')")
+	db.Close()
+
+	db, _ = engine.OpenTangkiWithOptions(path, engine.Options{Durability: engine.DurabilityFsync})
+	if results, _ := db.Query("PILIH id DARI produk"); len(results) != 1 {
+		t.Fatalf("Expected DurabilityNone to skip saving, got %v", results)
+	}
+	db.Jalankan("ISI TANGKI produk NILAI (3, 'Keyboard')")
+
+	// Perubahan sudah ada di file (lewat WAL) sebelum Close
+	other, err := openFileCopy(t, path)
+	if err != nil {
+		t.Fatalf("Failed to open file copy: %v", err)
+	}
+	defer other.Close()
+	if results, _ := other.Query("PILIH id DARI produk"); len(results) != 2 {
+		t.Fatalf("Expected DurabilityFsync to write every change, got %v", results)
+	}
+	db.Close()
+}
+
+func TestWALRecovery(t *testing.T) {
+	path := createProdukFile(t)
+	before, _ := os.ReadFile(path)
+
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{Durability: engine.DurabilityFsync})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	db.Jalankan("ISI TANGKI produk NILAI (2, 'Mouse')")
+	db.Jalankan("ATUR TANGKI produk SET nama = 'Tetikus' DIMANA id = 2")
+	db.Jalankan("BUAT TANGKI kategori (nama TEKS)")
+	db.DropTangki("kategori")
+
+	// Perubahan hanya ditambahkan ke WAL; snapshot tidak ditulis ulang
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Fatal("Expected the snapshot to stay untouched until checkpoint")
+	}
+
+	// Salinan file dan WAL saat ini sama dengan keadaan setelah crash;
+	// catatan terakhir yang terpotong diabaikan
+	dir := t.TempDir()
+	cp := filepath.Join(dir, "crash.bensin")
+	data, _ := os.ReadFile(path)
+	os.WriteFile(cp, data, 0644)
+	wal, _ := os.ReadFile(path + ".wal")
+	os.WriteFile(cp+".wal", append(wal, 40, 0, 0, 0, 1, 2), 0644)
+
+	other, err := engine.OpenTangki(cp)
+	if err != nil {
+		t.Fatalf("Recovery failed: %v", err)
+	}
+	results, _ := other.Query("PILIH nama DARI produk DIMANA id = 2")
+	if len(results) != 1 || results[0][0] != "Tetikus" {
+		t.Fatalf("Expected replayed update, got %v", results)
+	}
+	if _, ok := other.GetTangki("kategori"); ok {
+		t.Fatal("Expected replayed DropTangki")
+	}
+	other.Close()
+	if _, err := os.Stat(cp + ".wal"); !os.IsNotExist(err) {
+		t.Fatalf("Expected WAL to be removed after recovery, got %v", err)
+	}
+	other, _ = engine.OpenTangki(cp)
+	if results, _ := other.Query("PILIH id DARI produk"); len(results) != 2 {
+		t.Fatalf("Expected recovered rows to be saved once, got %v", results)
+	}
+	other.Close()
+
+	// Checkpoint menulis snapshot dan mengosongkan WAL
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if info, _ := os.Stat(path + ".wal"); info.Size() != 12 {
+		t.Fatalf("Expected an empty WAL after checkpoint, got %d bytes", info.Size())
+	}
+	db.Jalankan("ISI TANGKI produk NILAI (3, 'Keyboard')")
+	check, err := openFileCopy(t, path)
+	if err != nil {
+		t.Fatalf("Failed to open file copy: %v", err)
+	}
+	defer check.Close()
+	if results, _ := check.Query("PILIH id DARI produk"); len(results) != 3 {
+		t.Fatalf("Expected checkpoint and WAL to give 3 rows, got %v", results)
+	}
+}
+
+func TestFailedWALWriteIsNotPublished(t *testing.T) {
+	path := createProdukFile(t)
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{Durability: engine.DurabilityFsync})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	src := filepath.Join(t.TempDir(), "produk.csv")
+	os.WriteFile(src, []byte("2,Mouse\n3,Keyboard\n"), 0644)
+
+	// IMPOR dicatat sebagai checkpoint; direktori di tempat file database
+	// membuat checkpoint itu gagal
+	data, _ := os.ReadFile(path)
+	os.Remove(path)
+	os.MkdirAll(filepath.Join(path, "isi"), 0755)
+	if err := db.Jalankan("IMPOR TANGKI produk DARI '" + src + "'"); err == nil {
+		t.Fatal("Expected IMPOR to fail when its checkpoint cannot be written")
+	}
+	if results, _ := db.Query("PILIH id DARI produk"); len(results) != 1 {
+		t.Fatalf("Expected the failed IMPOR to stay invisible, got %v", results)
+	}
+	if tk, _ := db.GetTangki("produk"); len(tk.Rows) != 1 {
+		t.Fatalf("Expected the failed IMPOR to be rolled back, got %v", tk.Rows)
+	}
+
+	os.RemoveAll(path)
+	os.WriteFile(path, data, 0644)
+	if err := db.Jalankan("ISI TANGKI produk NILAI (4, 'Monitor')"); err != nil {
+		t.Fatalf("ISI after failed IMPOR: %v", err)
+	}
+	if results, _ := db.Query("PILIH id DARI produk"); len(results) != 2 {
+		t.Fatalf("Expected only the new row to be added, got %v", results)
+	}
+}
+
+func TestAutosave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auto.bensin")
+	logger := &recordingLogger{}
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{AutosaveInterval: 10 * time.Millisecond, Logger: logger})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	db.Jalankan("BUAT TANGKI produk (id INT, nama TEKS)")
+	db.Jalankan("ISI TANGKI produk NILAI (1, 'Laptop')")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if other, err := openFileCopy(t, path); err == nil {
+			results, _ := other.Query("PILIH id DARI produk")
+			other.Close()
+			if len(results) == 1 {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Autosave did not write the database")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.lines) == 0 || !strings.Contains(logger.lines[0], "membuka") {
+		t.Errorf("Expected open to be logged, got %v", logger.lines)
+	}
+}
+
+func TestMemoryLimitAndMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	db, err := engine.OpenTangkiWithOptions(engine.MemoryPath, engine.Options{MemoryLimit: 1000, Metrics: metrics})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+
+	db.Jalankan("BUAT TANGKI produk (id INT, nama TEKS)")
+	var err2 error
+	inserted := 0
+	for i := 0; i < 100 && err2 == nil; i++ {
+		if err2 = db.Jalankan(fmt.Sprintf("ISI TANGKI produk NILAI (%d, 'Barang')", i)); err2 == nil {
+			inserted++
+		}
+	}
+	if err2 == nil || !strings.Contains(err2.Error(), "batas memori") {
+		t.Fatalf("Expected memory limit error, got %v", err2)
+	}
+	if inserted == 0 || inserted >= 100 {
+		t.Fatalf("Expected some inserts before the limit, got %d", inserted)
+	}
+
+	db.Jalankan("BAKAR TANGKI produk DIMANA id >= 0")
+	if err := db.Jalankan("ISI TANGKI produk NILAI (1, 'Barang')"); err != nil {
+		t.Fatalf("Expected insert to succeed after freeing memory, got %v", err)
+	}
+
+	db.Query("PILIH * DARI produk")
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.kinds[0] != "CREATE" || metrics.kinds[len(metrics.kinds)-1] != "SELECT" {
+		t.Fatalf("Unexpected metrics %v", metrics.kinds)
+	}
+}
+
+func TestQueryIterMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	db, err := engine.OpenTangkiWithOptions(engine.MemoryPath, engine.Options{Metrics: metrics})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	db.Jalankan("BUAT TANGKI produk (id INT, nama TEKS)")
+	db.Jalankan("ISI TANGKI produk NILAI (1, 'Laptop')")
+
+	count := func() int {
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+		return len(metrics.kinds)
+	}
+	before := count()
+
+	// Baris yang habis melaporkan query sekali, termasuk bila Close
+	// dipanggil lagi sesudahnya
+	rows, err := db.QueryIter(context.Background(), "PILIH * DARI produk")
+	if err != nil {
+		t.Fatalf("QueryIter failed: %v", err)
+	}
+	if count() != before {
+		t.Fatal("Expected QueryIter to be observed only when it finishes")
+	}
+	for _, ok := rows.Next(); ok; _, ok = rows.Next() {
+	}
+	rows.Close()
+	if count() != before+1 {
+		t.Fatalf("Expected one observation after the rows ran out, got %d", count()-before)
+	}
+
+	// Rows yang ditutup lebih awal juga dilaporkan
+	rows, _ = db.QueryIter(context.Background(), "PILIH * DARI produk")
+	rows.Close()
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.kinds) != before+2 || metrics.kinds[len(metrics.kinds)-1] != "SELECT" {
+		t.Fatalf("Unexpected metrics %v", metrics.kinds)
+	}
+}
@@ -0,0 +1,309 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+	"github.com/Dziqha/BensinDB/pkg/pager"
+)
+
+func TestBufferPoolEvictsWithClock(t *testing.T) {
+	p, err := pager.Open(filepath.Join(t.TempDir(), "uji.pages"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	pool := pager.NewBufferPool(p, 2)
+
+	ids := make([]pager.PageID, 3)
+	for i := range ids {
+		pg, err := pool.New()
+		if err != nil {
+			t.Fatalf("New page %d: %v", i, err)
+		}
+		pg.Lock()
+		pg.Data[0] = byte(i + 1)
+		pg.Unlock()
+		ids[i] = pg.ID
+		pool.Unpin(pg, true)
+	}
+
+	// Halaman pertama sudah dikeluarkan dan harus terbaca dari file
+	for i, id := range ids {
+		pg, err := pool.Fetch(id)
+		if err != nil {
+			t.Fatalf("Fetch %d: %v", id, err)
+		}
+		if pg.Data[0] != byte(i+1) {
+			t.Fatalf("Page %d: expected %d, got %d", id, i+1, pg.Data[0])
+		}
+		pool.Unpin(pg, false)
+	}
+	if _, misses := pool.Stats(); misses == 0 {
+		t.Fatal("Expected evicted pages to be read back from disk")
+	}
+
+	a, _ := pool.Fetch(ids[0])
+	b, _ := pool.Fetch(ids[1])
+	if _, err := pool.Fetch(ids[2]); !errors.Is(err, pager.ErrPoolFull) {
+		t.Fatalf("Expected ErrPoolFull with every frame pinned, got %v", err)
+	}
+	pool.Unpin(a, false)
+	pool.Unpin(b, false)
+}
+
+func TestPagedStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.bensin")
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{PageCache: 4})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI audit (id INT, aksi TEKS, durasi FLOAT)")
+	db.Jalankan("BUAT PANDANGAN TERWUJUD hapus SEBAGAI PILIH id DARI audit DIMANA aksi = 'HAPUS'")
+	aksi := []string{"BACA", "TULIS", "HAPUS"}
+	for i := 0; i < 3000; i++ {
+		if err := db.Jalankan(fmt.Sprintf("ISI TANGKI audit NILAI (%d, '%s', %d.5)", i, aksi[i%3], i%10)); err != nil {
+			t.Fatalf("Insert %d: %v", i, err)
+		}
+	}
+
+	// GetTangki memuat baris berhalaman seperti tanpa mode halaman
+	audit, _ := db.GetTangki("audit")
+	if audit.Paged() || len(audit.Rows) != 3000 || audit.Rows[2999][0] != int64(2999) {
+		t.Fatalf("Expected GetTangki to load 3000 rows, got paged=%v rows=%d", audit.Paged(), len(audit.Rows))
+	}
+
+	check := func(db *engine.Engine) {
+		t.Helper()
+		rows, err := db.Query("PILIH id DARI audit DIMANA aksi = 'TULIS' DAN id >= 2900")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 33 {
+			t.Fatalf("Expected 33 rows, got %d", len(rows))
+		}
+		rows, err = db.Query("GRUPKAN TANGKI audit BERDASARKAN aksi COUNT(id)")
+		if err != nil || len(rows) != 3 {
+			t.Fatalf("GRUPKAN: %v %v", rows, err)
+		}
+		rows, err = db.Query("PILIH * DARI hapus")
+		if err != nil || len(rows) != 1000 {
+			t.Fatalf("Expected 1000 rows in view, got %d (%v)", len(rows), err)
+		}
+	}
+	check(db)
+
+	it, err := db.QueryIter(context.Background(), "PILIH id DARI audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, ok := it.Next(); ok; _, ok = it.Next() {
+		n++
+	}
+	if it.Err() != nil || n != 3000 {
+		t.Fatalf("QueryIter: %d rows, %v", n, it.Err())
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if info, err := os.Stat(path + ".pages"); err != nil || info.Size() <= 4*pager.PageSize {
+		t.Fatalf("Expected more pages than the cache in the page file: %v", err)
+	}
+
+	// Tanpa PageCache, file berhalaman tetap dibuka dengan pool bawaan
+	db, err = engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	check(db)
+
+	if err := db.Jalankan("ATUR TANGKI audit SET aksi = 'ARSIP' DIMANA id < 1500"); err != nil {
+		t.Fatalf("ATUR: %v", err)
+	}
+	if err := db.Jalankan("BAKAR TANGKI audit DIMANA aksi = 'ARSIP'"); err != nil {
+		t.Fatalf("BAKAR: %v", err)
+	}
+	if err := db.Jalankan("ISI TANGKI audit NILAI (5000, 'HAPUS', 1.5)"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("PILIH id DARI hapus")
+	if err != nil || len(rows) != 501 {
+		t.Fatalf("Expected 501 rows in view after changes, got %d (%v)", len(rows), err)
+	}
+
+	// Save ke path lain menulis baris langsung ke file itu
+	copyPath := filepath.Join(t.TempDir(), "salinan.bensin")
+	if err := engine.Save(db, copyPath); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{path, copyPath} {
+		db, err := engine.OpenTangki(p)
+		if err != nil {
+			t.Fatalf("Open %s: %v", p, err)
+		}
+		rows, err := db.Query("PILIH id DARI audit")
+		if err != nil || len(rows) != 1501 {
+			t.Fatalf("%s: expected 1501 rows, got %d (%v)", p, len(rows), err)
+		}
+		db.Close()
+	}
+	if _, err := os.Stat(copyPath + ".pages"); !os.IsNotExist(err) {
+		t.Fatalf("Copy should not need a page file, got %v", err)
+	}
+}
+
+func TestPagedFileReusesFreedPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stok.bensin")
+	db, err := engine.OpenTangkiWithOptions(path, engine.Options{PageCache: 4})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI stok (id INT, catatan TEKS)")
+	for i := 0; i < 1000; i++ {
+		db.Jalankan(fmt.Sprintf("ISI TANGKI stok NILAI (%d, 'catatan awal barang nomor %04d')", i, i))
+	}
+	if err := db.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path + ".pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial := info.Size()
+
+	// Versi lama yang masih dibaca tidak boleh tertimpa halaman baru
+	old, err := db.QueryIter(context.Background(), "PILIH * DARI stok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for round := 0; round < 20; round++ {
+		fql := fmt.Sprintf("ATUR TANGKI stok SET catatan = 'catatan putaran %02d barang' DIMANA id >= 0", round)
+		if err := db.Jalankan(fql); err != nil {
+			t.Fatalf("ATUR round %d: %v", round, err)
+		}
+		if err := db.Checkpoint(); err != nil {
+			t.Fatal(err)
+		}
+		runtime.GC()
+	}
+
+	info, err = os.Stat(path + ".pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > 4*initial {
+		t.Fatalf("Page file grew from %d to %d bytes; freed pages are not reused", initial, info.Size())
+	}
+
+	n := 0
+	for row, ok := old.Next(); ok; row, ok = old.Next() {
+		if want := fmt.Sprintf("catatan awal barang nomor %04d", n); row[1] != want {
+			t.Fatalf("Old version row %d changed: %v", n, row)
+		}
+		n++
+	}
+	if old.Err() != nil || n != 1000 {
+		t.Fatalf("Old version: %d rows, %v", n, old.Err())
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db.Close()
+	rows, err := db.Query("PILIH id DARI stok DIMANA catatan = 'catatan putaran 19 barang'")
+	if err != nil || len(rows) != 1000 {
+		t.Fatalf("Expected 1000 rows after reopen, got %d (%v)", len(rows), err)
+	}
+}
+
+func TestPagedUpdateDeleteKeepMemoryBounded(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "log.csv")
+	var data strings.Builder
+	data.WriteString("id,pesan\n")
+	for i := 0; i < 40000; i++ {
+		fmt.Fprintf(&data, "%d,pesan log nomor %06d %s\n", i, i, strings.Repeat("x", 100))
+	}
+	os.WriteFile(src, []byte(data.String()), 0644)
+
+	db, err := engine.OpenTangkiWithOptions(filepath.Join(dir, "log.bensin"), engine.Options{PageCache: 8})
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	defer db.Close()
+	if err := db.Jalankan("IMPOR TANGKI log DARI '" + src + "' DENGAN HEADER"); err != nil {
+		t.Fatalf("IMPOR: %v", err)
+	}
+	data.Reset()
+
+	// Sampel heap diambil selama perintah berjalan; GC yang lebih sering
+	// membuat sampah per halaman tidak terhitung sebagai pemakaian
+	defer debug.SetGCPercent(debug.SetGCPercent(10))
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	heap := func() uint64 {
+		metrics.Read(sample)
+		return sample[0].Value.Uint64()
+	}
+	run := func(fql string) {
+		t.Helper()
+		runtime.GC()
+		base := heap()
+		var peak atomic.Uint64
+		done := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if h := heap(); h > peak.Load() {
+					peak.Store(h)
+				}
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+		err := db.Jalankan(fql)
+		close(done)
+		if err != nil {
+			t.Fatalf("%s: %v", fql, err)
+		}
+		if grew := int64(peak.Load()) - int64(base); grew > 3<<20 {
+			t.Errorf("%s: heap grew by %d bytes; the tangki was loaded into memory", fql, grew)
+		}
+	}
+
+	run("ATUR TANGKI log SET pesan = 'diarsipkan' DIMANA id < 20000")
+	run("BAKAR TANGKI log DIMANA pesan = 'diarsipkan'")
+
+	rows, err := db.Query("PILIH id DARI log DIMANA id < 20000")
+	if err != nil || len(rows) != 0 {
+		t.Fatalf("Expected archived rows to be deleted, got %d (%v)", len(rows), err)
+	}
+	rows, err = db.Query("GRUPKAN TANGKI log BERDASARKAN pesan COUNT(id)")
+	if err != nil || len(rows) != 20000 {
+		t.Fatalf("Expected 20000 groups, got %d (%v)", len(rows), err)
+	}
+}
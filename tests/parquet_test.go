@@ -0,0 +1,347 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+	"github.com/Dziqha/BensinDB/pkg/parquet"
+	"github.com/Dziqha/BensinDB/pkg/tangki"
+)
+
+// openBarang membuat tangki barang berisi 3000 baris; setiap baris
+// kesepuluh punya nama kosong dan harga NULL.
+func openBarang(t *testing.T) (*engine.Engine, string) {
+	dir := t.TempDir()
+	db, err := engine.OpenTangki(filepath.Join(dir, "gudang.bensin"))
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var data strings.Builder
+	data.WriteString("id,nama,harga\n")
+	for i := 0; i < 3000; i++ {
+		if i%10 == 0 {
+			fmt.Fprintf(&data, "%d,,\n", i)
+			continue
+		}
+		fmt.Fprintf(&data, "%d,barang%d,%d.5\n", i, i%7, i)
+	}
+	_, err = db.ImportCSV(context.Background(), "barang", strings.NewReader(data.String()), engine.CSVOptions{Header: true})
+	if err != nil {
+		t.Fatalf("ImportCSV: %v", err)
+	}
+	return db, dir
+}
+
+// sameRows membandingkan baris dengan int dan int64 dianggap sama.
+func sameRows(a, b tangki.Row) bool {
+	norm := func(row tangki.Row) tangki.Row {
+		out := make(tangki.Row, len(row))
+		for i, v := range row {
+			if n, ok := v.(int); ok {
+				v = int64(n)
+			}
+			out[i] = v
+		}
+		return out
+	}
+	return reflect.DeepEqual(norm(a), norm(b))
+}
+
+func TestParquetExportImport(t *testing.T) {
+	db, dir := openBarang(t)
+	want, _ := db.Query("PILIH * DARI barang")
+
+	path := filepath.Join(dir, "barang.parquet")
+	if err := db.Jalankan("EKSPOR TANGKI barang KE '" + path + "'"); err != nil {
+		t.Fatalf("EKSPOR: %v", err)
+	}
+	if err := db.Jalankan("IMPOR TANGKI salinan DARI '" + path + "'"); err != nil {
+		t.Fatalf("IMPOR: %v", err)
+	}
+	salinan, _ := db.GetTangki("salinan")
+	cols := []tangki.Column{{Name: "id", Type: "INT"}, {Name: "nama", Type: "TEKS"}, {Name: "harga", Type: "FLOAT"}}
+	if !reflect.DeepEqual(salinan.Columns, cols) {
+		t.Fatalf("Unexpected columns: %v", salinan.Columns)
+	}
+	got, _ := db.Query("PILIH * DARI salinan")
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d rows, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !sameRows(got[i], want[i]) {
+			t.Fatalf("Row %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	// Snappy dengan row group kecil, ditambahkan ke tangki yang sudah ada
+	var buf bytes.Buffer
+	opts := engine.ParquetOptions{Compression: parquet.Snappy, RowGroupSize: 1000}
+	if err := db.ExportParquet(context.Background(), "barang", &buf, opts); err != nil {
+		t.Fatalf("ExportParquet: %v", err)
+	}
+	res, err := db.ImportParquet(context.Background(), "salinan", bytes.NewReader(buf.Bytes()), int64(buf.Len()), engine.ParquetOptions{})
+	if err != nil || res.Rows != len(want) || res.Created {
+		t.Fatalf("ImportParquet: %+v (%v)", res, err)
+	}
+	rows, _ := db.Query("PILIH id DARI salinan DIMANA id = 7")
+	if len(rows) != 2 {
+		t.Fatalf("Expected row 7 twice, got %v", rows)
+	}
+}
+
+func TestParquetQueryResult(t *testing.T) {
+	db, _ := openBarang(t)
+	rows, err := db.QueryIter(context.Background(), "PILIH id, harga * 2 SEBAGAI ganda DARI barang DIMANA id < 5")
+	if err != nil {
+		t.Fatalf("QueryIter: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := rows.WriteParquet(&buf, engine.ParquetOptions{Compression: parquet.Gzip}); err != nil {
+		t.Fatalf("WriteParquet: %v", err)
+	}
+
+	file, err := parquet.Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	cols := []tangki.Column{{Name: "id", Type: "INT"}, {Name: "ganda", Type: "FLOAT"}}
+	if !reflect.DeepEqual(file.Columns(), cols) || file.NumRows() != 5 {
+		t.Fatalf("Unexpected file: %v, %d rows", file.Columns(), file.NumRows())
+	}
+	var got []tangki.Row
+	file.Scan(func(row tangki.Row) bool {
+		got = append(got, row)
+		return true
+	})
+	if got[0][1] != nil || got[1][1] != 3.0 || got[4][0] != 4 {
+		t.Fatalf("Unexpected rows: %v", got)
+	}
+}
+
+func TestParquetRejectsUnsupported(t *testing.T) {
+	db, dir := openBarang(t)
+	if err := db.Jalankan("IMPOR TANGKI x DARI '" + filepath.Join(dir, "x.arrow") + "'"); err == nil {
+		t.Fatal("Expected IMPOR from Arrow to fail")
+	}
+	data := []byte("PAR1 bukan parquet PAR1")
+	if _, err := parquet.Open(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Fatal("Expected corrupt footer to be rejected")
+	}
+}
+
+// compact membaca Thrift compact protocol secara terpisah dari paket
+// parquet, agar footer bisa diperiksa langsung terhadap spesifikasi.
+// Struct menjadi map id field ke nilai; bilangan bulat menjadi int64,
+// binary menjadi []byte, dan list menjadi []interface{}.
+type compact struct {
+	data []byte
+	pos  int
+}
+
+func (r *compact) varint() uint64 {
+	var v uint64
+	for shift := 0; ; shift += 7 {
+		b := r.data[r.pos]
+		r.pos++
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v
+		}
+	}
+}
+
+func (r *compact) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compact) value(typ byte) interface{} {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case 3:
+		r.pos++
+		return int64(int8(r.data[r.pos-1]))
+	case 4, 5, 6:
+		return r.zigzag()
+	case 7:
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos-8:]))
+	case 8:
+		n := int(r.varint())
+		r.pos += n
+		return r.data[r.pos-n : r.pos]
+	case 9, 10:
+		head := r.data[r.pos]
+		r.pos++
+		n := int(head >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			elem := head & 0x0f
+			if elem == 1 || elem == 2 {
+				// Boolean dalam list ditulis sebagai satu byte
+				r.pos++
+				list[i] = r.data[r.pos-1] == 1
+				continue
+			}
+			list[i] = r.value(elem)
+		}
+		return list
+	case 12:
+		return r.readStruct()
+	}
+	panic(fmt.Sprintf("tipe compact %d tidak dikenal", typ))
+}
+
+func (r *compact) readStruct() map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var id int16
+	for {
+		head := r.data[r.pos]
+		r.pos++
+		if head == 0 {
+			return fields
+		}
+		if delta := head >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(head & 0x0f)
+	}
+}
+
+func TestParquetFollowsSpec(t *testing.T) {
+	db, _ := openBarang(t)
+	var buf bytes.Buffer
+	opts := engine.ParquetOptions{Compression: parquet.Snappy, RowGroupSize: 1000}
+	if err := db.ExportParquet(context.Background(), "barang", &buf, opts); err != nil {
+		t.Fatalf("ExportParquet: %v", err)
+	}
+	data := buf.Bytes()
+
+	// File diawali dan diakhiri magic "PAR1"; sebelum magic penutup ada
+	// panjang FileMetaData (uint32 little-endian)
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatalf("Expected PAR1 magic at both ends, got %q ... %q", data[:4], data[len(data)-4:])
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - footerLen
+	if footerStart <= 4 {
+		t.Fatalf("Footer length %d does not fit in %d bytes", footerLen, len(data))
+	}
+	r := &compact{data: data[:len(data)-8], pos: footerStart}
+	meta := r.readStruct()
+	if r.pos != len(data)-8 {
+		t.Fatalf("FileMetaData used %d of %d footer bytes", r.pos-footerStart, footerLen)
+	}
+
+	// FileMetaData: 1 version, 2 schema, 3 num_rows, 4 row_groups
+	if meta[1] != int64(1) || meta[3] != int64(3000) {
+		t.Fatalf("Unexpected version %v or num_rows %v", meta[1], meta[3])
+	}
+	schema := meta[2].([]interface{})
+	root := schema[0].(map[int16]interface{})
+	if string(root[4].([]byte)) != "schema" || root[5] != int64(3) {
+		t.Fatalf("Unexpected schema root %v", root)
+	}
+	// SchemaElement: 1 type, 3 repetition_type, 4 name, 6 converted_type.
+	// INT64 = 2, BYTE_ARRAY = 6 (UTF8 = 0), DOUBLE = 5; OPTIONAL = 1
+	names := []string{"id", "nama", "harga"}
+	types := []int64{2, 6, 5}
+	for i, el := range schema[1:] {
+		leaf := el.(map[int16]interface{})
+		if string(leaf[4].([]byte)) != names[i] || leaf[1] != types[i] || leaf[3] != int64(1) {
+			t.Fatalf("Unexpected schema element %d: %v", i, leaf)
+		}
+		if _, utf8 := leaf[6]; utf8 != (types[i] == 6) || (utf8 && leaf[6] != int64(0)) {
+			t.Fatalf("Unexpected converted type for %s: %v", names[i], leaf[6])
+		}
+	}
+
+	groups := meta[4].([]interface{})
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 row groups, got %d", len(groups))
+	}
+	next := int64(4)
+	for g, el := range groups {
+		// RowGroup: 1 columns, 3 num_rows
+		group := el.(map[int16]interface{})
+		if group[3] != int64(1000) {
+			t.Fatalf("Row group %d: expected 1000 rows, got %v", g, group[3])
+		}
+		for c, el := range group[1].([]interface{}) {
+			// ColumnChunk: 2 file_offset, 3 meta_data. ColumnMetaData: 1 type,
+			// 2 encodings, 3 path_in_schema, 4 codec, 5 num_values,
+			// 7 total_compressed_size, 9 data_page_offset,
+			// 11 dictionary_page_offset, 12 statistics
+			chunk := el.(map[int16]interface{})
+			cm := chunk[3].(map[int16]interface{})
+			path := cm[3].([]interface{})
+			if cm[1] != types[c] || len(path) != 1 || string(path[0].([]byte)) != names[c] {
+				t.Fatalf("Chunk %d/%d: unexpected type %v or path %q", g, c, cm[1], path)
+			}
+			if cm[4] != int64(parquet.Snappy) || cm[5] != int64(1000) {
+				t.Fatalf("Chunk %d/%d: unexpected codec %v or num_values %v", g, c, cm[4], cm[5])
+			}
+			hasRLE := false
+			for _, enc := range cm[2].([]interface{}) {
+				hasRLE = hasRLE || enc == int64(3)
+			}
+			if !hasRLE {
+				t.Fatalf("Chunk %d/%d: expected RLE definition levels, got %v", g, c, cm[2])
+			}
+
+			// Chunk dimulai di halaman dictionary bila ada, lalu halaman data,
+			// dan chunk-chunk tersusun rapat sampai footer
+			start, dict := cm[9].(int64), cm[11] != nil
+			if dict {
+				start = cm[11].(int64)
+			}
+			if start != next || chunk[2] != start {
+				t.Fatalf("Chunk %d/%d: expected to start at %d, got %d (file_offset %v)", g, c, next, start, chunk[2])
+			}
+			next = start + cm[7].(int64)
+
+			// PageHeader: 1 type (DATA_PAGE = 0, DICTIONARY_PAGE = 2),
+			// 3 compressed_page_size, 5 data_page_header (1 num_values)
+			ph := (&compact{data: data, pos: int(start)}).readStruct()
+			if want := map[bool]int64{false: 0, true: 2}[dict]; ph[1] != want {
+				t.Fatalf("Chunk %d/%d: expected page type %d at %d, got %v", g, c, want, start, ph[1])
+			}
+			page := (&compact{data: data, pos: int(cm[9].(int64))}).readStruct()
+			if page[1] != int64(0) || page[5].(map[int16]interface{})[1] != int64(1000) {
+				t.Fatalf("Chunk %d/%d: unexpected data page header %v", g, c, page)
+			}
+
+			// Statistics: 3 null_count, 5 max_value, 6 min_value
+			stats := cm[12].(map[int16]interface{})
+			if c == 0 {
+				lo := binary.LittleEndian.Uint64(stats[6].([]byte))
+				hi := binary.LittleEndian.Uint64(stats[5].([]byte))
+				if lo != uint64(g*1000) || hi != uint64(g*1000+999) {
+					t.Fatalf("Chunk %d/%d: expected id range %d..%d, got %d..%d", g, c, g*1000, g*1000+999, lo, hi)
+				}
+			}
+			if nulls := []int64{0, 0, 100}[c]; stats[3] != nulls {
+				t.Fatalf("Chunk %d/%d: expected %d nulls, got %v", g, c, nulls, stats[3])
+			}
+		}
+	}
+	if next != int64(footerStart) {
+		t.Fatalf("Expected the last chunk to end at the footer (%d), got %d", footerStart, next)
+	}
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+	"github.com/Dziqha/BensinDB/pkg/parser"
+)
+
+func setupPegawaiDivisi(t *testing.T) *engine.Engine {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	db.Jalankan("BUAT TANGKI pegawai (id INT, nama TEKS, gaji FLOAT, divisi_id INT)")
+	db.Jalankan("BUAT TANGKI divisi (id INT, nama TEKS, lokasi TEKS)")
+
+	db.Jalankan("ISI TANGKI pegawai NILAI (1, 'Andi', 5000000, 101)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (2, 'Budi', 6000000, 101)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (3, 'Citra', 7000000, 102)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (4, 'Dedi', 5500000, 999)")
+
+	db.Jalankan("ISI TANGKI divisi NILAI (101, 'IT', 'Jakarta')")
+	db.Jalankan("ISI TANGKI divisi NILAI (102, 'HR', 'Bandung')")
+	return db
+}
+
+func TestSelectJoinWithAliases(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	results, err := db.Query("PILIH p.nama, d.lokasi DARI pegawai p GABUNG divisi d PADA p.divisi_id = d.id DIMANA d.lokasi = 'Jakarta'")
+	if err != nil {
+		t.Fatalf("Join query failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(results))
+	}
+	for _, row := range results {
+		if len(row) != 2 || row[1] != "Jakarta" {
+			t.Fatalf("Unexpected row %v", row)
+		}
+	}
+
+	results, err = db.Query("PILIH pegawai.nama, divisi.nama SEBAGAI divisi DARI pegawai GABUNG KIRI divisi PADA pegawai.divisi_id = divisi.id")
+	if err != nil {
+		t.Fatalf("Left join query failed: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 rows, got %d", len(results))
+	}
+	if results[3][0] != "Dedi" || results[3][1] != nil {
+		t.Fatalf("Expected Dedi without divisi, got %v", results[3])
+	}
+
+	if len(db.ListTangki()) != 2 {
+		t.Fatalf("Query tidak boleh membuat tangki baru, got %v", db.ListTangki())
+	}
+}
+
+func TestSelectAmbiguousColumn(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	_, err := db.Query("PILIH nama DARI pegawai GABUNG divisi PADA pegawai.divisi_id = divisi.id")
+	if err == nil {
+		t.Fatal("Expected ambiguous column error")
+	}
+
+	results, err := db.Query("PILIH d.* DARI pegawai p GABUNG divisi d PADA p.divisi_id = d.id DIMANA p.gaji > 5500000 DAN d.nama = 'IT'")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 1 || len(results[0]) != 3 {
+		t.Fatalf("Expected one divisi row, got %v", results)
+	}
+}
+
+func TestMaterializedJoinQualifiesDuplicateColumns(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	err := db.Jalankan("GABUNG pegawai DAN divisi MENJADI lengkap DIMANA pegawai.divisi_id = divisi.id")
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+
+	results, err := db.Query("PILIH divisi.nama, lokasi DARI lengkap DIMANA pegawai.nama = 'Citra'")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 1 || results[0][0] != "HR" {
+		t.Fatalf("Expected HR, got %v", results)
+	}
+}
+
+func TestParserErrorsInsteadOfPanic(t *testing.T) {
+	db, _ := engine.OpenTangki("")
+	defer db.Close()
+
+	if _, err := db.Query("PILIH DARI"); err == nil {
+		t.Fatal("Expected parse error")
+	}
+}
+
+func TestParserRejectsTrailingTokens(t *testing.T) {
+	for _, input := range []string{
+		"PILIH * DARI a URUTKAN x",
+		"PILIH * DARI a, b",
+		"PILIH * DARI a BATAS 1 sampah",
+		"JELASKAN PILIH * DARI a sampah",
+		"ISI TANGKI a NILAI (1) x",
+		"BAKAR TANGKI a DIMANA id = 1 x",
+		"URUTKAN TANGKI a BERDASARKAN id x",
+	} {
+		if _, err := parser.NewParser(input).Parse(); err == nil {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+
+	for _, input := range []string{
+		"PILIH * DARI a;",
+		"PILIH p.id DARI a p",
+		"PILIH p.* DARI a p GABUNG b q PADA p.id = q.id",
+	} {
+		if _, err := parser.NewParser(input).Parse(); err != nil {
+			t.Errorf("Expected %q to parse, got %v", input, err)
+		}
+	}
+
+	q, err := parser.NewParser("BUAT PANDANGAN v SEBAGAI PILIH * DARI a ;").Parse()
+	if err != nil || q.ViewInfo.Definition != "PILIH * DARI a" {
+		t.Fatalf("Unexpected view definition %+v (%v)", q, err)
+	}
+}
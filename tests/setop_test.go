@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func setupKota(t *testing.T) *engine.Engine {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	db.Jalankan("BUAT TANGKI jakarta (id INT, nama TEKS)")
+	db.Jalankan("BUAT TANGKI bandung (id INT, nama TEKS)")
+	db.Jalankan("BUAT TANGKI angka (id FLOAT, nama TEKS)")
+	db.Jalankan("BUAT TANGKI lain (nama TEKS, id INT)")
+
+	db.Jalankan("ISI TANGKI jakarta NILAI (1, 'Eko')")
+	db.Jalankan("ISI TANGKI jakarta NILAI (2, 'Fitri')")
+	db.Jalankan("ISI TANGKI bandung NILAI (2, 'Fitri')")
+	db.Jalankan("ISI TANGKI bandung NILAI (3, 'Irfan')")
+	db.Jalankan("ISI TANGKI angka NILAI (1.0, 'Eko')")
+	return db
+}
+
+func TestUnionDedupesByDefault(t *testing.T) {
+	db := setupKota(t)
+	defer db.Close()
+
+	if err := db.Jalankan("SATUKAN jakarta, bandung MENJADI gabungan"); err != nil {
+		t.Fatalf("Union failed: %v", err)
+	}
+	gabungan, _ := db.GetTangki("gabungan")
+	if len(gabungan.Rows) != 3 {
+		t.Fatalf("Expected 3 distinct rows, got %d", len(gabungan.Rows))
+	}
+
+	if err := db.Jalankan("SATUKAN SEMUA jakarta, bandung MENJADI semua_all"); err != nil {
+		t.Fatalf("Union all failed: %v", err)
+	}
+	semuaAll, _ := db.GetTangki("semua_all")
+	if len(semuaAll.Rows) != 4 {
+		t.Fatalf("Expected 4 rows, got %d", len(semuaAll.Rows))
+	}
+}
+
+func TestUnionSchemaMismatch(t *testing.T) {
+	db := setupKota(t)
+	defer db.Close()
+
+	if err := db.Jalankan("SATUKAN jakarta, lain MENJADI salah"); err == nil {
+		t.Fatal("Expected schema mismatch error")
+	}
+	if _, exists := db.GetTangki("salah"); exists {
+		t.Fatal("Tangki hasil tidak boleh dibuat saat union gagal")
+	}
+
+	// INT dan FLOAT kompatibel dan dikoersi menjadi FLOAT
+	if err := db.Jalankan("SATUKAN jakarta, angka MENJADI campuran"); err != nil {
+		t.Fatalf("Union with coercion failed: %v", err)
+	}
+	campuran, _ := db.GetTangki("campuran")
+	if campuran.Columns[0].Type != "FLOAT" {
+		t.Fatalf("Expected FLOAT column, got %s", campuran.Columns[0].Type)
+	}
+	if len(campuran.Rows) != 2 {
+		t.Fatalf("Expected Eko to be deduplicated, got %d rows", len(campuran.Rows))
+	}
+}
+
+func TestIntersectExcept(t *testing.T) {
+	db := setupKota(t)
+	defer db.Close()
+
+	if err := db.Jalankan("IRISAN jakarta, bandung MENJADI keduanya"); err != nil {
+		t.Fatalf("Intersect failed: %v", err)
+	}
+	keduanya, _ := db.GetTangki("keduanya")
+	if len(keduanya.Rows) != 1 || keduanya.Rows[0][1] != "Fitri" {
+		t.Fatalf("Expected Fitri, got %v", keduanya.Rows)
+	}
+
+	if err := db.Jalankan("KECUALI jakarta, bandung MENJADI hanya_jakarta"); err != nil {
+		t.Fatalf("Except failed: %v", err)
+	}
+	hanya, _ := db.GetTangki("hanya_jakarta")
+	if len(hanya.Rows) != 1 || hanya.Rows[0][1] != "Eko" {
+		t.Fatalf("Expected Eko, got %v", hanya.Rows)
+	}
+}
+
+func TestSetOperationsInQuery(t *testing.T) {
+	db := setupKota(t)
+	defer db.Close()
+
+	tests := []struct {
+		fql      string
+		expected int
+	}{
+		{"PILIH nama DARI jakarta SATUKAN PILIH nama DARI bandung", 3},
+		{"PILIH nama DARI jakarta SATUKAN SEMUA PILIH nama DARI bandung", 4},
+		{"PILIH nama DARI jakarta CAMPUR PILIH nama DARI bandung", 3},
+		{"PILIH nama DARI jakarta IRISAN PILIH nama DARI bandung", 1},
+		{"PILIH nama DARI jakarta KECUALI PILIH nama DARI bandung", 1},
+		{"PILIH nama DARI pegawai_tidak_ada SATUKAN PILIH nama DARI bandung", -1},
+		{"PILIH id, nama DARI jakarta SATUKAN PILIH nama DARI bandung", -1},
+	}
+
+	for _, tt := range tests {
+		results, err := db.Query(tt.fql)
+		if tt.expected == -1 {
+			if err == nil {
+				t.Fatalf("%s: expected error", tt.fql)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.fql, err)
+		}
+		if len(results) != tt.expected {
+			t.Fatalf("%s: expected %d rows, got %d", tt.fql, tt.expected, len(results))
+		}
+	}
+
+	results, err := db.Query("PILIH nama DARI jakarta DIMANA nama DI DALAM (PILIH nama DARI bandung SATUKAN PILIH nama DARI angka)")
+	if err != nil {
+		t.Fatalf("Set operation in subquery failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(results))
+	}
+}
+
+func TestSetOperationsLeftToRight(t *testing.T) {
+	db := setupKota(t)
+	defer db.Close()
+
+	// (jakarta SATUKAN bandung) IRISAN angka, bukan
+	// jakarta SATUKAN (bandung IRISAN angka) seperti SQL standar
+	results, err := db.Query("PILIH nama DARI jakarta SATUKAN PILIH nama DARI bandung IRISAN PILIH nama DARI angka")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 1 || results[0][0] != "Eko" {
+		t.Fatalf("Expected left-to-right evaluation to give [Eko], got %v", results)
+	}
+
+	results, err = db.Query("PILIH nama DARI jakarta KECUALI PILIH nama DARI bandung SATUKAN PILIH nama DARI bandung")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected (jakarta KECUALI bandung) SATUKAN bandung to give 3 rows, got %v", results)
+	}
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func setupUjian(t *testing.T, path string) *engine.Engine {
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	db.Jalankan("BUAT TANGKI ujian (id INT, kelas TEKS, skor FLOAT)")
+	for i := 1; i <= 100; i++ {
+		kelas := "A"
+		if i%4 == 0 {
+			kelas = "B"
+		}
+		db.Jalankan(fmt.Sprintf("ISI TANGKI ujian NILAI (%d, '%s', %d)", i, kelas, i))
+	}
+	return db
+}
+
+func TestAnalyzeCollectsStatistics(t *testing.T) {
+	db := setupUjian(t, "")
+	defer db.Close()
+
+	if _, ok := db.GetStatistik("ujian"); ok {
+		t.Fatal("Expected no statistics before ANALISIS")
+	}
+	if err := db.Jalankan("ANALISIS TANGKI ujian"); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	stats, ok := db.GetStatistik("ujian")
+	if !ok || stats.Rows != 100 {
+		t.Fatalf("Expected statistics for 100 rows, got %+v", stats)
+	}
+	id := stats.Column("id")
+	if id.Distinct != 100 || toInt64(id.Min) != 1 || toInt64(id.Max) != 100 || id.NullFraction != 0 {
+		t.Errorf("Unexpected id statistics %+v", id)
+	}
+	if len(id.Bounds) != 11 {
+		t.Errorf("Expected 10 histogram buckets, got bounds %v", id.Bounds)
+	}
+	if kelas := stats.Column("kelas"); kelas.Distinct != 2 || kelas.Min != "A" || kelas.Max != "B" {
+		t.Errorf("Unexpected kelas statistics %+v", kelas)
+	}
+
+	if err := db.Jalankan("ANALISIS TANGKI hantu"); err == nil {
+		t.Error("Expected error analyzing a missing tangki")
+	}
+}
+
+func TestAnalyzeImprovesEstimates(t *testing.T) {
+	db := setupUjian(t, "")
+	defer db.Close()
+
+	db.Jalankan("ANALISIS TANGKI ujian")
+
+	rows, err := db.Query("JELASKAN PILIH id DARI ujian DIMANA skor < 26")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	lines := explainLines(t, rows)
+	if !strings.Contains(lines[1], "perkiraan=26 aktual=25") {
+		t.Errorf("Expected histogram-based estimate, got %q", lines[1])
+	}
+
+	rows, err = db.Query("JELASKAN PILIH id DARI ujian DIMANA kelas = 'B'")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	lines = explainLines(t, rows)
+	if !strings.Contains(lines[1], "perkiraan=50") {
+		t.Errorf("Expected distinct-count estimate, got %q", lines[1])
+	}
+}
+
+func TestStatisticsAutoRefresh(t *testing.T) {
+	db := setupUjian(t, "")
+	defer db.Close()
+
+	db.SetAnalyzeThreshold(0.1)
+	db.Jalankan("ANALISIS TANGKI ujian")
+
+	for i := 101; i <= 109; i++ {
+		db.Jalankan(fmt.Sprintf("ISI TANGKI ujian NILAI (%d, 'C', %d)", i, i))
+	}
+	if stats, _ := db.GetStatistik("ujian"); stats.Rows != 100 {
+		t.Fatalf("Expected stale statistics below threshold, got %d rows", stats.Rows)
+	}
+
+	db.Jalankan("ATUR TANGKI ujian SET kelas = 'C' DIMANA id = 1")
+	stats, _ := db.GetStatistik("ujian")
+	if stats.Rows != 109 || stats.Column("kelas").Distinct != 3 {
+		t.Fatalf("Expected statistics refreshed after 10%% of rows changed, got %+v", stats)
+	}
+
+	db.Jalankan("BAKAR TANGKI ujian DIMANA kelas = 'B'")
+	if stats, _ := db.GetStatistik("ujian"); stats.Rows != 84 {
+		t.Fatalf("Expected statistics refreshed after delete, got %d rows", stats.Rows)
+	}
+}
+
+func TestStatisticsPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.bensin")
+
+	db := setupUjian(t, path)
+	db.Jalankan("ANALISIS TANGKI ujian")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	stats, ok := db.GetStatistik("ujian")
+	if !ok || stats.Rows != 100 {
+		t.Fatalf("Expected persisted statistics, got %+v", stats)
+	}
+	skor := stats.Column("skor")
+	if skor.Distinct != 100 || skor.Min != 1.0 || skor.Max != 100.0 || len(skor.Bounds) != 11 {
+		t.Errorf("Unexpected skor statistics after reload %+v", skor)
+	}
+}
+
+func TestStatisticsPersistLongText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.bensin")
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	panjang := strings.Repeat("x", 70000)
+	db.Jalankan("BUAT TANGKI catatan (id INT, pesan TEKS)")
+	db.Jalankan("ISI TANGKI catatan NILAI (1, 'a')")
+	db.Jalankan("ISI TANGKI catatan NILAI (2, '" + panjang + "')")
+	if err := db.Jalankan("ANALISIS TANGKI catatan"); err != nil {
+		t.Fatalf("ANALISIS failed: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err = engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db.Close()
+	stats, ok := db.GetStatistik("catatan")
+	if !ok {
+		t.Fatal("Expected persisted statistics")
+	}
+	if pesan := stats.Column("pesan"); pesan.Min != "a" || pesan.Max != panjang {
+		t.Errorf("Unexpected pesan bounds after reload: min %v, max of %d bytes", pesan.Min, len(fmt.Sprint(pesan.Max)))
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+)
+
+func TestSubqueryIn(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	results, err := db.Query("PILIH nama DARI pegawai DIMANA divisi_id DI DALAM (PILIH id DARI divisi DIMANA lokasi = 'Jakarta')")
+	if err != nil {
+		t.Fatalf("IN subquery failed: %v", err)
+	}
+	if len(results) != 2 || results[0][0] != "Andi" || results[1][0] != "Budi" {
+		t.Fatalf("Expected Andi and Budi, got %v", results)
+	}
+
+	results, err = db.Query("PILIH nama DARI pegawai DIMANA divisi_id TIDAK DI DALAM (PILIH id DARI divisi)")
+	if err != nil {
+		t.Fatalf("NOT IN subquery failed: %v", err)
+	}
+	if len(results) != 1 || results[0][0] != "Dedi" {
+		t.Fatalf("Expected Dedi, got %v", results)
+	}
+
+	results, err = db.Query("PILIH nama DARI pegawai DIMANA id DI DALAM (1, 3)")
+	if err != nil {
+		t.Fatalf("IN list failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(results))
+	}
+
+	if _, err := db.Query("PILIH nama DARI pegawai DIMANA divisi_id DI DALAM (PILIH * DARI divisi)"); err == nil {
+		t.Fatal("Expected error for multi-column IN subquery")
+	}
+}
+
+func TestSubqueryExistsCorrelated(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	results, err := db.Query("PILIH d.nama DARI divisi d DIMANA ADA (PILIH id DARI pegawai p DIMANA p.divisi_id = d.id DAN p.gaji > 6500000)")
+	if err != nil {
+		t.Fatalf("EXISTS failed: %v", err)
+	}
+	if len(results) != 1 || results[0][0] != "HR" {
+		t.Fatalf("Expected HR, got %v", results)
+	}
+
+	results, err = db.Query("PILIH nama DARI pegawai p DIMANA TIDAK ADA (PILIH id DARI divisi d DIMANA d.id = p.divisi_id)")
+	if err != nil {
+		t.Fatalf("NOT EXISTS failed: %v", err)
+	}
+	if len(results) != 1 || results[0][0] != "Dedi" {
+		t.Fatalf("Expected Dedi, got %v", results)
+	}
+
+	// Korelasi non-kesamaan tidak bisa didekorelasi dan dijalankan per baris
+	results, err = db.Query("PILIH nama DARI pegawai p DIMANA ADA (PILIH id DARI pegawai q DIMANA q.gaji > p.gaji)")
+	if err != nil {
+		t.Fatalf("Correlated EXISTS failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(results))
+	}
+}
+
+func TestScalarSubquery(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	results, err := db.Query("PILIH p.nama, (PILIH d.lokasi DARI divisi d DIMANA d.id = p.divisi_id) SEBAGAI lokasi DARI pegawai p")
+	if err != nil {
+		t.Fatalf("Scalar subquery failed: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 rows, got %d", len(results))
+	}
+	if results[2][1] != "Bandung" || results[3][1] != nil {
+		t.Fatalf("Unexpected result %v", results)
+	}
+
+	results, err = db.Query("PILIH nama DARI pegawai DIMANA divisi_id = (PILIH id DARI divisi DIMANA nama = 'HR')")
+	if err != nil {
+		t.Fatalf("Scalar comparison failed: %v", err)
+	}
+	if len(results) != 1 || results[0][0] != "Citra" {
+		t.Fatalf("Expected Citra, got %v", results)
+	}
+
+	if _, err := db.Query("PILIH nama DARI pegawai DIMANA divisi_id = (PILIH id DARI divisi)"); err == nil {
+		t.Fatal("Expected error for scalar subquery returning multiple rows")
+	}
+}
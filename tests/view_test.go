@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func TestViewReflectsSourceChanges(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	if err := db.Jalankan("BUAT PANDANGAN jakarta SEBAGAI PILIH p.nama, p.gaji DARI pegawai p GABUNG divisi d PADA p.divisi_id = d.id DIMANA d.lokasi = 'Jakarta'"); err != nil {
+		t.Fatalf("Create view failed: %v", err)
+	}
+
+	results, err := db.Query("PILIH nama DARI jakarta DIMANA gaji > 5000000")
+	if err != nil {
+		t.Fatalf("Query view failed: %v", err)
+	}
+	if len(results) != 1 || results[0][0] != "Budi" {
+		t.Fatalf("Expected [Budi], got %v", results)
+	}
+
+	db.Jalankan("ISI TANGKI pegawai NILAI (5, 'Eka', 8000000, 101)")
+	results, _ = db.Query("PILIH * DARI jakarta")
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 rows after insert, got %d", len(results))
+	}
+
+	if err := db.Jalankan("ISI TANGKI jakarta NILAI ('X', 1)"); err == nil {
+		t.Fatal("Expected error writing into a view")
+	}
+	if err := db.Jalankan("BUAT TANGKI jakarta (id INT)"); err == nil {
+		t.Fatal("Expected error creating tangki with a view name")
+	}
+}
+
+func TestMaterializedViewRefresh(t *testing.T) {
+	db := setupPegawaiDivisi(t)
+	defer db.Close()
+
+	if err := db.Jalankan("BUAT PANDANGAN TERWUJUD kaya SEBAGAI PILIH nama, gaji DARI pegawai DIMANA gaji >= 6000000"); err != nil {
+		t.Fatalf("Create materialized view failed: %v", err)
+	}
+	if err := db.Jalankan("BUAT PANDANGAN TERWUJUD per_divisi SEBAGAI PILIH k.nama, d.nama SEBAGAI divisi DARI pegawai k GABUNG divisi d PADA k.divisi_id = d.id"); err != nil {
+		t.Fatalf("Create materialized join view failed: %v", err)
+	}
+
+	count := func(name string) int {
+		tk, ok := db.GetTangki(name)
+		if !ok {
+			t.Fatalf("Materialized view %s not stored as tangki", name)
+		}
+		return len(tk.Rows)
+	}
+	if count("kaya") != 2 || count("per_divisi") != 3 {
+		t.Fatalf("Unexpected initial sizes: kaya=%d per_divisi=%d", count("kaya"), count("per_divisi"))
+	}
+
+	// ISI diterapkan secara inkremental
+	db.Jalankan("ISI TANGKI pegawai NILAI (5, 'Eka', 9000000, 102)")
+	db.Jalankan("ISI TANGKI pegawai NILAI (6, 'Fajar', 1000000, 102)")
+	if count("kaya") != 3 || count("per_divisi") != 5 {
+		t.Fatalf("After insert: kaya=%d per_divisi=%d", count("kaya"), count("per_divisi"))
+	}
+
+	// ATUR dan BAKAR memicu refresh penuh
+	db.Jalankan("ATUR TANGKI pegawai SET gaji = 100 DIMANA nama = 'Budi'")
+	db.Jalankan("BAKAR TANGKI divisi DIMANA id = 102")
+	if count("kaya") != 2 || count("per_divisi") != 2 {
+		t.Fatalf("After update/delete: kaya=%d per_divisi=%d", count("kaya"), count("per_divisi"))
+	}
+
+	if err := db.Jalankan("SEGARKAN PANDANGAN kaya"); err != nil {
+		t.Fatalf("Manual refresh failed: %v", err)
+	}
+	if err := db.Jalankan("ISI TANGKI kaya NILAI ('X', 1)"); err == nil {
+		t.Fatal("Expected error writing into a materialized view")
+	}
+}
+
+func TestViewsPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "views.bensin")
+
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	db.Jalankan("BUAT TANGKI produk (nama TEKS, harga INT)")
+	db.Jalankan("ISI TANGKI produk NILAI ('Buku', 20)")
+	db.Jalankan("ISI TANGKI produk NILAI ('Tas', 150)")
+	if err := db.Jalankan("BUAT PANDANGAN murah SEBAGAI PILIH nama DARI produk DIMANA harga < 100"); err != nil {
+		t.Fatalf("Create view failed: %v", err)
+	}
+	if err := db.Jalankan("BUAT PANDANGAN TERWUJUD mahal SEBAGAI PILIH nama DARI produk DIMANA harga >= 100"); err != nil {
+		t.Fatalf("Create materialized view failed: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err = engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	if views := db.ListPandangan(); len(views) != 2 || views[0] != "murah" || views[1] != "mahal" {
+		t.Fatalf("Expected [murah mahal], got %v", views)
+	}
+
+	db.Jalankan("ISI TANGKI produk NILAI ('Sepatu', 300)")
+	results, err := db.Query("PILIH * DARI mahal")
+	if err != nil {
+		t.Fatalf("Query materialized view failed: %v", err)
+	}
+	if len(results) != 2 || results[1][0] != "Sepatu" {
+		t.Fatalf("Expected materialized view to keep refreshing after reload, got %v", results)
+	}
+
+	if err := db.DropPandangan("murah"); err != nil {
+		t.Fatalf("Drop view failed: %v", err)
+	}
+	if _, err := db.Query("PILIH * DARI murah"); err == nil {
+		t.Fatal("Expected error querying dropped view")
+	}
+}
+
+func TestLongViewDefinitionPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "views.bensin")
+	db, err := engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+	panjang := strings.Repeat("x", 70000)
+	db.Jalankan("BUAT TANGKI produk (nama TEKS, harga INT)")
+	db.Jalankan("ISI TANGKI produk NILAI ('" + panjang + "', 20)")
+	if err := db.Jalankan("BUAT PANDANGAN panjang SEBAGAI PILIH harga DARI produk DIMANA nama = '" + panjang + "'"); err != nil {
+		t.Fatalf("Create view failed: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err = engine.OpenTangki(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db.Close()
+	results, err := db.Query("PILIH * DARI panjang")
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected 1 row from reloaded view, got %v (%v)", results, err)
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Dziqha/BensinDB/pkg/engine"
+)
+
+func setupGajiDivisi(t *testing.T) *engine.Engine {
+	db, err := engine.OpenTangki("")
+	if err != nil {
+		t.Fatalf("Failed to open engine: %v", err)
+	}
+
+	db.Jalankan("BUAT TANGKI karyawan (nama TEKS, divisi TEKS, gaji INT)")
+	db.Jalankan("ISI TANGKI karyawan NILAI ('Andi', 'IT', 500)")
+	db.Jalankan("ISI TANGKI karyawan NILAI ('Budi', 'IT', 700)")
+	db.Jalankan("ISI TANGKI karyawan NILAI ('Citra', 'HR', 600)")
+	db.Jalankan("ISI TANGKI karyawan NILAI ('Dedi', 'IT', 700)")
+	db.Jalankan("ISI TANGKI karyawan NILAI ('Eka', 'HR', 400)")
+	db.Jalankan("ISI TANGKI karyawan NILAI ('Fajar', 'IT', 300)")
+	return db
+}
+
+func TestWindowRanking(t *testing.T) {
+	db := setupGajiDivisi(t)
+	defer db.Close()
+
+	results, err := db.Query("PILIH nama, ROW_NUMBER() OVER (PARTISI BERDASARKAN divisi URUTKAN BERDASARKAN gaji MENURUN, nama) SEBAGAI urut, RANK() OVER (PARTISI BERDASARKAN divisi URUTKAN BERDASARKAN gaji MENURUN), DENSE_RANK() OVER (PARTISI BERDASARKAN divisi URUTKAN BERDASARKAN gaji MENURUN) DARI karyawan")
+	if err != nil {
+		t.Fatalf("Window query failed: %v", err)
+	}
+
+	// Urutan baris hasil tetap mengikuti tangki sumber
+	expected := map[string][3]int64{
+		"Andi":  {3, 3, 2},
+		"Budi":  {1, 1, 1},
+		"Citra": {1, 1, 1},
+		"Dedi":  {2, 1, 1},
+		"Eka":   {2, 2, 2},
+		"Fajar": {4, 4, 3},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d", len(expected), len(results))
+	}
+	for _, row := range results {
+		want := expected[row[0].(string)]
+		if row[1] != want[0] || row[2] != want[1] || row[3] != want[2] {
+			t.Errorf("%s: expected %v, got %v", row[0], want, row[1:])
+		}
+	}
+}
+
+func TestWindowRunningAggregates(t *testing.T) {
+	db := setupGajiDivisi(t)
+	defer db.Close()
+
+	results, err := db.Query("PILIH nama, SUM(gaji) OVER (PARTISI BERDASARKAN divisi URUTKAN BERDASARKAN gaji), AVG(gaji) OVER (PARTISI BERDASARKAN divisi), COUNT(*) OVER () DARI karyawan DIMANA divisi = 'IT'")
+	if err != nil {
+		t.Fatalf("Window query failed: %v", err)
+	}
+
+	// Budi dan Dedi setara (700) sehingga jumlah berjalannya sama
+	sums := map[string]float64{"Fajar": 300, "Andi": 800, "Budi": 2200, "Dedi": 2200}
+	for _, row := range results {
+		if row[1] != sums[row[0].(string)] {
+			t.Errorf("%s: expected running sum %v, got %v", row[0], sums[row[0].(string)], row[1])
+		}
+		if row[2] != 550.0 {
+			t.Errorf("%s: expected partition avg 550, got %v", row[0], row[2])
+		}
+		if row[3] != int64(4) {
+			t.Errorf("%s: expected count 4, got %v", row[0], row[3])
+		}
+	}
+}
+
+func TestWindowLagLead(t *testing.T) {
+	db := setupGajiDivisi(t)
+	defer db.Close()
+
+	results, err := db.Query("PILIH nama, LAG(gaji) OVER (URUTKAN BERDASARKAN nama), LEAD(nama, 2, '-') OVER (URUTKAN BERDASARKAN nama), gaji - LAG(gaji) OVER (URUTKAN BERDASARKAN nama) SEBAGAI selisih DARI karyawan")
+	if err != nil {
+		t.Fatalf("Window query failed: %v", err)
+	}
+
+	if results[0][0] != "Andi" || results[0][1] != nil || results[0][2] != "Citra" || results[0][3] != nil {
+		t.Fatalf("Unexpected first row %v", results[0])
+	}
+	if results[1][1] != 500 || results[1][3] != int64(200) {
+		t.Fatalf("Unexpected second row %v", results[1])
+	}
+	if results[5][0] != "Fajar" || results[5][2] != "-" {
+		t.Fatalf("Unexpected last row %v", results[5])
+	}
+
+	if _, err := db.Query("PILIH nama DARI karyawan DIMANA ROW_NUMBER() OVER () = 1"); err == nil {
+		t.Fatal("Expected error using a window function in DIMANA")
+	}
+	if _, err := db.Query("PILIH SUM(gaji) DARI karyawan"); err == nil {
+		t.Fatal("Expected error for aggregate without OVER")
+	}
+}